@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/routes"
+)
+
+const (
+	sourcePath     = "spec/openapi.yaml"
+	componentsPath = "spec/openapi-v2-components.yaml"
+	outputPath     = "spec/openapi-v2.yaml"
+)
+
+// Generates the v2 OpenAPI document from the v1 document, the v2 components and the v2 routes.
+func main() {
+	source, err := os.ReadFile(sourcePath)
+	if err != nil {
+		log.Fatalf("Unable to read %s: %v", sourcePath, err)
+	}
+	components, err := os.ReadFile(componentsPath)
+	if err != nil {
+		log.Fatalf("Unable to read %s: %v", componentsPath, err)
+	}
+
+	document, err := routes.GenerateV2Spec(source, components)
+	if err != nil {
+		log.Fatalf("Unable to generate the v2 document: %v", err)
+	}
+	if err := os.WriteFile(outputPath, document, 0644); err != nil {
+		log.Fatalf("Unable to write %s: %v", outputPath, err)
+	}
+	log.Printf("Generated %s", outputPath)
+}
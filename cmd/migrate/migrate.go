@@ -5,6 +5,7 @@ import (
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -26,6 +27,8 @@ func main() {
 	var bundleRes *gorm.DB
 	var visitedRes *gorm.DB
 	var activeWorkspaceRes *gorm.DB
+	var bundleVisitsRes *gorm.DB
+	var favoritePositionsRes *gorm.DB
 	tx := database.DB.Begin().Session(&gorm.Session{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
@@ -107,7 +110,7 @@ func main() {
 	}
 
 	fmt.Println("Auto migrate relations")
-	if err := tx.AutoMigrate(&models.FavoritePage{}, &models.UserIdentity{}, &models.SelfReport{}, &models.ProductOfInterest{}, &models.DashboardTemplate{}); err != nil {
+	if err := tx.AutoMigrate(&models.FavoritePage{}, &models.FavoriteFolder{}, &models.UserIdentity{}, &models.SelfReport{}, &models.ProductOfInterest{}, &models.DashboardTemplate{}, &models.UserPreference{}, &models.Announcement{}, &models.AnnouncementDismissal{}, &models.DrawerEvent{}, &models.DrawerEventRecipient{}, &models.DrawerItemState{}, &models.QuickstartProgress{}, &models.OrgDashboardTemplate{}, &models.OrgStarterFavorite{}, &models.RateLimitBucket{}, &models.IdempotencyRecord{}, &models.AuditEvent{}); err != nil {
 		fmt.Println("Unable to migrate database!", err)
 		tx.Rollback()
 		panic(err)
@@ -131,6 +134,35 @@ func main() {
 		panic(bundleRes.Error)
 	}
 
+	fmt.Println("Migrate visited bundles to bundle visits history")
+	// Legacy entries only know that a bundle was visited, timestamps are left empty
+	bundleVisitsRes = tx.Exec(`UPDATE user_identities SET bundle_visits = COALESCE((
+		SELECT jsonb_object_agg(key, jsonb_build_object('bundle', key, 'firstVisit', NULL, 'lastVisit', NULL, 'visitCount', 1, 'returningAfterDays', 0))
+		FROM jsonb_each(visited_bundles) WHERE value = 'true'::jsonb
+	), '{}'::jsonb) WHERE bundle_visits IS NULL`)
+	if bundleVisitsRes.Error != nil {
+		fmt.Println("Unable to migrate database!", bundleVisitsRes.Error.Error())
+		tx.Rollback()
+		panic(bundleVisitsRes.Error)
+	}
+
+	fmt.Println("Seed favorite pages positions")
+	// Users without any manual order get their favorites ordered by creation time
+	favoritePositionsRes = tx.Exec(`UPDATE favorite_pages SET position = ordered.row_number - 1
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY user_identity_id ORDER BY created_at, id) AS row_number
+			FROM favorite_pages WHERE folder_id IS NULL
+		) AS ordered
+		WHERE favorite_pages.id = ordered.id
+		AND favorite_pages.user_identity_id IN (
+			SELECT user_identity_id FROM favorite_pages GROUP BY user_identity_id HAVING COALESCE(MAX(position), 0) = 0 AND COUNT(*) > 1
+		)`)
+	if favoritePositionsRes.Error != nil {
+		fmt.Println("Unable to migrate database!", favoritePositionsRes.Error.Error())
+		tx.Rollback()
+		panic(favoritePositionsRes.Error)
+	}
+
 	fmt.Println("Seed default value to last visited pages")
 	visitedRes = tx.Model(&models.UserIdentity{}).Where("last_visited_pages IS NULL").Update("last_visited_pages", []byte(`[]`))
 	if visitedRes.Error != nil {
@@ -166,6 +198,14 @@ func main() {
 		logrus.Infof("Migrated %d user identity bundles rows", bundleRes.RowsAffected)
 	}
 
+	if bundleVisitsRes.RowsAffected > 0 {
+		logrus.Infof("Migrated %d user identity bundle visits rows", bundleVisitsRes.RowsAffected)
+	}
+
+	if favoritePositionsRes.RowsAffected > 0 {
+		logrus.Infof("Migrated %d favorite pages positions", favoritePositionsRes.RowsAffected)
+	}
+
 	if visitedRes.RowsAffected > 0 {
 		logrus.Infof("Migrated %d user identity visited rows", visitedRes.RowsAffected)
 	}
@@ -203,5 +243,15 @@ func main() {
 		}
 	}
 
+	// Favorites are read without being rewritten, the favorites of moved routes are rewritten here.
+	// Redirected favorites no longer match the redirects so this is safe to run on every startup.
+	fmt.Println("Redirecting favorite pages to their new route")
+	redirected, err := service.RedirectFavoritePages()
+	if err != nil {
+		logrus.Errorf("Unable to redirect favorite pages: %v", err)
+	} else if redirected > 0 {
+		logrus.Infof("Redirected %d favorite pages to their new route", redirected)
+	}
+
 	logrus.Info("Migration complete")
 }
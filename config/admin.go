@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// AdminConfig lists the users allowed to use the admin routes, for example to manage console
+// announcements. Users are listed by user ID, associates by email and service accounts by client ID.
+type AdminConfig struct {
+	Allowlist []string
+}
+
+func parseUserList(value string) []string {
+	users := []string{}
+	for _, user := range strings.Split(value, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func loadAdminConfig() AdminConfig {
+	return AdminConfig{
+		Allowlist: parseUserList(os.Getenv("ADMIN_ALLOWLIST")),
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ApiVersionsConfig describes the deprecation of the v1 API, announced on every v1 response. The dates
+// are read from API_V1_DEPRECATED_AT and API_V1_SUNSET_AT in RFC 3339, a date that is not set is not
+// announced.
+type ApiVersionsConfig struct {
+	V1DeprecatedAt time.Time
+	V1SunsetAt     time.Time
+}
+
+func parseApiVersionDate(name string) time.Time {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logrus.Errorf("Invalid %s %q, expected an RFC 3339 date: %v", name, value, err)
+		return time.Time{}
+	}
+	return date
+}
+
+func loadApiVersionsConfig() ApiVersionsConfig {
+	return ApiVersionsConfig{
+		V1DeprecatedAt: parseApiVersionDate("API_V1_DEPRECATED_AT"),
+		V1SunsetAt:     parseApiVersionDate("API_V1_SUNSET_AT"),
+	}
+}
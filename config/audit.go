@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultAuditRetention is how long security events are kept in the audit trail.
+const DefaultAuditRetention = 90 * 24 * time.Hour
+
+// AuditConfig controls the audit trail persisted from security events. It is on unless
+// AUDIT_TRAIL_ENABLED is "false".
+type AuditConfig struct {
+	Enabled   bool
+	Retention time.Duration
+}
+
+func loadAuditConfig() AuditConfig {
+	auditConfig := AuditConfig{
+		Enabled:   os.Getenv("AUDIT_TRAIL_ENABLED") != "false",
+		Retention: DefaultAuditRetention,
+	}
+	if days, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil && days > 0 {
+		auditConfig.Retention = time.Duration(days) * 24 * time.Hour
+	}
+	return auditConfig
+}
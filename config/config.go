@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/joho/godotenv"
@@ -16,6 +17,10 @@ import (
 // workspaces" we want to store in Chrome's database.
 const DefaultMaximumNumberRecentlyUsedWorkspaces = 10
 
+// DefaultMaximumNumberLastVisitedPages sets a default number for the maximum amount of last visited pages stored
+// per user. The same default applies per bundle, so a single bundle can fill the whole history unless configured otherwise.
+const DefaultMaximumNumberLastVisitedPages = 10
+
 type KafkaSSLCfg struct {
 	KafkaCA       string
 	KafkaUsername string
@@ -31,56 +36,6 @@ type KafkaCfg struct {
 	BrokerConfig   clowder.BrokerConfig
 }
 
-type IntercomConfig struct {
-	fallback                string
-	acs                     string
-	acs_dev                 string
-	ansible                 string
-	ansible_dev             string
-	openshift               string
-	openshift_dev           string
-	ansibleDashboard        string
-	ansibleDashboard_dev    string
-	automationHub           string
-	automationHub_dev       string
-	automationAnalytics     string
-	automationAnalytics_dev string
-	dbaas                   string
-	dbaas_dev               string
-	activationKeys          string
-	activationKeys_dev      string
-	advisor                 string
-	advisor_dev             string
-	compliance              string
-	compliance_dev          string
-	connector               string
-	connector_dev           string
-	contentSources          string
-	contentSources_dev      string
-	dashboard               string
-	dashboard_dev           string
-	imageBuilder            string
-	imageBuilder_dev        string
-	inventory               string
-	inventory_dev           string
-	malware                 string
-	malware_dev             string
-	patch                   string
-	patch_dev               string
-	policies                string
-	policies_dev            string
-	registration            string
-	registration_dev        string
-	remediations            string
-	remediations_dev        string
-	ros                     string
-	ros_dev                 string
-	tasks                   string
-	tasks_dev               string
-	vulnerability           string
-	vulnerability_dev       string
-}
-
 type FeatureFlagsConfig struct {
 	ClientAccessToken string
 	Hostname          string
@@ -106,9 +61,33 @@ type WidgetDashboardConfig struct {
 	TemplatesWD string
 }
 
+type IdentityCacheConfig struct {
+	// Backend is either "memory" or "shared"
+	Backend         string
+	MaxEntries      int
+	TTL             time.Duration
+	JanitorInterval time.Duration
+}
+
+type LastVisitedConfig struct {
+	MaxPages          int
+	MaxPagesPerBundle int
+}
+
+// UserLifecycleConfig defines when a request starts a new session and when the onboarding flags are cleared.
+type UserLifecycleConfig struct {
+	// SessionWindow is the minimum time between two recorded logins
+	SessionWindow time.Duration
+	// DayOneDuration is how long after the account creation DayOne stays set
+	DayOneDuration time.Duration
+	// HistoryDays is how long the login history is kept
+	HistoryDays int
+}
+
 type ChromeServiceConfig struct {
 	WebPort                             int
 	OpenApiSpecPath                     string
+	OpenApiV2SpecPath                   string
 	DbHost                              string
 	DbUser                              string
 	DbPassword                          string
@@ -125,6 +104,20 @@ type ChromeServiceConfig struct {
 	FeatureFlagConfig                   FeatureFlagsConfig
 	DebugConfig                         DebugConfig
 	DashboardConfig                     WidgetDashboardConfig
+	IdentityCacheConfig                 IdentityCacheConfig
+	LastVisitedConfig                   LastVisitedConfig
+	UserLifecycleConfig                 UserLifecycleConfig
+	FavoritesConfig                     FavoritesConfig
+	PreferencesConfig                   PreferencesConfig
+	PreviewFeaturesConfig               PreviewFeaturesConfig
+	AdminConfig                         AdminConfig
+	WorkspacesConfig                    WorkspacesConfig
+	RequestValidationConfig             RequestValidationConfig
+	RateLimitConfig                     RateLimitConfig
+	ServerConfig                        ServerConfig
+	IdempotencyConfig                   IdempotencyConfig
+	AuditConfig                         AuditConfig
+	ApiVersionsConfig                   ApiVersionsConfig
 	MaximumNumberRecentlyUsedWorkspaces int
 }
 
@@ -255,55 +248,20 @@ func init() {
 	}
 
 	// env variables from .env or pod env variables
-	options.IntercomConfig = IntercomConfig{
-		fallback:                os.Getenv("INTERCOM_DEFAULT"),
-		acs:                     os.Getenv("INTERCOM_ACS"),
-		acs_dev:                 os.Getenv("INTERCOM_ACS_DEV"),
-		ansible:                 os.Getenv("INTERCOM_ANSIBLE"),
-		ansible_dev:             os.Getenv("INTERCOM_ANSIBLE_DEV"),
-		ansibleDashboard:        os.Getenv("INTERCOM_ANSIBLE"),
-		ansibleDashboard_dev:    os.Getenv("INTERCOM_ANSIBLE_DEV"),
-		automationHub:           os.Getenv("INTERCOM_ANSIBLE"),
-		automationHub_dev:       os.Getenv("INTERCOM_ANSIBLE_DEV"),
-		automationAnalytics:     os.Getenv("INTERCOM_ANSIBLE"),
-		automationAnalytics_dev: os.Getenv("INTERCOM_ANSIBLE_DEV"),
-		openshift:               os.Getenv("INTERCOM_OPENSHIFT"),
-		openshift_dev:           os.Getenv("INTERCOM_OPENSHIFT_DEV"),
-		dbaas:                   os.Getenv("INTERCOM_DBAAS"),
-		dbaas_dev:               os.Getenv("INTERCOM_DBAAS_DEV"),
-		activationKeys:          os.Getenv("INTERCOM_INSIGHTS"),
-		activationKeys_dev:      os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		advisor:                 os.Getenv("INTERCOM_INSIGHTS"),
-		advisor_dev:             os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		compliance:              os.Getenv("INTERCOM_INSIGHTS"),
-		compliance_dev:          os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		connector:               os.Getenv("INTERCOM_INSIGHTS"),
-		connector_dev:           os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		contentSources:          os.Getenv("INTERCOM_INSIGHTS"),
-		contentSources_dev:      os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		dashboard:               os.Getenv("INTERCOM_INSIGHTS"),
-		dashboard_dev:           os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		imageBuilder:            os.Getenv("INTERCOM_INSIGHTS"),
-		imageBuilder_dev:        os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		inventory:               os.Getenv("INTERCOM_INSIGHTS"),
-		inventory_dev:           os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		malware:                 os.Getenv("INTERCOM_INSIGHTS"),
-		malware_dev:             os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		patch:                   os.Getenv("INTERCOM_INSIGHTS"),
-		patch_dev:               os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		policies:                os.Getenv("INTERCOM_INSIGHTS"),
-		policies_dev:            os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		registration:            os.Getenv("INTERCOM_INSIGHTS"),
-		registration_dev:        os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		remediations:            os.Getenv("INTERCOM_INSIGHTS"),
-		remediations_dev:        os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		ros:                     os.Getenv("INTERCOM_INSIGHTS"),
-		ros_dev:                 os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		tasks:                   os.Getenv("INTERCOM_INSIGHTS"),
-		tasks_dev:               os.Getenv("INTERCOM_INSIGHTS_DEV"),
-		vulnerability:           os.Getenv("INTERCOM_INSIGHTS"),
-		vulnerability_dev:       os.Getenv("INTERCOM_INSIGHTS_DEV"),
-	}
+	options.IntercomConfig = loadIntercomConfig()
+	options.FavoritesConfig = loadFavoritesConfig()
+	options.PreferencesConfig = loadPreferencesConfig()
+	options.PreviewFeaturesConfig = loadPreviewFeaturesConfig()
+	options.AdminConfig = loadAdminConfig()
+	options.WorkspacesConfig = loadWorkspacesConfig()
+	options.RequestValidationConfig = loadRequestValidationConfig()
+	options.RateLimitConfig = loadRateLimitConfig()
+	options.ServerConfig = loadServerConfig()
+	options.IdempotencyConfig = loadIdempotencyConfig(options.ServerConfig.WriteTimeout)
+	options.AuditConfig = loadAuditConfig()
+	options.ApiVersionsConfig = loadApiVersionsConfig()
+	options.OpenApiSpecPath = loadOpenApiSpecPath()
+	options.OpenApiV2SpecPath = loadOpenApiV2SpecPath()
 
 	options.DebugConfig = DebugConfig{
 		DebugFavoriteIds: []string{"", os.Getenv("DEBUG_FAVORITES_ACCOUNT_1")},
@@ -316,6 +274,51 @@ func init() {
 		options.DashboardConfig.TemplatesWD = "/"
 	}
 
+	options.IdentityCacheConfig = IdentityCacheConfig{
+		Backend:         os.Getenv("IDENTITY_CACHE_BACKEND"),
+		MaxEntries:      util.DefaultIdentityCacheMaxEntries,
+		TTL:             util.DefaultIdentityCacheTTL,
+		JanitorInterval: util.DefaultIdentityCacheJanitorInterval,
+	}
+	if options.IdentityCacheConfig.Backend == "" {
+		options.IdentityCacheConfig.Backend = util.IdentityCacheMemory
+	}
+	if maxEntries, err := strconv.Atoi(os.Getenv("IDENTITY_CACHE_MAX_ENTRIES")); err == nil {
+		options.IdentityCacheConfig.MaxEntries = maxEntries
+	}
+	if ttl, err := strconv.Atoi(os.Getenv("IDENTITY_CACHE_TTL_SECONDS")); err == nil {
+		options.IdentityCacheConfig.TTL = time.Duration(ttl) * time.Second
+	}
+	if janitor, err := strconv.Atoi(os.Getenv("IDENTITY_CACHE_JANITOR_SECONDS")); err == nil {
+		options.IdentityCacheConfig.JanitorInterval = time.Duration(janitor) * time.Second
+	}
+
+	options.LastVisitedConfig = LastVisitedConfig{
+		MaxPages:          DefaultMaximumNumberLastVisitedPages,
+		MaxPagesPerBundle: DefaultMaximumNumberLastVisitedPages,
+	}
+	if maxPages, err := strconv.Atoi(os.Getenv("LAST_VISITED_MAX_PAGES")); err == nil && maxPages > 0 {
+		options.LastVisitedConfig.MaxPages = maxPages
+	}
+	if maxPages, err := strconv.Atoi(os.Getenv("LAST_VISITED_MAX_PAGES_PER_BUNDLE")); err == nil && maxPages > 0 {
+		options.LastVisitedConfig.MaxPagesPerBundle = maxPages
+	}
+
+	options.UserLifecycleConfig = UserLifecycleConfig{
+		SessionWindow:  8 * time.Hour,
+		DayOneDuration: 24 * time.Hour,
+		HistoryDays:    30,
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("USER_SESSION_WINDOW_MINUTES")); err == nil && minutes > 0 {
+		options.UserLifecycleConfig.SessionWindow = time.Duration(minutes) * time.Minute
+	}
+	if hours, err := strconv.Atoi(os.Getenv("USER_DAY_ONE_HOURS")); err == nil && hours > 0 {
+		options.UserLifecycleConfig.DayOneDuration = time.Duration(hours) * time.Hour
+	}
+	if days, err := strconv.Atoi(os.Getenv("USER_LOGIN_HISTORY_DAYS")); err == nil && days > 0 {
+		options.UserLifecycleConfig.HistoryDays = days
+	}
+
 	config = options
 }
 
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FavoritesConfig holds the optional redirect map for favorites whose route moved in the navigation.
+// Keys and values are pathnames, for example {"/insights/old-app": "/insights/new-app"}.
+type FavoritesConfig struct {
+	Redirects map[string]string
+	// ExportSecret signs exported favorites documents. It has to be shared by all environments
+	// that import each other's documents.
+	ExportSecret string
+}
+
+func loadFavoritesConfig() FavoritesConfig {
+	favoritesConfig := FavoritesConfig{
+		Redirects:    map[string]string{},
+		ExportSecret: os.Getenv("FAVORITES_EXPORT_SECRET"),
+	}
+	data := []byte(os.Getenv("FAVORITES_REDIRECTS"))
+	if file := os.Getenv("FAVORITES_REDIRECTS_FILE"); len(data) == 0 && file != "" {
+		fileData, err := os.ReadFile(file)
+		if err != nil {
+			logrus.Errorf("Unable to read favorites redirects %s: %v", file, err)
+			return favoritesConfig
+		}
+		data = fileData
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return favoritesConfig
+	}
+
+	if err := json.Unmarshal(data, &favoritesConfig.Redirects); err != nil {
+		logrus.Errorf("Unable to parse favorites redirects: %v", err)
+		favoritesConfig.Redirects = map[string]string{}
+	}
+	return favoritesConfig
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultIdempotencyKeyTTL is how long the response to a request with an Idempotency-Key is replayed.
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+// DefaultIdempotencyLockTimeout is how long the key of a request that never completed stays reserved.
+const DefaultIdempotencyLockTimeout = 5 * time.Minute
+
+type IdempotencyConfig struct {
+	TTL time.Duration
+	// LockTimeout releases keys of requests that never completed, for example when the replica handling
+	// them stopped. It has to be longer than the server write timeout, or a key is released while its
+	// request is still being handled.
+	LockTimeout time.Duration
+}
+
+func loadIdempotencyConfig(writeTimeout time.Duration) IdempotencyConfig {
+	idempotencyConfig := IdempotencyConfig{TTL: DefaultIdempotencyKeyTTL, LockTimeout: DefaultIdempotencyLockTimeout}
+	if hours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_KEY_TTL_HOURS")); err == nil && hours > 0 {
+		idempotencyConfig.TTL = time.Duration(hours) * time.Hour
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_LOCK_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		idempotencyConfig.LockTimeout = time.Duration(seconds) * time.Second
+	}
+	if idempotencyConfig.LockTimeout <= writeTimeout {
+		logrus.Errorf("Idempotency lock timeout %s is not longer than the server write timeout %s, using %s", idempotencyConfig.LockTimeout, writeTimeout, 2*writeTimeout)
+		idempotencyConfig.LockTimeout = 2 * writeTimeout
+	}
+	return idempotencyConfig
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed intercom-apps.yaml
+var defaultIntercomApps []byte
+
+// IntercomAppConfig maps an Intercom app to the environment variables holding its secrets.
+type IntercomAppConfig struct {
+	Name    string   `yaml:"name" json:"name"`
+	ProdEnv string   `yaml:"prodEnv" json:"prodEnv"`
+	DevEnv  string   `yaml:"devEnv" json:"devEnv"`
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+type IntercomSecrets struct {
+	Prod string
+	Dev  string
+}
+
+type IntercomConfig struct {
+	Apps []IntercomAppConfig
+	// Source describes where the registry was loaded from
+	Source string
+	// Problems lists every misconfiguration found while loading the registry
+	Problems []string
+	// secrets are indexed by app names and aliases
+	secrets map[string]IntercomSecrets
+}
+
+// Lookup returns the secrets for an app name or one of its aliases.
+func (ic IntercomConfig) Lookup(app string) (IntercomSecrets, bool) {
+	secrets, ok := ic.secrets[app]
+	return secrets, ok
+}
+
+// ParseIntercomApps parses the app registry. JSON is accepted as well as it is a subset of YAML.
+func ParseIntercomApps(data []byte) ([]IntercomAppConfig, error) {
+	var apps []IntercomAppConfig
+	err := yaml.Unmarshal(data, &apps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid intercom app registry: %w", err)
+	}
+	return apps, nil
+}
+
+// NewIntercomConfig resolves the secrets of every registered app. Problems are collected
+// instead of failing, so one broken entry does not disable Intercom for every app.
+func NewIntercomConfig(apps []IntercomAppConfig, source string, getenv func(string) string) IntercomConfig {
+	ic := IntercomConfig{
+		Apps:     apps,
+		Source:   source,
+		Problems: []string{},
+		secrets:  make(map[string]IntercomSecrets),
+	}
+
+	resolve := func(app string, kind string, envName string) string {
+		if envName == "" {
+			ic.Problems = append(ic.Problems, fmt.Sprintf("intercom app %s has no %s secret variable configured", app, kind))
+			return ""
+		}
+		value := getenv(envName)
+		if value == "" {
+			ic.Problems = append(ic.Problems, fmt.Sprintf("intercom app %s %s secret variable %s is missing or empty", app, kind, envName))
+		}
+		return value
+	}
+
+	for i, app := range apps {
+		if app.Name == "" {
+			ic.Problems = append(ic.Problems, fmt.Sprintf("intercom app at position %d has no name", i))
+			continue
+		}
+		secrets := IntercomSecrets{
+			Prod: resolve(app.Name, "prod", app.ProdEnv),
+			Dev:  resolve(app.Name, "dev", app.DevEnv),
+		}
+		for _, name := range append([]string{app.Name}, app.Aliases...) {
+			if _, ok := ic.secrets[name]; ok {
+				ic.Problems = append(ic.Problems, fmt.Sprintf("intercom app name %s is registered more than once", name))
+				continue
+			}
+			ic.secrets[name] = secrets
+		}
+	}
+
+	return ic
+}
+
+// loadIntercomConfig reads the registry from INTERCOM_APPS, INTERCOM_APPS_FILE or the embedded default, in that order.
+func loadIntercomConfig() IntercomConfig {
+	source := "embedded intercom-apps.yaml"
+	data := defaultIntercomApps
+	if env := os.Getenv("INTERCOM_APPS"); env != "" {
+		source = "INTERCOM_APPS"
+		data = []byte(env)
+	} else if file := os.Getenv("INTERCOM_APPS_FILE"); file != "" {
+		source = file
+		fileData, err := os.ReadFile(file)
+		if err != nil {
+			return IntercomConfig{
+				Source:   source,
+				Problems: []string{fmt.Sprintf("unable to read intercom app registry %s: %v", file, err)},
+			}
+		}
+		data = fileData
+	}
+
+	apps, err := ParseIntercomApps(data)
+	if err != nil {
+		return IntercomConfig{Source: source, Problems: []string{err.Error()}}
+	}
+	return NewIntercomConfig(apps, source, os.Getenv)
+}
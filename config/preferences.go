@@ -0,0 +1,82 @@
+package config
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultPreferencesMaxSize = 16 * 1024
+
+//go:embed preferences/*.json
+var defaultPreferencesSchemas embed.FS
+
+var preferencesNamespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// PreferencesConfig holds the JSON Schema of every registered preferences namespace, indexed by
+// namespace name. The namespace name is the schema file name without the .json extension.
+type PreferencesConfig struct {
+	Schemas map[string][]byte
+	// MaxSize is the maximum size in bytes of a namespace document
+	MaxSize int
+}
+
+// IsValidPreferencesNamespace checks the namespace is usable in URLs and file names.
+func IsValidPreferencesNamespace(namespace string) bool {
+	return preferencesNamespacePattern.MatchString(namespace)
+}
+
+func addPreferencesSchema(schemas map[string][]byte, name string, data []byte) {
+	namespace := strings.TrimSuffix(filepath.Base(name), ".json")
+	if !IsValidPreferencesNamespace(namespace) {
+		logrus.Errorf("Ignoring preferences schema %s, invalid namespace name %s", name, namespace)
+		return
+	}
+	schemas[namespace] = data
+}
+
+// loadPreferencesConfig reads the embedded schemas. Schemas from the PREFERENCES_SCHEMAS_DIR
+// directory are added to them and replace embedded schemas with the same name.
+func loadPreferencesConfig() PreferencesConfig {
+	preferencesConfig := PreferencesConfig{
+		Schemas: map[string][]byte{},
+		MaxSize: DefaultPreferencesMaxSize,
+	}
+	if maxSize, err := strconv.Atoi(os.Getenv("PREFERENCES_MAX_SIZE")); err == nil && maxSize > 0 {
+		preferencesConfig.MaxSize = maxSize
+	}
+
+	embedded, _ := defaultPreferencesSchemas.ReadDir("preferences")
+	for _, entry := range embedded {
+		data, err := defaultPreferencesSchemas.ReadFile("preferences/" + entry.Name())
+		if err != nil {
+			logrus.Errorf("Unable to read embedded preferences schema %s: %v", entry.Name(), err)
+			continue
+		}
+		addPreferencesSchema(preferencesConfig.Schemas, entry.Name(), data)
+	}
+
+	dir := os.Getenv("PREFERENCES_SCHEMAS_DIR")
+	if dir == "" {
+		return preferencesConfig
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		logrus.Errorf("Unable to list preferences schemas in %s: %v", dir, err)
+		return preferencesConfig
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			logrus.Errorf("Unable to read preferences schema %s: %v", file, err)
+			continue
+		}
+		addPreferencesSchema(preferencesConfig.Schemas, file, data)
+	}
+	return preferencesConfig
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed preview-features.yaml
+var defaultPreviewFeatures []byte
+
+// PreviewFeature describes a preview users can enroll into.
+type PreviewFeature struct {
+	Name        string     `yaml:"name" json:"name"`
+	Title       string     `yaml:"title" json:"title"`
+	Description string     `yaml:"description" json:"description"`
+	FeatureFlag string     `yaml:"featureFlag,omitempty" json:"featureFlag,omitempty"`
+	StartDate   *time.Time `yaml:"startDate,omitempty" json:"startDate,omitempty"`
+	EndDate     *time.Time `yaml:"endDate,omitempty" json:"endDate,omitempty"`
+}
+
+// IsActive reports whether the preview is offered at the given time.
+func (pf PreviewFeature) IsActive(now time.Time) bool {
+	if pf.StartDate != nil && now.Before(*pf.StartDate) {
+		return false
+	}
+	if pf.EndDate != nil && !now.Before(*pf.EndDate) {
+		return false
+	}
+	return true
+}
+
+type PreviewFeaturesConfig struct {
+	Features []PreviewFeature
+}
+
+// Lookup returns the catalog entry of a preview feature.
+func (pc PreviewFeaturesConfig) Lookup(name string) (PreviewFeature, bool) {
+	for _, feature := range pc.Features {
+		if feature.Name == name {
+			return feature, true
+		}
+	}
+	return PreviewFeature{}, false
+}
+
+// ParsePreviewFeatures parses the preview catalog. JSON is accepted as well as it is a subset of YAML.
+func ParsePreviewFeatures(data []byte) ([]PreviewFeature, error) {
+	var features []PreviewFeature
+	if err := yaml.Unmarshal(data, &features); err != nil {
+		return nil, fmt.Errorf("invalid preview features catalog: %w", err)
+	}
+	names := make(map[string]bool, len(features))
+	for i, feature := range features {
+		if feature.Name == "" {
+			return nil, fmt.Errorf("preview feature at position %d has no name", i)
+		}
+		if names[feature.Name] {
+			return nil, fmt.Errorf("duplicate preview feature %s", feature.Name)
+		}
+		if feature.StartDate != nil && feature.EndDate != nil && !feature.EndDate.After(*feature.StartDate) {
+			return nil, fmt.Errorf("preview feature %s ends before it starts", feature.Name)
+		}
+		names[feature.Name] = true
+	}
+	return features, nil
+}
+
+func loadPreviewFeaturesConfig() PreviewFeaturesConfig {
+	source := "embedded preview-features.yaml"
+	data := defaultPreviewFeatures
+	if env := os.Getenv("PREVIEW_FEATURES"); env != "" {
+		source = "PREVIEW_FEATURES"
+		data = []byte(env)
+	} else if file := os.Getenv("PREVIEW_FEATURES_FILE"); file != "" {
+		source = file
+		fileData, err := os.ReadFile(file)
+		if err != nil {
+			logrus.Errorf("Unable to read preview features catalog %s: %v", file, err)
+			return PreviewFeaturesConfig{Features: []PreviewFeature{}}
+		}
+		data = fileData
+	}
+
+	features, err := ParsePreviewFeatures(data)
+	if err != nil {
+		logrus.Errorf("Unable to load preview features from %s: %v", source, err)
+		return PreviewFeaturesConfig{Features: []PreviewFeature{}}
+	}
+	return PreviewFeaturesConfig{Features: features}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+)
+
+// RateLimitConfig holds the token bucket limits of the API. Each request takes a token from the bucket
+// of its user and from the bucket of its organization, reads and writes have separate buckets.
+type RateLimitConfig struct {
+	Enabled bool
+	// Backend is either "memory" or "shared"
+	Backend   string
+	UserRead  util.RateLimit
+	UserWrite util.RateLimit
+	OrgRead   util.RateLimit
+	OrgWrite  util.RateLimit
+}
+
+var (
+	DefaultUserReadRateLimit  = util.RateLimit{PerMinute: 600, Burst: 120}
+	DefaultUserWriteRateLimit = util.RateLimit{PerMinute: 120, Burst: 30}
+	DefaultOrgReadRateLimit   = util.RateLimit{PerMinute: 12000, Burst: 2000}
+	DefaultOrgWriteRateLimit  = util.RateLimit{PerMinute: 3000, Burst: 500}
+)
+
+// parseRateLimit reads a "<per minute>[:<burst>]" limit, invalid values keep the default. A zero per
+// minute value disables the limit.
+func parseRateLimit(value string, defaultLimit util.RateLimit) util.RateLimit {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultLimit
+	}
+	perMinute, burst, _ := strings.Cut(value, ":")
+	limit := util.RateLimit{}
+	var err error
+	if limit.PerMinute, err = strconv.Atoi(perMinute); err != nil || limit.PerMinute < 0 {
+		return defaultLimit
+	}
+	if burst != "" {
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 0 {
+			return defaultLimit
+		}
+	}
+	return limit
+}
+
+func loadRateLimitConfig() RateLimitConfig {
+	rateLimitConfig := RateLimitConfig{
+		Enabled:   os.Getenv("RATE_LIMIT_ENABLED") != "false",
+		Backend:   os.Getenv("RATE_LIMIT_BACKEND"),
+		UserRead:  parseRateLimit(os.Getenv("RATE_LIMIT_USER_READ"), DefaultUserReadRateLimit),
+		UserWrite: parseRateLimit(os.Getenv("RATE_LIMIT_USER_WRITE"), DefaultUserWriteRateLimit),
+		OrgRead:   parseRateLimit(os.Getenv("RATE_LIMIT_ORG_READ"), DefaultOrgReadRateLimit),
+		OrgWrite:  parseRateLimit(os.Getenv("RATE_LIMIT_ORG_WRITE"), DefaultOrgWriteRateLimit),
+	}
+	// the limits have to hold across replicas, the memory backend is meant for local development
+	if rateLimitConfig.Backend == "" {
+		rateLimitConfig.Backend = util.RateLimitShared
+	}
+	return rateLimitConfig
+}
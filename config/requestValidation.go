@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// DefaultOpenApiSpecPath is the OpenAPI document requests are validated against.
+const DefaultOpenApiSpecPath = "spec/openapi.yaml"
+
+// DefaultOpenApiV2SpecPath is the OpenAPI document v2 requests are validated against.
+const DefaultOpenApiV2SpecPath = "spec/openapi-v2.yaml"
+
+const DefaultRequestValidationMaxBodySize = 1024 * 1024
+
+// RequestValidationConfig toggles the validation of requests against the OpenAPI document. It is on
+// unless REQUEST_VALIDATION_ENABLED is "false".
+type RequestValidationConfig struct {
+	Enabled bool
+	// MaxBodySize is the maximum size in bytes of a request body read by the validation
+	MaxBodySize int
+}
+
+func loadRequestValidationConfig() RequestValidationConfig {
+	requestValidationConfig := RequestValidationConfig{
+		Enabled:     os.Getenv("REQUEST_VALIDATION_ENABLED") != "false",
+		MaxBodySize: DefaultRequestValidationMaxBodySize,
+	}
+	if maxBodySize, err := strconv.Atoi(os.Getenv("REQUEST_VALIDATION_MAX_BODY_SIZE")); err == nil && maxBodySize > 0 {
+		requestValidationConfig.MaxBodySize = maxBodySize
+	}
+	return requestValidationConfig
+}
+
+func loadOpenApiSpecPath() string {
+	if path := os.Getenv("OPENAPI_SPEC_PATH"); path != "" {
+		return path
+	}
+	return DefaultOpenApiSpecPath
+}
+
+func loadOpenApiV2SpecPath() string {
+	if path := os.Getenv("OPENAPI_V2_SPEC_PATH"); path != "" {
+		return path
+	}
+	return DefaultOpenApiV2SpecPath
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultServerWriteTimeout bounds the time to handle a request and write its response.
+const DefaultServerWriteTimeout = time.Minute
+
+type ServerConfig struct {
+	WriteTimeout time.Duration
+}
+
+func loadServerConfig() ServerConfig {
+	serverConfig := ServerConfig{WriteTimeout: DefaultServerWriteTimeout}
+	if seconds, err := strconv.Atoi(os.Getenv("SERVER_WRITE_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		serverConfig.WriteTimeout = time.Duration(seconds) * time.Second
+	}
+	return serverConfig
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWorkspacesTimeout bounds a single request to the workspace service.
+const DefaultWorkspacesTimeout = 3 * time.Second
+
+// WorkspacesConfig configures the optional workspace validation. Recently used workspaces are only checked
+// against the workspace service when ServiceURL is set.
+type WorkspacesConfig struct {
+	ServiceURL string
+	Timeout    time.Duration
+}
+
+func loadWorkspacesConfig() WorkspacesConfig {
+	workspacesConfig := WorkspacesConfig{
+		ServiceURL: strings.TrimSuffix(strings.TrimSpace(os.Getenv("WORKSPACES_SERVICE_URL")), "/"),
+		Timeout:    DefaultWorkspacesTimeout,
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("WORKSPACES_SERVICE_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		workspacesConfig.Timeout = time.Duration(seconds) * time.Second
+	}
+	return workspacesConfig
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/RedHatInsights/chrome-service-backend/rest/kafka"
 	"github.com/RedHatInsights/chrome-service-backend/rest/logger"
 	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/openapi"
 	"github.com/RedHatInsights/chrome-service-backend/rest/routes"
 	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
@@ -35,7 +36,7 @@ func init() {
 	godotenv.Load()
 	flag.Parse()
 	database.Init()
-	util.InitUserIdentitiesCache()
+	setupIdentityCache(config.Get())
 	util.CreateChromeConfiguration()
 }
 
@@ -45,6 +46,12 @@ func main() {
 	featureflags.Init(cfg)
 	setupGlobalLogger(cfg)
 	defer logger.FlushCloudWatch()
+	service.ReportIntercomConfiguration()
+	openApiSpec := loadOpenApiSpec(cfg, cfg.OpenApiSpecPath)
+	openApiV2Spec := loadOpenApiSpec(cfg, cfg.OpenApiV2SpecPath)
+	rateLimiter := setupRateLimiter(cfg)
+	go service.RunIdempotencyJanitor(time.Hour)
+	auditSink := setupAuditTrail(cfg)
 	router := chi.NewRouter()
 	metricsRouter := chi.NewRouter()
 
@@ -64,11 +71,11 @@ func main() {
 	// can't be in sub router as we don't enforce identity header
 	router.Handle("/api/chrome-service/v1/static/*", http.StripPrefix("/api/chrome-service/v1/static", fs))
 	router.Handle("/api/chrome-service/v1/spec/*", http.StripPrefix("/api/chrome-service/v1/spec", fsApiSpec))
+	router.Handle(routes.V2BasePath+"/spec/*", http.StripPrefix(routes.V2BasePath+"/spec", fsApiSpec))
 
 	router.Route("/api/chrome-service/v1/", func(subrouter chi.Router) {
-		subrouter.Use(m.ParseHeaders)
-		subrouter.Use(logger.EnrichLoggerWithIdentity)
-		subrouter.Use(m.InjectUser)
+		subrouter.Use(m.Deprecation(cfg.ApiVersionsConfig.V1DeprecatedAt, cfg.ApiVersionsConfig.V1SunsetAt, routes.V2BasePath+"/"))
+		useApiMiddlewares(subrouter, cfg, rateLimiter, openApiSpec)
 		subrouter.Get("/hello-world", HelloWorld)
 		subrouter.Route("/last-visited", routes.MakeLastVisitedRoutes)
 		subrouter.Route("/recently-used-workspaces", routes.MakeRecentlyUsedWorkspacesRoutes)
@@ -77,6 +84,28 @@ func main() {
 		subrouter.Route("/user", routes.MakeUserIdentityRoutes)
 		subrouter.Route("/dashboard-templates", routes.MakeDashboardTemplateRoutes)
 		subrouter.Route("/api-docs", routes.MakeApiDocsRoutes)
+		subrouter.Route("/announcements", routes.MakeAnnouncementsRoutes)
+		subrouter.Route("/notifications/drawer", routes.MakeNotificationDrawerRoutes)
+		subrouter.Route("/quickstarts", routes.MakeQuickstartRoutes)
+		subrouter.Route("/org-defaults", routes.MakeOrgDefaultsRoutes)
+		subrouter.Route("/bootstrap", routes.MakeBootstrapRoutes)
+		subrouter.Route("/audit-events", routes.MakeAuditRoutes)
+	})
+
+	router.Route(routes.V2BasePath, func(subrouter chi.Router) {
+		subrouter.Use(middleware.StripSlashes)
+		useApiMiddlewares(subrouter, cfg, rateLimiter, openApiV2Spec)
+		routes.MakeV2Routes(subrouter)
+	})
+
+	// the support API is used by associates and service accounts, it does not inject a user identity
+	router.Route(routes.SupportBasePath, func(subrouter chi.Router) {
+		subrouter.Use(m.RecoverProblem)
+		subrouter.Use(m.ParseHeaders)
+		subrouter.Use(logger.EnrichLoggerWithIdentity)
+		subrouter.NotFound(m.NotFound)
+		subrouter.MethodNotAllowed(m.MethodNotAllowed)
+		routes.MakeSupportRoutes(subrouter)
 	})
 
 	// We might want to set up some event listeners at some point, but the pod will
@@ -86,6 +115,8 @@ func main() {
 		go connectionhub.ConnectionHub.Run()
 		logrus.Infoln("Enabling WebSockets")
 		kafka.InitializeConsumers()
+		kafka.InitializeProducer()
+		service.SetEventPublisher(kafka.Producer.Publish)
 		router.Route("/wss/chrome-service/v1/", func(subrouter chi.Router) {
 			subrouter.Use(cors.Handler(cors.Options{
 				AllowedOrigins: []string{
@@ -112,7 +143,7 @@ func main() {
 	securitylog.LogStartup("chrome-service-backend", cfg.WebPort)
 
 	serverStringAddr := fmt.Sprintf(":%s", strconv.Itoa(cfg.WebPort))
-	server := &http.Server{Addr: serverStringAddr, Handler: router}
+	server := &http.Server{Addr: serverStringAddr, Handler: router, WriteTimeout: cfg.ServerConfig.WriteTimeout}
 
 	// Handle SIGTERM/SIGINT for graceful shutdown with security logging.
 	// Signal wait is synchronous in main so shutdown completes before exit.
@@ -137,6 +168,10 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Graceful shutdown error: %v", err)
 	}
+	// the requests are drained, write the security events still waiting in the buffer
+	if auditSink != nil {
+		auditSink.Close()
+	}
 	logger.FlushCloudWatch()
 }
 
@@ -148,6 +183,94 @@ func HealthProbe(response http.ResponseWriter, request *http.Request) {
 	response.Write([]byte("Why yes thank you, I am quite healthy :D"))
 }
 
+func setupIdentityCache(cfg *config.ChromeServiceConfig) {
+	cacheCfg := cfg.IdentityCacheConfig
+	options := util.IdentityCacheOptions{
+		MaxEntries:      cacheCfg.MaxEntries,
+		TTL:             cacheCfg.TTL,
+		JanitorInterval: cacheCfg.JanitorInterval,
+	}
+	util.InitUserIdentitiesCache(options)
+	if cacheCfg.Backend != util.IdentityCacheShared {
+		return
+	}
+
+	bus, err := database.NewPgCacheInvalidationBus(cfg)
+	if err != nil {
+		logrus.Errorf("Unable to set up shared identity cache, falling back to memory cache: %v", err)
+		return
+	}
+	sharedCache, err := util.NewSharedIdentityCache(util.NewLRUIdentityCache(options), bus)
+	if err != nil {
+		bus.Close()
+		logrus.Errorf("Unable to set up shared identity cache, falling back to memory cache: %v", err)
+		return
+	}
+	util.SetUserIdentitiesCache(sharedCache)
+	logrus.Infoln("Using shared identity cache")
+}
+
+// useApiMiddlewares sets up the middlewares shared by the API versions, requests are validated against
+// spec when it is set.
+func useApiMiddlewares(subrouter chi.Router, cfg *config.ChromeServiceConfig, rateLimiter util.RateLimiter, spec *openapi.Spec) {
+	subrouter.Use(m.RecoverProblem)
+	subrouter.Use(m.ParseHeaders)
+	subrouter.Use(logger.EnrichLoggerWithIdentity)
+	if rateLimiter != nil {
+		subrouter.Use(m.RateLimit(rateLimiter, cfg.RateLimitConfig))
+	}
+	// invalid requests are rejected before they create or update the identity of the user
+	if spec != nil {
+		subrouter.Use(m.ValidateRequest(spec, int64(cfg.RequestValidationConfig.MaxBodySize)))
+	}
+	subrouter.Use(m.InjectUser)
+	subrouter.Use(m.Idempotency(cfg.IdempotencyConfig, int64(cfg.RequestValidationConfig.MaxBodySize)))
+	subrouter.NotFound(m.NotFound)
+	subrouter.MethodNotAllowed(m.MethodNotAllowed)
+}
+
+// setupRateLimiter returns the limiter of the configured backend, the shared backend keeps the buckets
+// in the database so every replica enforces the same limits.
+func setupRateLimiter(cfg *config.ChromeServiceConfig) util.RateLimiter {
+	if !cfg.RateLimitConfig.Enabled {
+		logrus.Infoln("Rate limiting is disabled")
+		return nil
+	}
+	if cfg.RateLimitConfig.Backend == util.RateLimitShared {
+		logrus.Infoln("Using shared rate limiter")
+		return database.NewDBRateLimiter(util.DefaultRateLimitJanitorInterval)
+	}
+	return util.NewMemoryRateLimiter(util.DefaultRateLimitJanitorInterval)
+}
+
+// setupAuditTrail persists the security events to the audit trail and removes the events older than
+// the retention.
+func setupAuditTrail(cfg *config.ChromeServiceConfig) *database.AsyncAuditSink {
+	if !cfg.AuditConfig.Enabled {
+		logrus.Infoln("Audit trail is disabled")
+		return nil
+	}
+	sink := database.NewAsyncAuditSink(database.AuditSinkOptions{})
+	securitylog.RegisterSink(sink)
+	go service.RunAuditRetention(time.Hour, cfg.AuditConfig.Retention)
+	return sink
+}
+
+// loadOpenApiSpec compiles the document at path requests are validated against. A broken document
+// disables the validation instead of failing the service.
+func loadOpenApiSpec(cfg *config.ChromeServiceConfig, path string) *openapi.Spec {
+	if !cfg.RequestValidationConfig.Enabled {
+		logrus.Infoln("Request validation is disabled")
+		return nil
+	}
+	spec, err := openapi.Load(path)
+	if err != nil {
+		logrus.Errorf("Unable to load the OpenAPI document %s, request validation is disabled: %v", path, err)
+		return nil
+	}
+	return spec
+}
+
 func setupGlobalLogger(opts *config.ChromeServiceConfig) {
 	logLevel, err := logrus.ParseLevel(opts.LogLevel)
 	if err != nil {
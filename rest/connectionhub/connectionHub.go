@@ -1,6 +1,7 @@
 package connectionhub
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -54,6 +55,13 @@ type WsMessage struct {
 	Payload       map[string]interface{} `json:"payload"`
 }
 
+// Disconnect asks the hub to close every connection of User, the number of closed connections is
+// sent to Result.
+type Disconnect struct {
+	User   string
+	Result chan int
+}
+
 type ConnectionNamespaces struct {
 	// index rooms clients by connections to allow better access
 	Roles        map[string]map[*Connection]*Client
@@ -62,12 +70,16 @@ type ConnectionNamespaces struct {
 }
 
 type connectionHub struct {
+	running    atomic.Bool
 	Rooms      ConnectionNamespaces
 	Emit       chan Message
 	Broadcast  chan Message
 	Register   chan Client
 	Unregister chan Client
-	Clients    clients
+	Disconnect chan Disconnect
+	// Organizations receives the channels the connected organizations are sent to
+	Organizations chan chan []string
+	Clients       clients
 }
 
 var ConnectionHub = connectionHub{
@@ -76,11 +88,13 @@ var ConnectionHub = connectionHub{
 		Organization: make(map[string]map[*Connection]*Client),
 		Usernames:    make(map[string]map[*Connection]*Client),
 	},
-	Emit:       make(chan Message),
-	Broadcast:  make(chan Message),
-	Register:   make(chan Client),
-	Unregister: make(chan Client),
-	Clients:    make(clients),
+	Emit:          make(chan Message),
+	Broadcast:     make(chan Message),
+	Register:      make(chan Client),
+	Unregister:    make(chan Client),
+	Disconnect:    make(chan Disconnect),
+	Organizations: make(chan chan []string),
+	Clients:       make(clients),
 }
 
 func registerClientRoles(c Client, h *connectionHub) {
@@ -194,13 +208,81 @@ func emitMessage(m Message, h *connectionHub) {
 
 }
 
+// disconnectUser closes the connections of the user. Every connection is registered in an
+// organization room, the client map only holds one connection per user.
+func disconnectUser(user string, h *connectionHub) int {
+	connections := make(map[*Connection]*Client)
+	for _, room := range h.Rooms.Organization {
+		for conn, c := range room {
+			if c.User == user {
+				connections[conn] = c
+			}
+		}
+	}
+
+	closeMessage := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "disconnected by support")
+	for conn, client := range connections {
+		unregisterClient(*client, h)
+		// closing the socket ends the read pump, which unregisters the client again
+		if err := conn.Ws.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait)); err != nil {
+			logrus.Debugln("Unable to send close frame to WS connection: ", err)
+		}
+		conn.Ws.Close()
+	}
+	return len(connections)
+}
+
+// DisconnectUser closes every connection the user holds on this replica and returns the number of
+// closed connections. Nothing is closed when the hub is not running.
+func (h *connectionHub) DisconnectUser(user string) int {
+	if !h.IsRunning() {
+		return 0
+	}
+	result := make(chan int, 1)
+	h.Disconnect <- Disconnect{User: user, Result: result}
+	return <-result
+}
+
+// connectedOrganizations lists the organizations with at least one open connection.
+func connectedOrganizations(h *connectionHub) []string {
+	organizations := []string{}
+	for organization, room := range h.Rooms.Organization {
+		if len(room) > 0 {
+			organizations = append(organizations, organization)
+		}
+	}
+	return organizations
+}
+
+// ConnectedOrganizations returns the organizations with an open connection on this replica. Nothing is
+// returned when the hub is not running.
+func (h *connectionHub) ConnectedOrganizations() []string {
+	if !h.IsRunning() {
+		return []string{}
+	}
+	result := make(chan []string, 1)
+	h.Organizations <- result
+	return <-result
+}
+
+// IsRunning reports whether the hub accepts messages. Emitting to a hub that is not running blocks.
+func (h *connectionHub) IsRunning() bool {
+	return h.running.Load()
+}
+
 func (h *connectionHub) Run() {
+	h.running.Store(true)
+	defer h.running.Store(false)
 	for {
 		select {
 		case c := <-h.Register:
 			registerClient(c, h)
 		case c := <-h.Unregister:
 			unregisterClient(c, h)
+		case d := <-h.Disconnect:
+			d.Result <- disconnectUser(d.User, h)
+		case result := <-h.Organizations:
+			result <- connectedOrganizations(h)
 		case m := <-h.Broadcast:
 			logrus.Errorln("Broadcasting messages is not allowed! Source: ", m.Origin)
 			return
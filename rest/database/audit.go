@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+)
+
+const (
+	DefaultAuditBufferSize    = 10000
+	DefaultAuditBatchSize     = 100
+	DefaultAuditFlushInterval = time.Second
+)
+
+var (
+	auditEventsWritten = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chrome_service_audit_events_written_total",
+		Help: "Number of security events persisted to the audit trail",
+	})
+	auditEventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chrome_service_audit_events_dropped_total",
+		Help: "Number of security events that were not persisted to the audit trail, by reason",
+	}, []string{"reason"})
+)
+
+func newAuditEvent(event securitylog.Event) (models.AuditEvent, error) {
+	auditEvent := models.AuditEvent{
+		CreatedAt:    event.Time,
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		ResourceID:   event.ResourceID,
+		Outcome:      event.Outcome,
+		Reason:       event.Reason,
+		UserID:       event.UserID,
+		OrgID:        event.OrgID,
+		RequestID:    event.RequestID,
+	}
+	if event.Diff != nil {
+		diff, err := json.Marshal(event.Diff)
+		if err != nil {
+			return auditEvent, err
+		}
+		auditEvent.Diff = datatypes.JSON(diff)
+	}
+	return auditEvent, nil
+}
+
+// AuditSink appends every security event to the audit events table from the request that emitted
+// it. Use the AsyncAuditSink to keep the writes out of the request path.
+type AuditSink struct{}
+
+func (AuditSink) Write(ctx context.Context, event securitylog.Event) error {
+	auditEvent, err := newAuditEvent(event)
+	if err != nil {
+		return err
+	}
+	// the event outlives a canceled request, so the request context is not used
+	return DB.Create(&auditEvent).Error
+}
+
+type AuditSinkOptions struct {
+	// BufferSize is the number of events waiting to be written, events are dropped once it is full
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+func (o AuditSinkOptions) withDefaults() AuditSinkOptions {
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultAuditBufferSize
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultAuditBatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = DefaultAuditFlushInterval
+	}
+	return o
+}
+
+// AsyncAuditSink buffers the security events and appends them to the audit events table in batches
+// from a background goroutine. Requests never wait for the database, when the buffer is full, for
+// example while a client floods the service with rejected requests, new events are dropped and counted.
+type AsyncAuditSink struct {
+	options AuditSinkOptions
+	events  chan models.AuditEvent
+	done    chan struct{}
+	once    sync.Once
+}
+
+func NewAsyncAuditSink(options AuditSinkOptions) *AsyncAuditSink {
+	options = options.withDefaults()
+	s := &AsyncAuditSink{
+		options: options,
+		events:  make(chan models.AuditEvent, options.BufferSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *AsyncAuditSink) Write(ctx context.Context, event securitylog.Event) error {
+	auditEvent, err := newAuditEvent(event)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.events <- auditEvent:
+	default:
+		auditEventsDropped.WithLabelValues("buffer_full").Inc()
+	}
+	return nil
+}
+
+// Close writes the buffered events and stops the sink. Events written after Close are lost.
+func (s *AsyncAuditSink) Close() {
+	s.once.Do(func() {
+		close(s.events)
+	})
+	<-s.done
+}
+
+func (s *AsyncAuditSink) flush(batch []models.AuditEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := DB.CreateInBatches(batch, s.options.BatchSize).Error; err != nil {
+		logrus.Errorf("Unable to write %d security events to the audit trail: %v", len(batch), err)
+		auditEventsDropped.WithLabelValues("write_failed").Add(float64(len(batch)))
+		return
+	}
+	auditEventsWritten.Add(float64(len(batch)))
+}
+
+func (s *AsyncAuditSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.AuditEvent, 0, s.options.BatchSize)
+	for {
+		select {
+		case event, ok := <-s.events:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= s.options.BatchSize {
+				s.flush(batch)
+				batch = make([]models.AuditEvent, 0, s.options.BatchSize)
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = make([]models.AuditEvent, 0, s.options.BatchSize)
+		}
+	}
+}
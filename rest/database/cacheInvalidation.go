@@ -0,0 +1,80 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const identityCacheChannel = "chrome_service_identity_cache"
+
+// PgCacheInvalidationBus distributes identity cache invalidations between replicas
+// using the postgres LISTEN/NOTIFY mechanism. No additional infrastructure is required.
+type PgCacheInvalidationBus struct {
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+func NewPgCacheInvalidationBus(cfg *config.ChromeServiceConfig) (*PgCacheInvalidationBus, error) {
+	if cfg.Test {
+		return nil, errors.New("postgres cache invalidation is not available with the test database")
+	}
+
+	listener := pq.NewListener(dsn(cfg), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logrus.Errorf("identity cache invalidation listener error: %v", err)
+		}
+	})
+	err := listener.Listen(identityCacheChannel)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &PgCacheInvalidationBus{listener: listener, done: make(chan struct{})}, nil
+}
+
+func (b *PgCacheInvalidationBus) Publish(message util.IdentityInvalidation) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return DB.Exec("SELECT pg_notify(?, ?)", identityCacheChannel, string(payload)).Error
+}
+
+func (b *PgCacheInvalidationBus) Subscribe(handler func(message util.IdentityInvalidation)) error {
+	go func() {
+		for {
+			select {
+			case n, ok := <-b.listener.Notify:
+				if !ok {
+					return
+				}
+				// nil notification is sent after the listener re-establishes a lost connection,
+				// invalidations missed in the meantime are covered by the cache TTL
+				if n == nil {
+					continue
+				}
+				var message util.IdentityInvalidation
+				if err := json.Unmarshal([]byte(n.Extra), &message); err != nil {
+					logrus.Errorf("invalid identity cache invalidation payload %s: %v", n.Extra, err)
+					continue
+				}
+				handler(message)
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *PgCacheInvalidationBus) Close() error {
+	close(b.done)
+	return b.listener.Close()
+}
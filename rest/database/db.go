@@ -13,24 +13,24 @@ import (
 
 var DB *gorm.DB
 
+func dsn(cfg *config.ChromeServiceConfig) string {
+	dbdns := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v sslmode=%v", cfg.DbHost, cfg.DbUser, cfg.DbPassword, cfg.DbName, cfg.DbPort, cfg.DbSSLMode)
+	if cfg.DbSSLRootCert != "" {
+		dbdns = fmt.Sprintf("%s  sslrootcert=%s", dbdns, cfg.DbSSLRootCert)
+	}
+	return dbdns
+}
+
 func Init() {
 	var err error
 	var dialector gorm.Dialector
 
 	cfg := config.Get()
-	var dbdns string
 
 	if cfg.Test {
 		dialector = sqlite.Open(cfg.DbName)
 	} else {
-
-		dbdns = fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v sslmode=%v", cfg.DbHost, cfg.DbUser, cfg.DbPassword, cfg.DbName, cfg.DbPort, cfg.DbSSLMode)
-		if cfg.DbSSLRootCert != "" {
-			dbdns = fmt.Sprintf("%s  sslrootcert=%s", dbdns, cfg.DbSSLRootCert)
-		}
-
-		dialector = postgres.Open(dbdns)
-
+		dialector = postgres.Open(dsn(cfg))
 	}
 
 	DB, err = gorm.Open(dialector, &gorm.Config{})
@@ -52,9 +52,48 @@ func Init() {
 	if !DB.Migrator().HasTable(&models.FavoritePage{}) {
 		DB.Migrator().CreateTable(&models.FavoritePage{})
 	}
+	if !DB.Migrator().HasTable(&models.FavoriteFolder{}) {
+		DB.Migrator().CreateTable(&models.FavoriteFolder{})
+	}
+	if !DB.Migrator().HasTable(&models.UserPreference{}) {
+		DB.Migrator().CreateTable(&models.UserPreference{})
+	}
+	if !DB.Migrator().HasTable(&models.Announcement{}) {
+		DB.Migrator().CreateTable(&models.Announcement{})
+	}
+	if !DB.Migrator().HasTable(&models.AnnouncementDismissal{}) {
+		DB.Migrator().CreateTable(&models.AnnouncementDismissal{})
+	}
+	if !DB.Migrator().HasTable(&models.DrawerEvent{}) {
+		DB.Migrator().CreateTable(&models.DrawerEvent{})
+	}
+	if !DB.Migrator().HasTable(&models.DrawerEventRecipient{}) {
+		DB.Migrator().CreateTable(&models.DrawerEventRecipient{})
+	}
+	if !DB.Migrator().HasTable(&models.DrawerItemState{}) {
+		DB.Migrator().CreateTable(&models.DrawerItemState{})
+	}
+	if !DB.Migrator().HasTable(&models.QuickstartProgress{}) {
+		DB.Migrator().CreateTable(&models.QuickstartProgress{})
+	}
+	if !DB.Migrator().HasTable(&models.OrgDashboardTemplate{}) {
+		DB.Migrator().CreateTable(&models.OrgDashboardTemplate{})
+	}
+	if !DB.Migrator().HasTable(&models.OrgStarterFavorite{}) {
+		DB.Migrator().CreateTable(&models.OrgStarterFavorite{})
+	}
 	if !DB.Migrator().HasTable(&models.SelfReport{}) {
 		DB.Migrator().CreateTable(&models.SelfReport{})
 	}
+	if !DB.Migrator().HasTable(&models.RateLimitBucket{}) {
+		DB.Migrator().CreateTable(&models.RateLimitBucket{})
+	}
+	if !DB.Migrator().HasTable(&models.IdempotencyRecord{}) {
+		DB.Migrator().CreateTable(&models.IdempotencyRecord{})
+	}
+	if !DB.Migrator().HasTable(&models.AuditEvent{}) {
+		DB.Migrator().CreateTable(&models.AuditEvent{})
+	}
 	if err != nil {
 		panic(fmt.Sprintf("Database connection failed: %s", err.Error()))
 	}
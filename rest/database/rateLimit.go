@@ -0,0 +1,114 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+)
+
+// takeRateLimitTokenSQL refills the bucket of a key and takes a token from it in a single statement, so
+// concurrent requests never wait on a row lock held by a transaction. A missing bucket is created full
+// minus the taken token. A denied request leaves the bucket untouched, the refill is linear below the
+// capacity so the elapsed time is credited by the next request.
+var takeRateLimitTokenSQL = expandRateLimitSQL(`
+INSERT INTO rate_limit_buckets (key, tokens, refilled_at_unix, full_at_unix, taken)
+VALUES (@key, {capacity} - 1, {now}, {now} + 1 / {rate}, true)
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE WHEN {refilled} >= 1 THEN {refilled} - 1 ELSE rate_limit_buckets.tokens END,
+	refilled_at_unix = CASE WHEN {refilled} >= 1 THEN {now} ELSE rate_limit_buckets.refilled_at_unix END,
+	full_at_unix = CASE WHEN {refilled} >= 1 THEN {now} + ({capacity} - {refilled} + 1) / {rate} ELSE rate_limit_buckets.full_at_unix END,
+	taken = {refilled} >= 1
+RETURNING tokens, refilled_at_unix, taken`)
+
+// expandRateLimitSQL replaces the placeholders of the rate limit statement, each replacement may use the
+// placeholders that follow it.
+func expandRateLimitSQL(query string) string {
+	for _, placeholder := range [][2]string{
+		// the stored tokens refilled up to now and capped by the capacity
+		{"{refilled}", "(CASE WHEN {stored} > {capacity} THEN {capacity} ELSE {stored} END)"},
+		// the clocks of other replicas may be ahead, a bucket is never drained by the refill
+		{"{stored}", "(rate_limit_buckets.tokens + CASE WHEN {now} > rate_limit_buckets.refilled_at_unix THEN ({now} - rate_limit_buckets.refilled_at_unix) * {rate} ELSE 0 END)"},
+		// postgres infers the parameter types from the expressions, the params must not become integers
+		{"{capacity}", "CAST(@capacity AS DOUBLE PRECISION)"},
+		{"{rate}", "CAST(@rate AS DOUBLE PRECISION)"},
+		{"{now}", "CAST(@now AS DOUBLE PRECISION)"},
+	} {
+		query = strings.ReplaceAll(query, placeholder[0], placeholder[1])
+	}
+	return query
+}
+
+// DBRateLimiter keeps the rate limit buckets in the database so every replica enforces the same limits.
+// Every request runs one statement per bucket.
+type DBRateLimiter struct {
+	stop chan struct{}
+}
+
+func NewDBRateLimiter(janitorInterval time.Duration) *DBRateLimiter {
+	if janitorInterval <= 0 {
+		janitorInterval = util.DefaultRateLimitJanitorInterval
+	}
+	limiter := &DBRateLimiter{stop: make(chan struct{})}
+	go limiter.runJanitor(janitorInterval)
+	return limiter
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func (dl *DBRateLimiter) Take(key string, limit util.RateLimit, now time.Time) (util.RateLimitDecision, error) {
+	var bucket models.RateLimitBucket
+	err := DB.Raw(takeRateLimitTokenSQL, map[string]interface{}{
+		"key":      key,
+		"capacity": limit.Capacity(),
+		"rate":     limit.RefillPerSecond(),
+		"now":      unixSeconds(now),
+	}).Scan(&bucket).Error
+	if err != nil {
+		return util.RateLimitDecision{}, err
+	}
+	if bucket.Taken {
+		return util.NewRateLimitDecision(limit, bucket.Tokens, true), nil
+	}
+	// the stored tokens of a denied request were not refilled yet
+	refilledAt := time.Unix(0, int64(bucket.RefilledAtUnix*float64(time.Second)))
+	_, decision := util.TakeRateLimitToken(limit, bucket.Tokens, refilledAt, now)
+	return decision, nil
+}
+
+// refundRateLimitTokenSQL puts a token back in a bucket, the bucket is never filled above its capacity.
+var refundRateLimitTokenSQL = expandRateLimitSQL(`
+UPDATE rate_limit_buckets SET
+	tokens = CASE WHEN rate_limit_buckets.tokens + 1 > {capacity} THEN {capacity} ELSE rate_limit_buckets.tokens + 1 END
+WHERE key = @key`)
+
+func (dl *DBRateLimiter) Refund(key string, limit util.RateLimit, now time.Time) error {
+	return DB.Exec(refundRateLimitTokenSQL, map[string]interface{}{
+		"key":      key,
+		"capacity": limit.Capacity(),
+	}).Error
+}
+
+func (dl *DBRateLimiter) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			// full buckets are equivalent to missing ones
+			if err := DB.Where("full_at_unix <= ?", unixSeconds(now)).Delete(&models.RateLimitBucket{}).Error; err != nil {
+				logrus.Errorf("Unable to remove full rate limit buckets: %v", err)
+			}
+		case <-dl.stop:
+			return
+		}
+	}
+}
+
+func (dl *DBRateLimiter) Close() {
+	close(dl.stop)
+}
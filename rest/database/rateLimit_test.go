@@ -0,0 +1,80 @@
+package database_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	cfg := config.Get()
+	cfg.Test = true
+	cfg.DbName = fmt.Sprintf("%d-services.db", time.Now().UnixNano())
+	database.Init()
+	exitCode := m.Run()
+	os.Remove(cfg.DbName)
+	os.Exit(exitCode)
+}
+
+func TestDBRateLimiter(t *testing.T) {
+	limiter := database.NewDBRateLimiter(time.Hour)
+	t.Cleanup(limiter.Close)
+	limit := util.RateLimit{PerMinute: 60, Burst: 2}
+	now := time.Now()
+
+	t.Run("should take the tokens of a new bucket", func(t *testing.T) {
+		decision, err := limiter.Take("user:db-limiter:read", limit, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 1, decision.Remaining)
+
+		decision, err = limiter.Take("user:db-limiter:read", limit, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 0, decision.Remaining)
+	})
+
+	t.Run("should deny requests of an empty bucket", func(t *testing.T) {
+		decision, err := limiter.Take("user:db-limiter:read", limit, now.Add(500*time.Millisecond))
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, time.Second, decision.RetryAfter)
+	})
+
+	t.Run("should refill the bucket over time", func(t *testing.T) {
+		decision, err := limiter.Take("user:db-limiter:read", limit, now.Add(time.Second))
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 0, decision.Remaining)
+
+		decision, err = limiter.Take("user:db-limiter:read", limit, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 1, decision.Remaining)
+	})
+
+	t.Run("should keep separate buckets per key", func(t *testing.T) {
+		decision, err := limiter.Take("org:db-limiter:read", limit, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 1, decision.Remaining)
+	})
+	t.Run("should refund a taken token up to the capacity", func(t *testing.T) {
+		decision, err := limiter.Take("user:db-limiter-refund:read", limit, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, decision.Remaining)
+
+		require.NoError(t, limiter.Refund("user:db-limiter-refund:read", limit, now))
+		require.NoError(t, limiter.Refund("user:db-limiter-refund:read", limit, now))
+		decision, err = limiter.Take("user:db-limiter-refund:read", limit, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, decision.Remaining)
+	})
+}
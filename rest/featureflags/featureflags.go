@@ -5,6 +5,7 @@ import (
 
 	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/Unleash/unleash-client-go/v3"
+	unleashContext "github.com/Unleash/unleash-client-go/v3/context"
 	"github.com/sirupsen/logrus"
 )
 
@@ -72,6 +73,19 @@ func IsEnabled(flag string) bool {
 	}
 }
 
+// IsEnabledForUser evaluates the flag with the user ID and additional properties in the
+// Unleash context, so strategies and constraints can target specific users.
+func IsEnabledForUser(flag string, userID string, properties map[string]string) bool {
+	if ffClient == nil {
+		return false
+	}
+	ctx := unleashContext.Context{
+		UserId:     userID,
+		Properties: properties,
+	}
+	return ffClient.unleashClient.IsEnabled(flag, unleash.WithContext(ctx))
+}
+
 func Close() {
 	if ffClient != nil {
 		ffClient.unleashClient.Close()
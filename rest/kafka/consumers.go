@@ -15,6 +15,7 @@ import (
 	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
 	"github.com/RedHatInsights/chrome-service-backend/rest/connectionhub"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/google/uuid"
 	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
 	"github.com/segmentio/kafka-go"
@@ -205,8 +206,18 @@ func startKafkaReader(r *kafka.Reader) {
 
 		var p cloudevents.KafkaEnvelope
 		err = json.Unmarshal(m.Value, &p)
+		if err == nil && p.Type == service.AnnouncementsEventType && len(p.Data.Organizations) == 0 {
+			// announcements without targeted organizations are shown to every organization
+			p.Data.Organizations = connectionhub.ConnectionHub.ConnectedOrganizations()
+		}
 		if err != nil {
 			logrus.Errorln(fmt.Sprintf("Unable to unmarshal message %s\n", string(m.Value)))
+		} else if p.Type == service.ConnectionsDisconnectEventType {
+			// published by the support API of any replica, every replica closes its own connections
+			for _, user := range p.Data.Users {
+				disconnected := connectionhub.ConnectionHub.DisconnectUser(user)
+				logrus.Infof("Disconnected %d connections of user %s", disconnected, user)
+			}
 		} else if p.Data.Payload == nil {
 			logrus.Errorln(fmt.Sprintf("No message will be emitted due to missing payload %s! Message might not follow cloud events spec.\n", string(m.Value)))
 		} else {
@@ -218,6 +229,10 @@ func startKafkaReader(r *kafka.Reader) {
 			} else {
 				validateErr := cloudevents.ValidatePayload(p)
 				if validateErr == nil {
+					// persist drawer events so their read state survives page reloads
+					if err := service.StoreDrawerEvent(p); err != nil {
+						logrus.Errorln("Unable to store drawer event", p.Id, err)
+					}
 					newMessage := connectionhub.Message{
 						Destinations: connectionhub.MessageDestinations{
 							Users:         p.Data.Users,
@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+// kafkaProducer publishes the events of the service to the topic the consumers read. Every replica
+// consumes the topic with its own consumer group, so an event reaches the connections of every replica.
+type kafkaProducer struct {
+	Writer *kafka.Writer
+}
+
+var Producer = kafkaProducer{}
+
+func createWriter(topic string) *kafka.Writer {
+	cfg := config.Get()
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+	mechanism, err := CreateSaslMechanism(cfg.KafkaConfig.BrokerConfig.Sasl)
+	if cfg.KafkaConfig.BrokerConfig.Authtype != nil {
+		dialer, err = createDialer(&cfg.KafkaConfig.BrokerConfig)
+		if err != nil {
+			logrus.Errorln("Couldn't create dialer for Kafka: ", err)
+		}
+	}
+	if err == nil {
+		dialer.SASLMechanism = mechanism
+	} else {
+		logrus.Errorln("Couldn't create SASL mechanism for Kafka: ", err)
+	}
+
+	// the events are published from requests, they must not wait for the brokers
+	w := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:     cfg.KafkaConfig.KafkaBrokers,
+		Topic:       topic,
+		Balancer:    &kafka.LeastBytes{},
+		Async:       true,
+		Logger:      kafka.LoggerFunc(logrus.Debugf),
+		ErrorLogger: kafka.LoggerFunc(logrus.Errorf),
+		Dialer:      dialer,
+	})
+	logrus.Infoln("Creating new kafka writer for topic:", topic)
+	return w
+}
+
+func InitializeProducer() {
+	topics := config.Get().KafkaConfig.KafkaTopics
+	if len(topics) == 0 {
+		logrus.Errorln("No kafka topic is configured, events of the service will not be published")
+		return
+	}
+	Producer.Writer = createWriter(topics[0])
+}
+
+// Publish sends the event to every replica. The connection hubs emit it like the events of other
+// services, broadcast events are rejected by them.
+func (p *kafkaProducer) Publish(event cloudevents.KafkaEnvelope) error {
+	if p.Writer == nil {
+		return errors.New("the kafka producer is not initialized")
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.Writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(fmt.Sprintf("%s-%s", event.Type, event.Id)),
+		Value: value,
+	})
+}
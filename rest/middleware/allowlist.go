@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+)
+
+// RequireAllowlistedUser rejects requests of users whose ID is not returned by allowlist. The
+// allowlist is read on every request so configuration changes in tests are picked up.
+func RequireAllowlistedUser(resource string, allowlist func() []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := ""
+			if id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil && id.Identity.User != nil {
+				userID = id.Identity.User.UserID
+			}
+			if userID == "" || !slices.Contains(allowlist(), userID) {
+				securitylog.LogWithReason(r.Context(), "AUTHORIZE", resource, r.URL.Path, "failure", "user is not allowlisted")
+				util.WriteError(w, r, util.ErrNotAuthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAllowlistedStaff rejects requests that are not made by an associate or a service account
+// whose principal ID is returned by allowlist. Customer users are rejected even when allowlisted.
+func RequireAllowlistedStaff(resource string, allowlist func() []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := ""
+			if id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil && id.Identity.User == nil {
+				principal = util.PrincipalID(id)
+			}
+			if principal == "" || !slices.Contains(allowlist(), principal) {
+				securitylog.LogWithReason(r.Context(), "AUTHORIZE", resource, r.URL.Path, "failure", "principal is not an allowlisted associate or service account")
+				util.WriteError(w, r, util.ErrNotAuthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecation announces that the routes are deprecated in favor of successor. The Deprecation header
+// (RFC 9745) carries the deprecation date, the Sunset header (RFC 8594) the date the routes go away. Each
+// header is only sent when its date is set, nothing is announced when neither is.
+func Deprecation(deprecatedAt time.Time, sunsetAt time.Time, successor string) func(http.Handler) http.Handler {
+	if deprecatedAt.IsZero() && sunsetAt.IsZero() {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	deprecation := ""
+	if !deprecatedAt.IsZero() {
+		deprecation = fmt.Sprintf("@%d", deprecatedAt.Unix())
+	}
+	sunset := ""
+	if !sunsetAt.IsZero() {
+		sunset = sunsetAt.UTC().Format(http.TimeFormat)
+	}
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if deprecation != "" {
+				w.Header().Set("Deprecation", deprecation)
+			}
+			if sunset != "" {
+				w.Header().Set("Sunset", sunset)
+			}
+			w.Header().Add("Link", link)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
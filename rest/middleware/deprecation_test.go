@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func deprecatedRequest(deprecatedAt time.Time, sunsetAt time.Time) *httptest.ResponseRecorder {
+	handler := m.Deprecation(deprecatedAt, sunsetAt, "/api/chrome-service/v2")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/chrome-service/v1/user", nil))
+	return recorder
+}
+
+func TestDeprecation(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should announce nothing when no date is set", func(t *testing.T) {
+		recorder := deprecatedRequest(time.Time{}, time.Time{})
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Deprecation"))
+		assert.Empty(t, recorder.Header().Get("Sunset"))
+		assert.Empty(t, recorder.Header().Get("Link"))
+	})
+
+	t.Run("should only send the headers of the set dates", func(t *testing.T) {
+		recorder := deprecatedRequest(deprecatedAt, time.Time{})
+		assert.Equal(t, "@1767225600", recorder.Header().Get("Deprecation"))
+		assert.Empty(t, recorder.Header().Get("Sunset"))
+		assert.Equal(t, `</api/chrome-service/v2>; rel="successor-version"`, recorder.Header().Get("Link"))
+	})
+
+	t.Run("should send the sunset date", func(t *testing.T) {
+		recorder := deprecatedRequest(deprecatedAt, sunsetAt)
+		assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", recorder.Header().Get("Sunset"))
+	})
+}
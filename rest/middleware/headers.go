@@ -14,9 +14,7 @@ func ParseHeaders(next http.Handler) http.Handler {
 		header := r.Header.Get(util.XRHIDENTITY)
 		ctx := r.Context()
 		if header == "" {
-			errString := "Missing authentication"
-			w.WriteHeader(http.StatusForbidden)
-			w.Write([]byte(errString))
+			util.WriteProblem(w, r, util.NewProblem(http.StatusForbidden, "missing authentication").WithCode("missing_identity"))
 			logger.LogFor(r.Context()).Errorf("missing the %s header", util.XRHIDENTITY)
 			securitylog.LogWithReason(r.Context(), "AUTHENTICATE", "api_request", r.URL.Path, "failure", "missing identity header")
 			return
@@ -25,8 +23,7 @@ func ParseHeaders(next http.Handler) http.Handler {
 			if err != nil {
 				logger.LogFor(r.Context()).Errorln("Error parsing X-RH-IDENTITY header: ", err)
 				securitylog.LogWithReason(r.Context(), "AUTHENTICATE", "api_request", r.URL.Path, "failure", "invalid identity header")
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte("Internal server error"))
+				util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "internal server error"))
 				return
 			}
 			ctx = context.WithValue(ctx, util.IDENTITY_CTX_KEY, identity)
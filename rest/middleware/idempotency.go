@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/logger"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+)
+
+const (
+	IDEMPOTENCY_KEY_HEADER      = "Idempotency-Key"
+	IDEMPOTENCY_REPLAYED_HEADER = "Idempotent-Replayed"
+)
+
+// Idempotency stores the first response to a POST request sent with an Idempotency-Key header for the
+// configured TTL, and answers repeats of the request by the same user with it. A key reused for a different
+// request is rejected with 422, and a repeat sent while the first request is still handled with 409. Server
+// errors are not stored so the request can be retried with the same key. At most maxBodySize bytes of the
+// body are read, larger requests are rejected with 413.
+func Idempotency(idempotencyConfig config.IdempotencyConfig, maxBodySize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IDEMPOTENCY_KEY_HEADER)
+			user, ok := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+			if r.Method != http.MethodPost || key == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					util.WriteError(w, r, fmt.Errorf("%w: the request body exceeds %d bytes", util.ErrTooLarge, maxBytesErr.Limit))
+					return
+				}
+				util.WriteError(w, r, util.BadRequest(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			requestHash := service.IdempotencyRequestHash(r.Method, r.URL.RequestURI(), body)
+			stored, err := service.BeginIdempotentRequest(user.ID, key, requestHash, idempotencyConfig.TTL, idempotencyConfig.LockTimeout, time.Now())
+			if err != nil {
+				util.WriteError(w, r, err)
+				return
+			}
+			if stored != nil {
+				for name, value := range stored.Header.Data() {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set(IDEMPOTENCY_REPLAYED_HEADER, "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			defer func() {
+				// keys of panicking requests are released as well, the panic is rendered further up
+				if rec := recover(); rec != nil {
+					releaseIdempotencyKey(r, user.ID, key)
+					panic(rec)
+				}
+				if recorder.status == 0 {
+					recorder.status = http.StatusOK
+				}
+				if !service.IsIdempotencyReplayable(recorder.status) {
+					releaseIdempotencyKey(r, user.ID, key)
+					return
+				}
+				if err := service.CompleteIdempotentRequest(user.ID, key, recorder.status, recorder.Header(), recorder.body.Bytes()); err != nil {
+					logger.LogFor(r.Context()).Errorf("unable to store the response of idempotency key %s: %v", key, err)
+					releaseIdempotencyKey(r, user.ID, key)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+func releaseIdempotencyKey(r *http.Request, userId uint, key string) {
+	if err := service.ReleaseIdempotentRequest(userId, key); err != nil {
+		logger.LogFor(r.Context()).Errorf("unable to release idempotency key %s: %v", key, err)
+	}
+}
@@ -3,10 +3,12 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/sirupsen/logrus"
 )
 
 func InjectUser(next http.Handler) http.Handler {
@@ -18,9 +20,14 @@ func InjectUser(next http.Handler) http.Handler {
 		if p == "true" {
 			skipCache = true
 		}
-		userIdentity, err := service.CreateIdentity(userId, skipCache)
+		userIdentity, err := service.CreateIdentity(userId, identity.Identity.OrgID, skipCache)
 		if err != nil {
-			panic(err)
+			util.WriteError(w, r, err)
+			return
+		}
+		// lifecycle tracking must not block the request
+		if err := service.TrackUserSession(&userIdentity, time.Now()); err != nil {
+			logrus.Errorf("Unable to track session of user %s: %v", userId, err)
 		}
 
 		ctx := r.Context()
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/openapi"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+)
+
+// ValidateRequest rejects requests that do not match their documented operation with a 400 problem
+// listing every violation. Undocumented routes are passed through. The validation reads at most
+// maxBodySize bytes of the body, larger requests are rejected with a 413 problem.
+func ValidateRequest(spec *openapi.Spec, maxBodySize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && maxBodySize > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+			}
+			if err := spec.ValidateRequest(r); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					util.WriteError(w, r, fmt.Errorf("%w: the request body exceeds %d bytes", util.ErrTooLarge, maxBytesErr.Limit))
+					return
+				}
+				problem := util.NewProblem(http.StatusBadRequest, "the request does not match the API specification").WithCode("invalid_request")
+				var validationErr *openapi.ValidationError
+				if errors.As(err, &validationErr) {
+					problem = problem.WithErrors(validationErr.Errors)
+				}
+				util.WriteProblem(w, r, problem)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// responseRecorder keeps a copy of the response for validation while writing it through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// ValidateResponse reports responses that do not match their documented operation. It is meant for
+// tests, where report fails the test, to catch places where the specification drifted from the code.
+func ValidateResponse(spec *openapi.Spec, report func(r *http.Request, err error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			if err := spec.ValidateResponse(r, recorder.status, recorder.Header(), recorder.body.Bytes()); err != nil {
+				report(r, err)
+			}
+		})
+	}
+}
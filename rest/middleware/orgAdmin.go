@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+)
+
+// RequireOrgAdmin rejects requests of users who are not org admins according to the x-rh-identity header.
+func RequireOrgAdmin(resource string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID)
+			if !ok || id == nil || id.Identity.User == nil || !id.Identity.User.OrgAdmin || id.Identity.OrgID == "" {
+				securitylog.LogWithReason(r.Context(), "AUTHORIZE", resource, r.URL.Path, "failure", "user is not an org admin")
+				util.WriteError(w, r, util.ErrNotAuthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
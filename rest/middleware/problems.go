@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/logger"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+)
+
+// RecoverProblem renders panics of the handlers as internal error problems instead of plain text.
+func RecoverProblem(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logger.LogFor(r.Context()).Errorf("panic while handling %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "internal server error"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NotFound renders unknown routes as problems.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	util.WriteProblem(w, r, util.NewProblem(http.StatusNotFound, fmt.Sprintf("route %s does not exist", r.URL.Path)))
+}
+
+// MethodNotAllowed renders unsupported methods of known routes as problems.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	util.WriteProblem(w, r, util.NewProblem(http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed on %s", r.Method, r.URL.Path)))
+}
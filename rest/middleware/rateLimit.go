@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/logger"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+)
+
+type rateLimitBucket struct {
+	owner string
+	key   string
+	limit util.RateLimit
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, decision util.RateLimitDecision) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(decision.Reset.Seconds())))
+}
+
+// retryAfterSeconds rounds the wait up to whole seconds, a denied client is never told to retry immediately.
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
+
+// RateLimit takes a token from the user and the organization buckets of every request, reads and writes
+// use separate buckets. Requests are rejected with 429 once a bucket is empty, and the RateLimit headers
+// describe the most restrictive bucket. The tokens already taken for a rejected request are refunded, so
+// a user is not charged for requests the organization limit denied. Limiter errors let the request through.
+func RateLimit(limiter util.RateLimiter, rateLimitConfig config.RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID)
+			if !ok || id == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			class, userLimit, orgLimit := "write", rateLimitConfig.UserWrite, rateLimitConfig.OrgWrite
+			if isReadMethod(r.Method) {
+				class, userLimit, orgLimit = "read", rateLimitConfig.UserRead, rateLimitConfig.OrgRead
+			}
+			buckets := []rateLimitBucket{}
+			if id.Identity.User != nil && id.Identity.User.UserID != "" {
+				buckets = append(buckets, rateLimitBucket{owner: "user", key: fmt.Sprintf("user:%s:%s", id.Identity.User.UserID, class), limit: userLimit})
+			}
+			if id.Identity.OrgID != "" {
+				buckets = append(buckets, rateLimitBucket{owner: "org", key: fmt.Sprintf("org:%s:%s", id.Identity.OrgID, class), limit: orgLimit})
+			}
+
+			now := time.Now()
+			var reported *util.RateLimitDecision
+			taken := []rateLimitBucket{}
+			for _, bucket := range buckets {
+				if !bucket.limit.Enabled() {
+					continue
+				}
+				decision, err := limiter.Take(bucket.key, bucket.limit, now)
+				if err != nil {
+					logger.LogFor(r.Context()).Errorf("unable to apply the %s %s rate limit: %v", bucket.owner, class, err)
+					continue
+				}
+				if !decision.Allowed {
+					for _, charged := range taken {
+						if err := limiter.Refund(charged.key, charged.limit, now); err != nil {
+							logger.LogFor(r.Context()).Errorf("unable to refund the %s %s rate limit: %v", charged.owner, class, err)
+						}
+					}
+					setRateLimitHeaders(w, decision)
+					retryAfter := retryAfterSeconds(decision.RetryAfter)
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+					securitylog.LogWithReason(r.Context(), "RATE_LIMIT", "rate_limit", bucket.key, "failure", fmt.Sprintf("%s %s rate limit exceeded", bucket.owner, class))
+					util.WriteProblem(w, r, util.NewProblem(http.StatusTooManyRequests, fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter)).WithCode("rate_limited"))
+					return
+				}
+				taken = append(taken, bucket)
+				if reported == nil || decision.Remaining < reported.Remaining {
+					reported = &decision
+				}
+			}
+			if reported != nil {
+				setRateLimitHeaders(w, *reported)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRateLimiter returns the same decision for every bucket.
+type stubRateLimiter struct {
+	decision util.RateLimitDecision
+	err      error
+}
+
+func (sl stubRateLimiter) Take(key string, limit util.RateLimit, now time.Time) (util.RateLimitDecision, error) {
+	return sl.decision, sl.err
+}
+
+func (sl stubRateLimiter) Refund(key string, limit util.RateLimit, now time.Time) error {
+	return sl.err
+}
+
+func (sl stubRateLimiter) Close() {}
+
+var testRateLimitConfig = config.RateLimitConfig{
+	UserRead:  util.RateLimit{PerMinute: 60, Burst: 2},
+	UserWrite: util.RateLimit{PerMinute: 60, Burst: 1},
+}
+
+func rateLimitedRequest(t *testing.T, limiter util.RateLimiter, method string, xrhid *identity.XRHID) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := m.RateLimit(limiter, testRateLimitConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := httptest.NewRequest(method, "/api/chrome-service/v1/user", nil)
+	if xrhid != nil {
+		request = request.WithContext(context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid))
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func rateLimitIdentity(userID string) *identity.XRHID {
+	return &identity.XRHID{Identity: identity.Identity{User: &identity.User{UserID: userID}}}
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("should describe the bucket in the RateLimit headers", func(t *testing.T) {
+		limiter := util.NewMemoryRateLimiter(time.Hour)
+		t.Cleanup(limiter.Close)
+
+		recorder := rateLimitedRequest(t, limiter, http.MethodGet, rateLimitIdentity("headers-user"))
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		assert.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", recorder.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", recorder.Header().Get("RateLimit-Reset"))
+		assert.Empty(t, recorder.Header().Get("Retry-After"))
+	})
+
+	t.Run("should reject requests of an empty bucket", func(t *testing.T) {
+		limiter := util.NewMemoryRateLimiter(time.Hour)
+		t.Cleanup(limiter.Close)
+		xrhid := rateLimitIdentity("empty-user")
+
+		assert.Equal(t, http.StatusNoContent, rateLimitedRequest(t, limiter, http.MethodGet, xrhid).Code)
+		assert.Equal(t, http.StatusNoContent, rateLimitedRequest(t, limiter, http.MethodGet, xrhid).Code)
+		recorder := rateLimitedRequest(t, limiter, http.MethodGet, xrhid)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+		assert.Equal(t, util.PROBLEM_CONTENT_TYPE, recorder.Header().Get("Content-Type"))
+
+		var problem util.Problem
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+		assert.Equal(t, "rate_limited", problem.Code)
+
+		// writes take from their own bucket
+		assert.Equal(t, http.StatusNoContent, rateLimitedRequest(t, limiter, http.MethodPost, xrhid).Code)
+	})
+
+	t.Run("should refund the user token when the organization bucket denies", func(t *testing.T) {
+		limiter := util.NewMemoryRateLimiter(time.Hour)
+		t.Cleanup(limiter.Close)
+		orgLimitConfig := testRateLimitConfig
+		orgLimitConfig.OrgRead = util.RateLimit{PerMinute: 60, Burst: 1}
+		handler := m.RateLimit(limiter, orgLimitConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		orgRequest := func(userID string) *httptest.ResponseRecorder {
+			xrhid := rateLimitIdentity(userID)
+			xrhid.Identity.OrgID = "refund-org"
+			request := httptest.NewRequest(http.MethodGet, "/api/chrome-service/v1/user", nil)
+			request = request.WithContext(context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			return recorder
+		}
+
+		assert.Equal(t, http.StatusNoContent, orgRequest("refund-other-user").Code)
+		assert.Equal(t, http.StatusTooManyRequests, orgRequest("refund-user").Code)
+
+		decision, err := limiter.Take("user:refund-user:read", orgLimitConfig.UserRead, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, decision.Remaining)
+	})
+
+	t.Run("should never ask to retry immediately", func(t *testing.T) {
+		limiter := stubRateLimiter{decision: util.RateLimitDecision{Limit: 2, RetryAfter: 300 * time.Millisecond}}
+		recorder := rateLimitedRequest(t, limiter, http.MethodGet, rateLimitIdentity("rounding-user"))
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+		limiter.decision.RetryAfter = 1500 * time.Millisecond
+		recorder = rateLimitedRequest(t, limiter, http.MethodGet, rateLimitIdentity("rounding-user"))
+		assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+	})
+
+	t.Run("should let requests through when the limiter fails", func(t *testing.T) {
+		limiter := stubRateLimiter{err: errors.New("database is down")}
+		recorder := rateLimitedRequest(t, limiter, http.MethodGet, rateLimitIdentity("failing-user"))
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("should not limit requests without identity", func(t *testing.T) {
+		limiter := stubRateLimiter{decision: util.RateLimitDecision{}}
+		recorder := rateLimitedRequest(t, limiter, http.MethodGet, nil)
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type AnnouncementSeverity string
+
+const (
+	AnnouncementInfo    AnnouncementSeverity = "info"
+	AnnouncementSuccess AnnouncementSeverity = "success"
+	AnnouncementWarning AnnouncementSeverity = "warning"
+	AnnouncementDanger  AnnouncementSeverity = "danger"
+)
+
+func (as AnnouncementSeverity) IsValid() error {
+	switch as {
+	case AnnouncementInfo, AnnouncementSuccess, AnnouncementWarning, AnnouncementDanger:
+		return nil
+	}
+
+	return fmt.Errorf("invalid announcement severity. Expected one of %s, %s, %s, %s, got %s", AnnouncementInfo, AnnouncementSuccess, AnnouncementWarning, AnnouncementDanger, as)
+}
+
+// AnnouncementTargeting restricts who sees an announcement. Empty lists do not restrict the
+// audience, all set criteria have to match.
+type AnnouncementTargeting struct {
+	OrgIds []string `json:"orgIds,omitempty"`
+	// Bundles limits the announcement to pages of the listed bundles
+	Bundles []string `json:"bundles,omitempty"`
+	// Entitlements requires the organization to be entitled to at least one of the listed services
+	Entitlements []string `json:"entitlements,omitempty"`
+	// FeatureFlag has to be enabled for the user
+	FeatureFlag string `json:"featureFlag,omitempty"`
+}
+
+// Announcement is an in-console banner managed by the announcements admins.
+type Announcement struct {
+	BaseModel
+	Title       string                                    `json:"title"`
+	Content     string                                    `json:"content"`
+	Severity    AnnouncementSeverity                      `json:"severity"`
+	StartTime   *time.Time                                `json:"startTime"`
+	EndTime     *time.Time                                `json:"endTime"`
+	Dismissible bool                                      `json:"dismissible"`
+	Targeting   datatypes.JSONType[AnnouncementTargeting] `json:"targeting"`
+	CreatedBy   string                                    `json:"createdBy"`
+}
+
+// IsActive reports whether the announcement is shown at the given time.
+func (a Announcement) IsActive(now time.Time) bool {
+	if a.StartTime != nil && now.Before(*a.StartTime) {
+		return false
+	}
+	if a.EndTime != nil && !now.Before(*a.EndTime) {
+		return false
+	}
+	return true
+}
+
+func (a Announcement) IsValid() error {
+	if strings.TrimSpace(a.Title) == "" {
+		return errors.New("announcement title is required")
+	}
+	if err := a.Severity.IsValid(); err != nil {
+		return err
+	}
+	if a.StartTime != nil && a.EndTime != nil && !a.EndTime.After(*a.StartTime) {
+		return errors.New("announcement ends before it starts")
+	}
+	return nil
+}
+
+// AnnouncementDismissal records that a user closed an announcement.
+type AnnouncementDismissal struct {
+	BaseModel
+	AnnouncementID uint `json:"announcementId" gorm:"uniqueIndex:idx_announcement_dismissal"`
+	UserIdentityID uint `json:"userIdentityId" gorm:"uniqueIndex:idx_announcement_dismissal"`
+}
+
+// AnnouncementRequest creates or replaces an announcement.
+type AnnouncementRequest struct {
+	Title       string                `json:"title"`
+	Content     string                `json:"content"`
+	Severity    AnnouncementSeverity  `json:"severity"`
+	StartTime   *time.Time            `json:"startTime"`
+	EndTime     *time.Time            `json:"endTime"`
+	Dismissible *bool                 `json:"dismissible"`
+	Targeting   AnnouncementTargeting `json:"targeting"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// AuditEvent is a persisted security event. Events are only ever appended, the retention job is the
+// only one removing them.
+type AuditEvent struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `gorm:"not null;index" json:"createdAt"`
+	Action       string    `gorm:"not null;index" json:"action"`
+	ResourceType string    `gorm:"not null;index:idx_audit_resource" json:"resourceType"`
+	ResourceID   string    `gorm:"index:idx_audit_resource" json:"resourceId"`
+	Outcome      string    `gorm:"not null" json:"outcome"`
+	Reason       string    `json:"reason,omitempty"`
+	// UserID and OrgID identify the principal from the identity header
+	UserID    string `gorm:"index" json:"userId,omitempty"`
+	OrgID     string `gorm:"index" json:"orgId,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	// Diff maps the changed fields to their value before and after the change
+	Diff datatypes.JSON `json:"diff,omitempty"`
+}
@@ -287,6 +287,8 @@ type DashboardTemplate struct {
 	Default        bool                  `gorm:"not null;default:false" json:"default"`
 	TemplateBase   DashboardTemplateBase `gorm:"not null;default null; embedded" 'json:"templateBase"`
 	TemplateConfig TemplateConfig        `gorm:"not null;default null; embedded" json:"templateConfig"`
+	// Version is bumped on every write, the entity tag of the template is derived from it
+	Version int `gorm:"not null;default:1" json:"-"`
 }
 
 type BaseDashboardTemplate struct {
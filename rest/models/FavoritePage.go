@@ -1,8 +1,163 @@
 package models
 
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 type FavoritePage struct {
 	BaseModel
-	Pathname       string `json:"pathname"`
-	Favorite       bool   `json:"favorite"`
+	Pathname string `json:"pathname"`
+	Favorite bool   `json:"favorite"`
+	// Title overrides the navigation title of the page when set
+	Title          string `json:"title,omitempty"`
+	Position       int    `json:"position" gorm:"default:0"`
+	FolderID       *uint  `json:"folderId"`
 	UserIdentityID uint   `json:"userIdentityId"`
+	// Version is bumped on every write, the entity tag of the page is derived from it
+	Version int `gorm:"not null;default:1" json:"-"`
+	// Navigation data resolved from the generated navigation, not stored
+	ResolvedTitle string `json:"resolvedTitle,omitempty" gorm:"-"`
+	Bundle        string `json:"bundle,omitempty" gorm:"-"`
+	Icon          string `json:"icon,omitempty" gorm:"-"`
+	Stale         bool   `json:"stale" gorm:"-"`
+}
+
+type FavoriteFolder struct {
+	BaseModel
+	Name           string         `json:"name"`
+	Position       int            `json:"position" gorm:"default:0"`
+	UserIdentityID uint           `json:"userIdentityId"`
+	FavoritePages  []FavoritePage `json:"favoritePages,omitempty" gorm:"foreignKey:FolderID"`
+}
+
+// FavoritesReorderRequest sets the order of favorites or folders. Items missing from the list keep
+// their relative order after the listed ones.
+type FavoritesReorderRequest struct {
+	FolderID *uint  `json:"folderId"`
+	IDs      []uint `json:"ids"`
+}
+
+// FavoritesMoveRequest moves favorites into a folder, or to the top level when the folder is empty.
+type FavoritesMoveRequest struct {
+	FolderID *uint  `json:"folderId"`
+	IDs      []uint `json:"ids"`
+}
+
+type FavoritePageUpdateRequest struct {
+	Title *string `json:"title"`
+}
+
+type FavoriteFolderRequest struct {
+	Name string `json:"name"`
+}
+
+// FavoritesDocumentVersion is the version of exported favorites documents. Documents of newer versions are rejected.
+const FavoritesDocumentVersion = 1
+
+const maxFavoritesDocumentItems = 1000
+
+type FavoritesDocumentFolder struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type FavoritesDocumentPage struct {
+	Pathname string `json:"pathname"`
+	Title    string `json:"title,omitempty"`
+	Folder   string `json:"folder,omitempty"`
+	Position int    `json:"position"`
+}
+
+// FavoritesDocument is the portable representation of user favorites, free of user and environment specific IDs.
+type FavoritesDocument struct {
+	Version    int                       `json:"version"`
+	ExportedAt time.Time                 `json:"exportedAt"`
+	Folders    []FavoritesDocumentFolder `json:"folders"`
+	Pages      []FavoritesDocumentPage   `json:"pages"`
+}
+
+func (fd *FavoritesDocument) IsValid() error {
+	if fd.Version < 1 || fd.Version > FavoritesDocumentVersion {
+		return fmt.Errorf("unsupported favorites document version %d", fd.Version)
+	}
+	if len(fd.Pages) > maxFavoritesDocumentItems || len(fd.Folders) > maxFavoritesDocumentItems {
+		return fmt.Errorf("favorites document can have at most %d pages and folders", maxFavoritesDocumentItems)
+	}
+
+	folders := make(map[string]bool, len(fd.Folders))
+	for _, folder := range fd.Folders {
+		if folder.Name == "" {
+			return errors.New("invalid favorites document folder name")
+		}
+		folders[folder.Name] = true
+	}
+
+	for _, page := range fd.Pages {
+		if page.Pathname == "" {
+			return errors.New("invalid favorites document page pathname")
+		}
+		if page.Folder != "" && !folders[page.Folder] {
+			return fmt.Errorf("favorites document page %s references unknown folder %s", page.Pathname, page.Folder)
+		}
+	}
+
+	return nil
+}
+
+func signFavoritesPayload(payload string, secret []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// EncodeBase64 encodes the document as base64 JSON followed by its HMAC signature, separated by a dot.
+func (fd *FavoritesDocument) EncodeBase64(secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("favorites document signing key is not configured")
+	}
+
+	err := fd.IsValid()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := base64.NewEncoder(base64.StdEncoding, &buf)
+	err = json.NewEncoder(encoder).Encode(fd)
+	if err != nil {
+		return "", err
+	}
+	encoder.Close()
+
+	payload := buf.String()
+	return payload + "." + signFavoritesPayload(payload, secret), nil
+}
+
+func DecodeFavoritesBase64(encoded string, secret []byte) (FavoritesDocument, error) {
+	var fd FavoritesDocument
+	if len(secret) == 0 {
+		return fd, errors.New("favorites document signing key is not configured")
+	}
+
+	payload, signature, found := strings.Cut(strings.TrimSpace(encoded), ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signFavoritesPayload(payload, secret))) {
+		return fd, errors.New("invalid favorites document signature")
+	}
+
+	decoder := base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(payload))
+	err := json.NewDecoder(decoder).Decode(&fd)
+	if err != nil {
+		return fd, err
+	}
+
+	err = fd.IsValid()
+	return fd, err
 }
@@ -0,0 +1,68 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFavoritesSecret = []byte("favorites-secret")
+
+var validFavoritesDocument = models.FavoritesDocument{
+	Version: models.FavoritesDocumentVersion,
+	Folders: []models.FavoritesDocumentFolder{{Name: "Insights", Position: 0}},
+	Pages: []models.FavoritesDocumentPage{
+		{Pathname: "/insights/dashboard", Position: 0},
+		{Pathname: "/insights/advisor", Title: "Advisor", Folder: "Insights", Position: 0},
+	},
+}
+
+func TestFavoritesDocumentValidation(t *testing.T) {
+	t.Run("Should accept valid document", func(t *testing.T) {
+		assert.NoError(t, validFavoritesDocument.IsValid())
+	})
+
+	t.Run("Should reject newer versions", func(t *testing.T) {
+		document := validFavoritesDocument
+		document.Version = models.FavoritesDocumentVersion + 1
+		assert.Error(t, document.IsValid())
+	})
+
+	t.Run("Should reject unknown folders", func(t *testing.T) {
+		document := validFavoritesDocument
+		document.Folders = nil
+		assert.Error(t, document.IsValid())
+	})
+}
+
+func TestFavoritesDocumentEncoding(t *testing.T) {
+	encoded, err := validFavoritesDocument.EncodeBase64(testFavoritesSecret)
+	require.NoError(t, err)
+
+	t.Run("Should decode signed document", func(t *testing.T) {
+		decoded, err := models.DecodeFavoritesBase64(encoded, testFavoritesSecret)
+		require.NoError(t, err)
+		assert.Equal(t, validFavoritesDocument.Pages, decoded.Pages)
+		assert.Equal(t, validFavoritesDocument.Folders, decoded.Folders)
+	})
+
+	t.Run("Should reject document signed with another key", func(t *testing.T) {
+		_, err := models.DecodeFavoritesBase64(encoded, []byte("other-secret"))
+		assert.EqualError(t, err, "invalid favorites document signature")
+	})
+
+	t.Run("Should reject tampered document", func(t *testing.T) {
+		payload, signature, _ := strings.Cut(encoded, ".")
+		tampered := "x" + payload[1:] + "." + signature
+		_, err := models.DecodeFavoritesBase64(tampered, testFavoritesSecret)
+		assert.EqualError(t, err, "invalid favorites document signature")
+	})
+
+	t.Run("Should require signing key", func(t *testing.T) {
+		_, err := validFavoritesDocument.EncodeBase64(nil)
+		assert.Error(t, err)
+	})
+}
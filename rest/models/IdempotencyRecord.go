@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// IdempotencyRecord is the first response to a request sent with an Idempotency-Key header. Requests
+// repeating the key are answered with the stored response until the record expires. The record is
+// reserved before the request is handled, Completed is set once the response is stored.
+type IdempotencyRecord struct {
+	ID             uint   `gorm:"primarykey"`
+	UserIdentityID uint   `gorm:"not null;uniqueIndex:idx_idempotency_key"`
+	Key            string `gorm:"not null;uniqueIndex:idx_idempotency_key"`
+	// RequestHash identifies the method, URL and body of the request
+	RequestHash string `gorm:"not null"`
+	Completed   bool   `gorm:"not null;default:false"`
+	Status      int
+	Header      datatypes.JSONType[map[string]string]
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type DrawerRecipientKind string
+
+const (
+	DrawerRecipientUser         DrawerRecipientKind = "user"
+	DrawerRecipientUsername     DrawerRecipientKind = "username"
+	DrawerRecipientOrganization DrawerRecipientKind = "organization"
+)
+
+// DrawerEvent is a notifications drawer CloudEvent received from kafka. It is stored once and
+// shared by all its recipients.
+type DrawerEvent struct {
+	BaseModel
+	EventID    string                 `json:"eventId" gorm:"uniqueIndex"`
+	Source     string                 `json:"source"`
+	Time       time.Time              `json:"time" gorm:"index"`
+	Payload    datatypes.JSON         `json:"payload" gorm:"type: JSONB"`
+	Recipients []DrawerEventRecipient `json:"-"`
+}
+
+// DrawerEventRecipient is one of the destinations of a drawer event.
+type DrawerEventRecipient struct {
+	ID            uint                `gorm:"primarykey" json:"-"`
+	DrawerEventID uint                `gorm:"index" json:"-"`
+	Kind          DrawerRecipientKind `gorm:"index:idx_drawer_recipient" json:"kind"`
+	Value         string              `gorm:"index:idx_drawer_recipient" json:"value"`
+}
+
+// DrawerItemState holds the read and dismissed state of a drawer event for a user. Events without
+// a state are unread.
+type DrawerItemState struct {
+	BaseModel
+	UserIdentityID uint `json:"userIdentityId" gorm:"uniqueIndex:idx_drawer_item_state"`
+	DrawerEventID  uint `json:"drawerEventId" gorm:"uniqueIndex:idx_drawer_item_state"`
+	Read           bool `json:"read"`
+	Dismissed      bool `json:"dismissed"`
+}
+
+// DrawerItem is a drawer event as seen by a user.
+type DrawerItem struct {
+	ID        uint           `json:"id"`
+	EventID   string         `json:"eventId"`
+	Source    string         `json:"source"`
+	Time      time.Time      `json:"time"`
+	Payload   datatypes.JSON `json:"payload"`
+	Read      bool           `json:"read"`
+	Dismissed bool           `json:"dismissed"`
+}
+
+// DrawerStateRequest selects the drawer items to update, all the user items when All is set.
+type DrawerStateRequest struct {
+	IDs []uint `json:"ids"`
+	All bool   `json:"all"`
+}
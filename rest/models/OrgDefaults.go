@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const maxOrgStarterFavorites = 50
+
+// OrgDashboardTemplate is the dashboard layout an org admin published for the users of the organization.
+// New user dashboards are forked from it instead of the base template.
+type OrgDashboardTemplate struct {
+	BaseModel
+	OrgID          string         `json:"orgId" gorm:"uniqueIndex:idx_org_dashboard_template"`
+	Name           string         `json:"name" gorm:"uniqueIndex:idx_org_dashboard_template"`
+	TemplateConfig TemplateConfig `gorm:"not null;default null; embedded" json:"templateConfig"`
+	UpdatedBy      string         `json:"updatedBy"`
+}
+
+// OrgStarterFavorite is a favorite page new users of the organization are seeded with.
+type OrgStarterFavorite struct {
+	BaseModel
+	OrgID     string `json:"orgId" gorm:"index"`
+	Pathname  string `json:"pathname"`
+	Title     string `json:"title,omitempty"`
+	Position  int    `json:"position"`
+	UpdatedBy string `json:"updatedBy"`
+}
+
+type OrgStarterFavoritePage struct {
+	Pathname string `json:"pathname"`
+	Title    string `json:"title,omitempty"`
+}
+
+// OrgStarterFavoritesRequest replaces the starter favorites of the organization, the list order is kept.
+type OrgStarterFavoritesRequest struct {
+	Pages []OrgStarterFavoritePage `json:"pages"`
+}
+
+func (or OrgStarterFavoritesRequest) IsValid() error {
+	if len(or.Pages) > maxOrgStarterFavorites {
+		return fmt.Errorf("an organization can have at most %d starter favorites", maxOrgStarterFavorites)
+	}
+	pathnames := make(map[string]bool, len(or.Pages))
+	for _, page := range or.Pages {
+		if page.Pathname == "" {
+			return errors.New("invalid starter favorite, field pathname is required")
+		}
+		if pathnames[page.Pathname] {
+			return fmt.Errorf("duplicate starter favorite %s", page.Pathname)
+		}
+		pathnames[page.Pathname] = true
+	}
+	return nil
+}
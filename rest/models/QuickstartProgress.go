@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	maxQuickstartIDLength = 255
+	maxQuickstartTasks    = 100
+)
+
+// QuickstartTaskStatus matches the task statuses of the quickstarts frontend.
+type QuickstartTaskStatus string
+
+const (
+	QuickstartTaskInitial QuickstartTaskStatus = "Initial"
+	QuickstartTaskVisited QuickstartTaskStatus = "Visited"
+	QuickstartTaskReview  QuickstartTaskStatus = "Review"
+	QuickstartTaskSuccess QuickstartTaskStatus = "Success"
+	QuickstartTaskFailed  QuickstartTaskStatus = "Failed"
+)
+
+func (qs QuickstartTaskStatus) IsValid() error {
+	switch qs {
+	case QuickstartTaskInitial, QuickstartTaskVisited, QuickstartTaskReview, QuickstartTaskSuccess, QuickstartTaskFailed:
+		return nil
+	}
+
+	return fmt.Errorf("invalid quickstart task status. Expected one of %s, %s, %s, %s, %s, got %s", QuickstartTaskInitial, QuickstartTaskVisited, QuickstartTaskReview, QuickstartTaskSuccess, QuickstartTaskFailed, qs)
+}
+
+// QuickstartProgress is the progress of a user in a single quickstart or guided tour.
+// TaskIndex is the active task, -1 before the first task is opened.
+type QuickstartProgress struct {
+	BaseModel
+	UserIdentityID uint                                       `json:"userIdentityId" gorm:"uniqueIndex:idx_quickstart_progress"`
+	QuickstartID   string                                     `json:"quickstartId" gorm:"uniqueIndex:idx_quickstart_progress"`
+	Bundle         string                                     `json:"bundle" gorm:"index"`
+	TaskIndex      int                                        `json:"taskIndex"`
+	TaskStatuses   datatypes.JSONType[[]QuickstartTaskStatus] `json:"taskStatuses"`
+	StartedAt      time.Time                                  `json:"startedAt"`
+	CompletedAt    *time.Time                                 `json:"completedAt"`
+}
+
+// QuickstartProgressRequest stores the progress of a quickstart. Completed marks the quickstart as done,
+// the completion time is kept until the progress is reset.
+type QuickstartProgressRequest struct {
+	Bundle       string                 `json:"bundle"`
+	TaskIndex    int                    `json:"taskIndex"`
+	TaskStatuses []QuickstartTaskStatus `json:"taskStatuses"`
+	Completed    bool                   `json:"completed"`
+}
+
+func (qr QuickstartProgressRequest) IsValid() error {
+	if len(qr.TaskStatuses) > maxQuickstartTasks {
+		return fmt.Errorf("a quickstart can have at most %d tasks", maxQuickstartTasks)
+	}
+	if qr.TaskIndex < -1 || (len(qr.TaskStatuses) > 0 && qr.TaskIndex >= len(qr.TaskStatuses)) {
+		return fmt.Errorf("invalid task index %d", qr.TaskIndex)
+	}
+	for _, status := range qr.TaskStatuses {
+		if err := status.IsValid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func IsValidQuickstartID(quickstartID string) error {
+	if quickstartID == "" || len(quickstartID) > maxQuickstartIDLength {
+		return errors.New("invalid quickstart id")
+	}
+	return nil
+}
+
+// QuickstartBundleSummary counts the quickstarts of a bundle by state.
+type QuickstartBundleSummary struct {
+	Bundle     string `json:"bundle"`
+	Started    int    `json:"started"`
+	InProgress int    `json:"inProgress"`
+	Completed  int    `json:"completed"`
+}
+
+// QuickstartStats aggregates the progress of every user in a quickstart.
+type QuickstartStats struct {
+	QuickstartID string `json:"quickstartId"`
+	Bundle       string `json:"bundle"`
+	Started      int    `json:"started"`
+	Completed    int    `json:"completed"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// RateLimitBucket is a token bucket shared by every replica. The key identifies the owner and the route
+// class of the bucket, for example "user:1234:write". Times are unix seconds so the database can refill
+// the bucket and take a token in a single statement.
+type RateLimitBucket struct {
+	Key            string  `gorm:"primaryKey"`
+	Tokens         float64 `gorm:"not null"`
+	RefilledAtUnix float64 `gorm:"not null"`
+	// FullAtUnix is when the bucket refilled completely, the bucket is deleted after that
+	FullAtUnix float64 `gorm:"not null;index"`
+	// Taken reports whether the last request took a token from the bucket
+	Taken bool `gorm:"not null"`
+}
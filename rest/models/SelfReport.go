@@ -9,4 +9,6 @@ type SelfReport struct {
 	ProductsOfInterest pq.StringArray `gorm:"type:text[]" json:"productsOfInterest"`
 	JobRole            string         `json:"jobRole"`
 	UserIdentityID     uint           `json:"userIdentityID"`
+	// Version is bumped on every write, the entity tag of the report is derived from it
+	Version int `gorm:"not null;default:1" json:"-"`
 }
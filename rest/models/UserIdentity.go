@@ -17,30 +17,82 @@ type Workspace struct {
 }
 
 type VisitedPage struct {
-	Bundle   string `json:"bundle"`
-	Pathname string `json:"pathname"`
-	Title    string `json:"title"`
+	Bundle    string     `json:"bundle"`
+	Pathname  string     `json:"pathname"`
+	Title     string     `json:"title"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
 }
 
+// BundleVisit tracks how often and when a user visited a bundle. Visits migrated from the
+// legacy boolean map have no timestamps.
+type BundleVisit struct {
+	Bundle             string     `json:"bundle"`
+	FirstVisit         *time.Time `json:"firstVisit"`
+	LastVisit          *time.Time `json:"lastVisit"`
+	VisitCount         int        `json:"visitCount"`
+	ReturningAfterDays int        `json:"returningAfterDays"`
+}
+
+type BundleVisitsSummary struct {
+	BundleCount        int          `json:"bundleCount"`
+	TotalVisits        int          `json:"totalVisits"`
+	MostUsedBundle     *BundleVisit `json:"mostUsedBundle"`
+	MostRecentBundle   *BundleVisit `json:"mostRecentBundle"`
+	ReturningAfterDays int          `json:"returningAfterDays"`
+}
+
+// PreviewFeatureState is the enrollment of a user into a single preview feature.
+type PreviewFeatureState struct {
+	Enrolled   bool       `json:"enrolled"`
+	EnrolledAt *time.Time `json:"enrolledAt,omitempty"`
+	SeenAt     *time.Time `json:"seenAt,omitempty"`
+}
+
+// LoginSummary describes the recent activity of a user. A session is recorded at most once per
+// session window.
+type LoginSummary struct {
+	TotalSessions int `json:"totalSessions"`
+	// RecentSessions counts the sessions of the last RecentDays days, 30 unless less login history is kept
+	RecentSessions         int        `json:"recentSessions"`
+	RecentDays             int        `json:"recentDays"`
+	CurrentSessionStart    time.Time  `json:"currentSessionStart"`
+	PreviousSessionStart   *time.Time `json:"previousSessionStart"`
+	ReturningAfterDays     int        `json:"returningAfterDays"`
+	DaysSinceAccountCreate int        `json:"daysSinceAccountCreate"`
+}
+
+// LastVisitedRequest accepts either a single visit event in "page" or a list of pages
+// ordered from the most recent. Both are merged into the stored history.
 type LastVisitedRequest struct {
+	Page  *VisitedPage  `json:"page,omitempty"`
 	Pages []VisitedPage `json:"pages"`
 }
 
 type UserIdentity struct {
 	BaseModel
-	AccountId              string                            `json:"accountId,omitempty"`
-	FirstLogin             bool                              `json:"firstLogin"`
-	DayOne                 bool                              `json:"dayOne"`
-	LastLogin              time.Time                         `json:"lastLogin"`
-	LastVisitedPages       datatypes.JSONType[[]VisitedPage] `json:"lastVisitedPages"`
-	RecentlyUsedWorkspaces datatypes.JSONType[[]Workspace]   `json:"recentlyUsedWorkspaces"`
-	FavoritePages          []FavoritePage                    `json:"favoritePages"`
-	SelfReport             SelfReport                        `json:"selfReport"`
-	VisitedBundles         datatypes.JSON                    `json:"visitedBundles,omitempty" gorm:"type: JSONB"`
-	DashboardTemplates     []DashboardTemplate               `json:"dashboardTemplates,omitempty"`
-	UIPreview              bool                              `json:"uiPreview"`
-	UIPreviewSeen          bool                              `json:"uiPreviewSeen"`
-	ActiveWorkspace        string                            `json:"activeWorkspace"`
+	AccountId              string                                             `json:"accountId,omitempty"`
+	FirstLogin             bool                                               `json:"firstLogin"`
+	DayOne                 bool                                               `json:"dayOne"`
+	LastLogin              time.Time                                          `json:"lastLogin"`
+	PreviousLogin          *time.Time                                         `json:"previousLogin,omitempty"`
+	SessionCount           int                                                `json:"sessionCount" gorm:"default:1"`
+	LoginHistory           datatypes.JSONType[[]time.Time]                    `json:"loginHistory"`
+	LastVisitedPages       datatypes.JSONType[[]VisitedPage]                  `json:"lastVisitedPages"`
+	RecentlyUsedWorkspaces datatypes.JSONType[[]Workspace]                    `json:"recentlyUsedWorkspaces"`
+	FavoritePages          []FavoritePage                                     `json:"favoritePages"`
+	SelfReport             SelfReport                                         `json:"selfReport"`
+	VisitedBundles         datatypes.JSON                                     `json:"visitedBundles,omitempty" gorm:"type: JSONB"`
+	BundleVisits           datatypes.JSONType[map[string]BundleVisit]         `json:"bundleVisits"`
+	DashboardTemplates     []DashboardTemplate                                `json:"dashboardTemplates,omitempty"`
+	QuickstartProgress     []QuickstartProgress                               `json:"quickstartProgress,omitempty"`
+	UIPreview              bool                                               `json:"uiPreview"`
+	UIPreviewSeen          bool                                               `json:"uiPreviewSeen"`
+	PreviewFeatures        datatypes.JSONType[map[string]PreviewFeatureState] `json:"previewFeatures"`
+	ActiveWorkspace        string                                             `json:"activeWorkspace"`
+	// FavoritesVersion is bumped by every write to the favorite pages, the entity tag of the list is derived from it
+	FavoritesVersion int `gorm:"not null;default:1" json:"-"`
+	// WorkspacesVersion is bumped by every write to the recently used workspaces, the entity tag of the list is derived from it
+	WorkspacesVersion int `gorm:"not null;default:1" json:"-"`
 }
 
 type UserIdentityResponse struct {
@@ -49,11 +101,13 @@ type UserIdentityResponse struct {
 	FirstLogin       bool           `json:"firstLogin"`
 	DayOne           bool           `json:"dayOne"`
 	LastLogin        time.Time      `json:"lastLogin"`
+	LoginSummary     LoginSummary   `json:"loginSummary"`
 	LastVisitedPages []VisitedPage  `json:"lastVisitedPages"`
 	FavoritePages    []FavoritePage `json:"favoritePages"`
 	SelfReport       SelfReport     `json:"selfReport"`
 	VisitedBundles   datatypes.JSON `json:"visitedBundles,omitempty" gorm:"type: JSONB"`
 	UIPreview        bool           `json:"uiPreview"`
 	UIPreviewSeen    bool           `json:"uiPreviewSeen"`
+	PreviewFeatures  []string       `json:"previewFeatures"`
 	ActiveWorkspace  string         `json:"activeWorkspace"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+)
+
+// UserPreference stores the JSON document of a single preferences namespace of a user.
+// Version is incremented on every write and used for optimistic concurrency.
+type UserPreference struct {
+	BaseModel
+	UserIdentityID uint           `json:"userIdentityId" gorm:"uniqueIndex:idx_user_preference_namespace"`
+	Namespace      string         `json:"namespace" gorm:"uniqueIndex:idx_user_preference_namespace"`
+	Value          datatypes.JSON `json:"value" gorm:"type: JSONB"`
+	Version        int            `json:"version" gorm:"default:0"`
+}
+
+// UserPreferenceRequest replaces (PUT) or merge patches (PATCH) a namespace document. When set,
+// Version has to match the stored version or the request is rejected.
+type UserPreferenceRequest struct {
+	Value   datatypes.JSON `json:"value"`
+	Version *int           `json:"version,omitempty"`
+}
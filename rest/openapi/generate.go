@@ -0,0 +1,311 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Envelope is the shape of the body of a success response.
+type Envelope int
+
+const (
+	// NoBody documents responses without a body
+	NoBody Envelope = iota
+	// EntityEnvelope documents a {"data": <Response>} body
+	EntityEnvelope
+	// ListEnvelope documents a {"data": [<Response>], "meta": ..., "links": ...} body
+	ListEnvelope
+	// RawBody documents the Response schema as is, for schemas that are their own envelope
+	RawBody
+)
+
+// GeneratedOperation describes an operation of a generated document.
+type GeneratedOperation struct {
+	Method string
+	// Path uses the OpenAPI template syntax, which is also the chi route syntax
+	Path string
+	// From is the "METHOD /path" of a source document operation accepting the same query. Its
+	// description and query and header parameters are copied.
+	From        string
+	Description string
+	// Parameters lists parameter components of the operation
+	Parameters []string
+	// Request is the schema component of the JSON request body, empty without a body
+	Request string
+	Status  int
+	// Response is the schema component of the response body, wrapped as described by Envelope
+	Response string
+	Envelope Envelope
+	// Errors lists the error statuses of the operation besides the DefaultErrors, they reference the
+	// ErrorResponses components
+	Errors []int
+}
+
+// GenerateOptions describe the generated document.
+type GenerateOptions struct {
+	// Generator names the command regenerating the document in its header
+	Generator   string
+	Title       string
+	Description string
+	Version     string
+	Server      string
+	// Components are merged into the components of the source document
+	Components []byte
+	// PathParameterTypes maps path parameter names to their schema type, other parameters are strings
+	PathParameterTypes map[string]string
+	// ErrorResponses maps error statuses to response components
+	ErrorResponses map[int]string
+	// DefaultErrors lists the error statuses of every operation
+	DefaultErrors []int
+}
+
+var pathParameterPattern = regexp.MustCompile(`{([^}]+)}`)
+
+// decodeDocument reads a YAML document into the plain maps and slices JSON decoding produces.
+func decodeDocument(data []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(jsonData, &document); err != nil {
+		return nil, fmt.Errorf("expected an object: %w", err)
+	}
+	return document, nil
+}
+
+func componentRef(kind string, name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/" + kind + "/" + name}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+func envelopeSchema(envelope Envelope, schema string) (interface{}, error) {
+	if schema == "" {
+		return nil, fmt.Errorf("missing response schema")
+	}
+	switch envelope {
+	case EntityEnvelope:
+		return map[string]interface{}{
+			"type":       "object",
+			"required":   []interface{}{"data"},
+			"properties": map[string]interface{}{"data": componentRef("schemas", schema)},
+		}, nil
+	case ListEnvelope:
+		return map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"data", "meta"},
+			"properties": map[string]interface{}{
+				"data":  map[string]interface{}{"type": "array", "items": componentRef("schemas", schema)},
+				"meta":  componentRef("schemas", "ListMeta"),
+				"links": componentRef("schemas", "ListLinks"),
+			},
+		}, nil
+	case RawBody:
+		return componentRef("schemas", schema), nil
+	}
+	return nil, fmt.Errorf("unknown envelope %d", envelope)
+}
+
+// sourceOperation returns the description and the query and header parameters of an operation of the
+// source document.
+func sourceOperation(source map[string]interface{}, from string) (string, []interface{}, error) {
+	method, path, ok := strings.Cut(from, " ")
+	if !ok {
+		return "", nil, fmt.Errorf("invalid source operation %q, expected METHOD /path", from)
+	}
+	paths, _ := source["paths"].(map[string]interface{})
+	item, _ := paths[path].(map[string]interface{})
+	op, ok := item[strings.ToLower(method)].(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("source operation %s is not documented", from)
+	}
+
+	params := []interface{}{}
+	inherited, _ := item["parameters"].([]interface{})
+	own, _ := op["parameters"].([]interface{})
+	for _, param := range append(append([]interface{}{}, inherited...), own...) {
+		if definition, ok := param.(map[string]interface{}); ok && definition["in"] == "path" {
+			continue
+		}
+		params = append(params, param)
+	}
+	description, _ := op["description"].(string)
+	return description, params, nil
+}
+
+func generatedOperation(source map[string]interface{}, operation GeneratedOperation, options GenerateOptions) (map[string]interface{}, error) {
+	op := map[string]interface{}{}
+	description := operation.Description
+	params := []interface{}{}
+	if operation.From != "" {
+		sourceDescription, sourceParams, err := sourceOperation(source, operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if description == "" {
+			description = sourceDescription
+		}
+		params = append(params, sourceParams...)
+	}
+	for _, name := range operation.Parameters {
+		params = append(params, componentRef("parameters", name))
+	}
+	if description != "" {
+		op["description"] = description
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if operation.Request != "" {
+		op["requestBody"] = map[string]interface{}{"required": true, "content": jsonContent(componentRef("schemas", operation.Request))}
+	}
+
+	if operation.Status == 0 {
+		return nil, fmt.Errorf("missing success status")
+	}
+	success := map[string]interface{}{"description": http.StatusText(operation.Status)}
+	if operation.Envelope != NoBody {
+		schema, err := envelopeSchema(operation.Envelope, operation.Response)
+		if err != nil {
+			return nil, err
+		}
+		success["content"] = jsonContent(schema)
+	}
+	responses := map[string]interface{}{strconv.Itoa(operation.Status): success}
+	for _, status := range append(append([]int{}, options.DefaultErrors...), operation.Errors...) {
+		name, ok := options.ErrorResponses[status]
+		if !ok {
+			return nil, fmt.Errorf("no response component for status %d", status)
+		}
+		responses[strconv.Itoa(status)] = componentRef("responses", name)
+	}
+	op["responses"] = responses
+	return op, nil
+}
+
+func pathParameters(path string, options GenerateOptions) []interface{} {
+	params := []interface{}{}
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(path, -1) {
+		schemaType := options.PathParameterTypes[match[1]]
+		if schemaType == "" {
+			schemaType = "string"
+		}
+		params = append(params, map[string]interface{}{
+			"in":       "path",
+			"name":     match[1],
+			"required": true,
+			"schema":   map[string]interface{}{"type": schemaType},
+		})
+	}
+	return params
+}
+
+// Generate builds a document for operations, sharing the components of the source document. The
+// output is stable so generated documents can be committed and checked for drift.
+func Generate(source []byte, options GenerateOptions, operations []GeneratedOperation) ([]byte, error) {
+	sourceDocument, err := decodeDocument(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source document: %w", err)
+	}
+
+	components, _ := sourceDocument["components"].(map[string]interface{})
+	if components == nil {
+		components = map[string]interface{}{}
+	}
+	if len(options.Components) > 0 {
+		extra, err := decodeDocument(options.Components)
+		if err != nil {
+			return nil, fmt.Errorf("invalid components: %w", err)
+		}
+		for kind, values := range extra {
+			additions, ok := values.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid components: %s is not an object", kind)
+			}
+			merged, _ := components[kind].(map[string]interface{})
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			for name, value := range additions {
+				if _, ok := merged[name]; ok {
+					return nil, fmt.Errorf("component %s %s is already defined by the source document", kind, name)
+				}
+				merged[name] = value
+			}
+			components[kind] = merged
+		}
+	}
+
+	paths := map[string]interface{}{}
+	for _, operation := range operations {
+		op, err := generatedOperation(sourceDocument, operation, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", operation.Method, operation.Path, err)
+		}
+
+		item, _ := paths[operation.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			if params := pathParameters(operation.Path, options); len(params) > 0 {
+				item["parameters"] = params
+			}
+			paths[operation.Path] = item
+		}
+		method := strings.ToLower(operation.Method)
+		if _, ok := item[method]; ok {
+			return nil, fmt.Errorf("%s %s is defined twice", operation.Method, operation.Path)
+		}
+		item[method] = op
+	}
+
+	info := map[string]interface{}{
+		"title":       options.Title,
+		"description": options.Description,
+		"version":     options.Version,
+	}
+	if sourceInfo, ok := sourceDocument["info"].(map[string]interface{}); ok && sourceInfo["license"] != nil {
+		info["license"] = sourceInfo["license"]
+	}
+	// a struct keeps the top level keys in the usual order, nested maps are sorted by key
+	document := struct {
+		OpenAPI    interface{}            `yaml:"openapi"`
+		Info       map[string]interface{} `yaml:"info"`
+		Servers    []interface{}          `yaml:"servers"`
+		Paths      map[string]interface{} `yaml:"paths"`
+		Components map[string]interface{} `yaml:"components"`
+	}{
+		OpenAPI:    sourceDocument["openapi"],
+		Info:       info,
+		Servers:    []interface{}{map[string]interface{}{"url": options.Server}},
+		Paths:      paths,
+		Components: components,
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "# Code generated by %s. DO NOT EDIT.\n", options.Generator)
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
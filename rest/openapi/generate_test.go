@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatorComponents = `
+schemas:
+  ListMeta:
+    type: object
+  ListLinks:
+    type: object
+  ItemRequest:
+    type: object
+    required:
+    - name
+    properties:
+      name:
+        type: string
+`
+
+func TestGenerate(t *testing.T) {
+	options := GenerateOptions{
+		Generator:          "go test",
+		Title:              "Test",
+		Version:            "2.0.0",
+		Server:             "/api/test/v2/",
+		Components:         []byte(generatorComponents),
+		PathParameterTypes: map[string]string{"itemId": "integer"},
+		ErrorResponses:     map[int]string{http.StatusBadRequest: "BadRequest"},
+		DefaultErrors:      []int{http.StatusBadRequest},
+	}
+	operations := []GeneratedOperation{
+		{Method: http.MethodGet, Path: "/items", From: "GET /items", Status: http.StatusOK, Response: "Item", Envelope: ListEnvelope},
+		{Method: http.MethodPost, Path: "/items", Request: "ItemRequest", Status: http.StatusCreated, Response: "Item", Envelope: EntityEnvelope},
+		{Method: http.MethodDelete, Path: "/items/{itemId}", Status: http.StatusNoContent},
+	}
+
+	document, err := Generate([]byte(testDocument), options, operations)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(document), "# Code generated by go test. DO NOT EDIT.\n"))
+
+	spec, err := Parse(document)
+	require.NoError(t, err)
+
+	t.Run("should copy the query parameters of the source operation", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/test/v2/items?limit=0", nil)
+		assert.Error(t, spec.ValidateRequest(request))
+	})
+
+	t.Run("should wrap responses in envelopes", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/test/v2/items", nil)
+		header := http.Header{"Content-Type": []string{"application/json"}}
+		assert.NoError(t, spec.ValidateResponse(request, http.StatusOK, header, []byte(`{"data": [{"name": "a"}], "meta": {"count": 1}}`)))
+		assert.Error(t, spec.ValidateResponse(request, http.StatusOK, header, []byte(`[{"name": "a"}]`)))
+
+		request = httptest.NewRequest(http.MethodPost, "/api/test/v2/items", strings.NewReader(`{"name": "a"}`))
+		assert.NoError(t, spec.ValidateRequest(request))
+		assert.NoError(t, spec.ValidateResponse(request, http.StatusCreated, header, []byte(`{"data": {"name": "a"}}`)))
+	})
+
+	t.Run("should declare the path parameters", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodDelete, "/api/test/v2/items/abc", nil)
+		assert.Error(t, spec.ValidateRequest(request))
+		request = httptest.NewRequest(http.MethodDelete, "/api/test/v2/items/1", nil)
+		assert.NoError(t, spec.ValidateRequest(request))
+	})
+
+	t.Run("should reject components defined by the source document", func(t *testing.T) {
+		options := options
+		options.Components = []byte("schemas:\n  Item:\n    type: object\n")
+		_, err := Generate([]byte(testDocument), options, operations)
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject undocumented source operations", func(t *testing.T) {
+		_, err := Generate([]byte(testDocument), options, []GeneratedOperation{
+			{Method: http.MethodGet, Path: "/other", From: "GET /other", Status: http.StatusOK},
+		})
+		assert.Error(t, err)
+	})
+}
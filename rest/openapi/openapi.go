@@ -0,0 +1,199 @@
+// Package openapi validates requests and responses against the OpenAPI document of the service.
+//
+// The validation itself is done by kin-openapi, this package only adapts it to the service: requests
+// are matched without their trailing slash, the bodies stay readable for the handlers and the errors
+// are collected in a ValidationError the problem responses can list.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// Spec is a loaded OpenAPI document.
+type Spec struct {
+	router routers.Router
+}
+
+// Load reads the OpenAPI document at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read OpenAPI document: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse loads a YAML or JSON OpenAPI document, documents that are not valid OpenAPI are rejected.
+func Parse(data []byte) (*Spec, error) {
+	loader := openapi3.NewLoader()
+	document, err := loader.LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if err := document.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	router, err := legacy.NewRouter(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return &Spec{router: router}, nil
+}
+
+// ValidationError lists every way a request or response does not match the document.
+type ValidationError struct {
+	Errors []string
+}
+
+func (ve *ValidationError) Error() string {
+	return strings.Join(ve.Errors, "; ")
+}
+
+// newValidationError collects the errors reported by kin-openapi, each with the location of the
+// invalid value and without the dump of the schema.
+func newValidationError(location string, err error) *ValidationError {
+	ve := &ValidationError{}
+	ve.add(location, err)
+	return ve
+}
+
+func (ve *ValidationError) add(location string, err error) {
+	switch cause := err.(type) {
+	case openapi3.MultiError:
+		for _, err := range cause {
+			ve.add(location, err)
+		}
+		return
+	case *openapi3filter.RequestError:
+		if cause.Parameter != nil {
+			location = fmt.Sprintf("%s parameter %s", cause.Parameter.In, cause.Parameter.Name)
+		} else if cause.RequestBody != nil {
+			location = "body"
+		}
+		if cause.Err != nil {
+			ve.add(location, cause.Err)
+			return
+		}
+		err = errors.New(cause.Reason)
+	case *openapi3filter.ResponseError:
+		if cause.Err != nil {
+			ve.add(location, cause.Err)
+			return
+		}
+		err = errors.New(cause.Reason)
+	case *openapi3.SchemaError:
+		if pointer := cause.JSONPointer(); len(pointer) > 0 {
+			location += "/" + strings.Join(pointer, "/")
+		}
+		err = errors.New(cause.Reason)
+	}
+	ve.Errors = append(ve.Errors, fmt.Sprintf("%s: %v", location, err))
+}
+
+var validationOptions = &openapi3filter.Options{
+	MultiError:            true,
+	IncludeResponseStatus: true,
+	// requests reach the handlers as they were sent
+	SkipSettingDefaults: true,
+	// the identity is checked by the middlewares
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+}
+
+// findRoute returns the documented operation of the request. Requests outside of the documented paths
+// and methods are not validated.
+func (s *Spec) findRoute(r *http.Request) (*routers.Route, map[string]string, bool) {
+	// the routes of the service accept a trailing slash, the document does not list it
+	matched := r
+	if path := strings.TrimSuffix(r.URL.Path, "/"); path != r.URL.Path {
+		matched = r.Clone(r.Context())
+		matched.URL.Path = path
+		matched.URL.RawPath = ""
+	}
+	route, pathParams, err := s.router.FindRoute(matched)
+	if err != nil {
+		return nil, nil, false
+	}
+	return route, pathParams, true
+}
+
+// ValidateRequest checks the parameters and the body of a request. The body is restored so handlers
+// can still decode it. A body that cannot be read is reported with the read error instead of a
+// ValidationError, for example the *http.MaxBytesError of a limited body.
+func (s *Spec) ValidateRequest(r *http.Request) error {
+	route, pathParams, ok := s.findRoute(r)
+	if !ok {
+		return nil
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+	}
+	validated := r.Clone(r.Context())
+	validated.Body = io.NopCloser(bytes.NewReader(body))
+	// handlers decode bodies sent without a content type as JSON
+	if len(body) > 0 && validated.Header.Get("Content-Type") == "" {
+		validated.Header.Set("Content-Type", "application/json")
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    validated,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    validationOptions,
+	}
+	if err := openapi3filter.ValidateRequest(context.Background(), input); err != nil {
+		return newValidationError("request", err)
+	}
+	return nil
+}
+
+// ValidateResponse checks that the status of a response is documented and that its body matches the
+// documented schema. It is meant for tests, to catch places where the document drifted from the code.
+func (s *Spec) ValidateResponse(r *http.Request, status int, header http.Header, body []byte) error {
+	route, pathParams, ok := s.findRoute(r)
+	if !ok {
+		return nil
+	}
+
+	// handlers that do not set a content type still write JSON
+	header = header.Clone()
+	if contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); contentType == "" || contentType == "text/plain" {
+		header.Set("Content-Type", "application/json")
+	}
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    validationOptions,
+		},
+		Status:  status,
+		Header:  header,
+		Body:    io.NopCloser(bytes.NewReader(body)),
+		Options: validationOptions,
+	}
+	if err := openapi3filter.ValidateResponse(context.Background(), input); err != nil {
+		return newValidationError(fmt.Sprintf("status %d", status), err)
+	}
+	return nil
+}
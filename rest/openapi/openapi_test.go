@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `
+openapi: 3.0.0
+info:
+  title: Test
+  version: 1.0.0
+servers:
+- url: "/api/test/v1/"
+paths:
+  "/items":
+    get:
+      parameters:
+      - in: query
+        name: limit
+        schema:
+          type: integer
+          minimum: 1
+      - in: query
+        name: archived
+        schema:
+          type: boolean
+      responses:
+        '200':
+          description: Items
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  "$ref": "#/components/schemas/Item"
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              "$ref": "#/components/schemas/Item"
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                "$ref": "#/components/schemas/Item"
+        '400':
+          "$ref": "#/components/responses/BadRequest"
+  "/items/{itemId}":
+    parameters:
+    - in: path
+      name: itemId
+      required: true
+      schema:
+        type: integer
+    delete:
+      responses:
+        '204':
+          description: Deleted
+  "/items/archived":
+    delete:
+      responses:
+        '204':
+          description: Deleted
+components:
+  responses:
+    BadRequest:
+      description: Bad request
+      content:
+        application/problem+json:
+          schema:
+            type: object
+            required:
+            - status
+  schemas:
+    Item:
+      type: object
+      required:
+      - name
+      properties:
+        name:
+          type: string
+          maxLength: 10
+        parentId:
+          type: integer
+          nullable: true
+`
+
+func testSpec(t *testing.T) *Spec {
+	t.Helper()
+	spec, err := Parse([]byte(testDocument))
+	require.NoError(t, err)
+	return spec
+}
+
+func validationErrors(t *testing.T, err error) []string {
+	t.Helper()
+	require.Error(t, err)
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok, "expected a validation error, got %v", err)
+	return validationErr.Errors
+}
+
+func TestValidateRequest(t *testing.T) {
+	spec := testSpec(t)
+
+	t.Run("should accept valid requests", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/test/v1/items?limit=5&archived=true", nil)
+		assert.NoError(t, spec.ValidateRequest(request))
+
+		request = httptest.NewRequest(http.MethodPost, "/api/test/v1/items/", strings.NewReader(`{"name": "item", "parentId": null}`))
+		assert.NoError(t, spec.ValidateRequest(request))
+	})
+
+	t.Run("should validate query parameters", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/test/v1/items?limit=0&archived=maybe", nil)
+		errs := validationErrors(t, spec.ValidateRequest(request))
+		assert.Len(t, errs, 2)
+		assert.Contains(t, errs[0], "query parameter limit")
+		assert.Equal(t, "query parameter archived: value maybe: an invalid boolean: invalid syntax", errs[1])
+	})
+
+	t.Run("should validate path parameters", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodDelete, "/api/test/v1/items/abc", nil)
+		errs := validationErrors(t, spec.ValidateRequest(request))
+		assert.Equal(t, []string{"path parameter itemId: value abc: an invalid integer: invalid syntax"}, errs)
+	})
+
+	t.Run("should prefer static path segments", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodDelete, "/api/test/v1/items/archived", nil)
+		assert.NoError(t, spec.ValidateRequest(request))
+	})
+
+	t.Run("should validate bodies and keep them readable", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/api/test/v1/items", strings.NewReader(`{"name": "a very long name", "parentId": "1"}`))
+		errs := validationErrors(t, spec.ValidateRequest(request))
+		assert.Equal(t, []string{"body/name: maximum string length is 10", "body/parentId: value must be an integer"}, errs)
+
+		body := make([]byte, 4)
+		n, _ := request.Body.Read(body)
+		assert.Equal(t, `{"na`, string(body[:n]))
+	})
+
+	t.Run("should require bodies", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/api/test/v1/items", nil)
+		errs := validationErrors(t, spec.ValidateRequest(request))
+		assert.Equal(t, []string{"body: value is required but missing"}, errs)
+	})
+
+	t.Run("should reject undocumented content types", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/api/test/v1/items", strings.NewReader(`name=item`))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		errs := validationErrors(t, spec.ValidateRequest(request))
+		assert.Equal(t, []string{`body: header Content-Type has unexpected value "application/x-www-form-urlencoded"`}, errs)
+	})
+
+	t.Run("should report bodies that can not be read", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/api/test/v1/items", strings.NewReader(`{"name": "item"}`))
+		request.Body = http.MaxBytesReader(httptest.NewRecorder(), request.Body, 4)
+		var maxBytesErr *http.MaxBytesError
+		assert.ErrorAs(t, spec.ValidateRequest(request), &maxBytesErr)
+	})
+
+	t.Run("should ignore undocumented operations", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPut, "/api/test/v1/items", strings.NewReader(`not json`))
+		assert.NoError(t, spec.ValidateRequest(request))
+
+		request = httptest.NewRequest(http.MethodGet, "/api/other/v1/items?limit=0", nil)
+		assert.NoError(t, spec.ValidateRequest(request))
+	})
+}
+
+func TestValidateResponse(t *testing.T) {
+	spec := testSpec(t)
+	jsonHeader := http.Header{"Content-Type": []string{"application/json"}}
+
+	t.Run("should accept documented responses", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/test/v1/items", nil)
+		assert.NoError(t, spec.ValidateResponse(request, http.StatusOK, jsonHeader, []byte(`[{"name": "item"}]`)))
+
+		problemHeader := http.Header{"Content-Type": []string{"application/problem+json"}}
+		request = httptest.NewRequest(http.MethodPost, "/api/test/v1/items", nil)
+		assert.NoError(t, spec.ValidateResponse(request, http.StatusBadRequest, problemHeader, []byte(`{"status": 400}`)))
+	})
+
+	t.Run("should report bodies drifting from the schema", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/test/v1/items", nil)
+		errs := validationErrors(t, spec.ValidateResponse(request, http.StatusOK, jsonHeader, []byte(`{"data": []}`)))
+		assert.Equal(t, []string{"status 200: value must be an array"}, errs)
+	})
+
+	t.Run("should report undocumented statuses", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodDelete, "/api/test/v1/items/1", nil)
+		errs := validationErrors(t, spec.ValidateResponse(request, http.StatusConflict, jsonHeader, nil))
+		assert.Equal(t, []string{"status 409: status is not supported"}, errs)
+	})
+}
@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+)
+
+func announcementAudience(r *http.Request) service.AnnouncementAudience {
+	audience := service.AnnouncementAudience{
+		User:         r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity),
+		Bundle:       r.URL.Query().Get("bundle"),
+		Entitlements: []string{},
+	}
+	if id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil {
+		audience.OrgID = id.Identity.OrgID
+		for name, entitlement := range id.Entitlements {
+			if entitlement.IsEntitled {
+				audience.Entitlements = append(audience.Entitlements, name)
+			}
+		}
+	}
+	return audience
+}
+
+func parseAnnouncementID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "announcementId"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid announcement ID", util.ErrBadRequest)
+	}
+	return uint(id), nil
+}
+
+func GetAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := service.GetUserAnnouncements(announcementAudience(r))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.Announcement]{
+		Data: announcements,
+		Meta: util.ListMeta{Count: len(announcements), Total: len(announcements)},
+	})
+}
+
+func DismissAnnouncement(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	announcementID, err := parseAnnouncementID(r)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	if err := service.DismissAnnouncement(user.ID, announcementID); err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func ListAllAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := service.ListAnnouncements()
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.Announcement]{
+		Data: announcements,
+		Meta: util.ListMeta{Count: len(announcements), Total: len(announcements)},
+	})
+}
+
+func CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request models.AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	announcement, err := service.CreateAnnouncement(request, user.AccountId)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "CREATE", "announcement", user.AccountId, "failure", "create announcement failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "CREATE", "announcement", fmt.Sprint(announcement.ID), "success")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(util.EntityResponse[models.Announcement]{Data: announcement})
+}
+
+func UpdateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	announcementID, err := parseAnnouncementID(r)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	var request models.AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	announcement, err := service.UpdateAnnouncement(announcementID, request)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "announcement", chi.URLParam(r, "announcementId"), "failure", "update announcement failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "announcement", chi.URLParam(r, "announcementId"), "success")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.Announcement]{Data: announcement})
+}
+
+func DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	announcementID, err := parseAnnouncementID(r)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	if err := service.DeleteAnnouncement(announcementID); err != nil {
+		securitylog.LogWithReason(r.Context(), "DELETE", "announcement", chi.URLParam(r, "announcementId"), "failure", "delete announcement failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "DELETE", "announcement", chi.URLParam(r, "announcementId"), "success")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func MakeAnnouncementsRoutes(sub chi.Router) {
+	sub.Get("/", GetAnnouncements)
+	sub.Post("/{announcementId}/dismiss", DismissAnnouncement)
+	sub.Route("/admin", func(r chi.Router) {
+		r.Use(m.RequireAllowlistedUser("announcement", func() []string {
+			return config.Get().AdminConfig.Allowlist
+		}))
+		r.Get("/", ListAllAnnouncements)
+		r.Post("/", CreateAnnouncement)
+		r.Put("/{announcementId}", UpdateAnnouncement)
+		r.Delete("/{announcementId}", DeleteAnnouncement)
+	})
+}
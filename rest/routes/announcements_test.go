@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// announcementsRequest runs a request against the announcements routes as the given user and organization.
+func announcementsRequest(t *testing.T, user models.UserIdentity, orgID string, method string, path string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Use(validateResponses(t))
+	router.Route(apiBasePath+"/announcements", MakeAnnouncementsRoutes)
+
+	request := httptest.NewRequest(method, apiBasePath+path, strings.NewReader(body))
+	xrhid := &identity.XRHID{
+		Identity: identity.Identity{
+			OrgID: orgID,
+			User:  &identity.User{UserID: user.AccountId},
+		},
+		Entitlements: map[string]identity.ServiceDetails{"insights": {IsEntitled: true}},
+	}
+	ctx := context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid)
+	ctx = context.WithValue(ctx, util.USER_CTX_KEY, user)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request.WithContext(ctx))
+	return recorder
+}
+
+func TestAnnouncements(t *testing.T) {
+	database.Init()
+	admin := models.UserIdentity{AccountId: "announcements-admin"}
+	user := models.UserIdentity{AccountId: "announcements-user"}
+	require.NoError(t, database.DB.Create(&admin).Error)
+	require.NoError(t, database.DB.Create(&user).Error)
+
+	cfg := config.Get()
+	original := cfg.AdminConfig
+	cfg.AdminConfig = config.AdminConfig{Allowlist: []string{admin.AccountId}}
+	t.Cleanup(func() {
+		cfg.AdminConfig = original
+		database.DB.Unscoped().Where("1 = 1").Delete(&models.AnnouncementDismissal{})
+		database.DB.Unscoped().Where("1 = 1").Delete(&models.Announcement{})
+		database.DB.Unscoped().Delete(&admin)
+		database.DB.Unscoped().Delete(&user)
+	})
+
+	listAnnouncements := func(t *testing.T, orgID string, query string) []models.Announcement {
+		recorder := announcementsRequest(t, user, orgID, http.MethodGet, "/announcements/"+query, "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var response util.ListResponse[models.Announcement]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	t.Run("Should reject users missing from the allowlist", func(t *testing.T) {
+		recorder := announcementsRequest(t, user, "1", http.MethodPost, "/announcements/admin/", `{"title": "Maintenance"}`)
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("Should validate announcements", func(t *testing.T) {
+		recorder := announcementsRequest(t, admin, "1", http.MethodPost, "/announcements/admin/", `{"title": "Maintenance", "severity": "critical"}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	var global models.Announcement
+	t.Run("Should create announcements", func(t *testing.T) {
+		recorder := announcementsRequest(t, admin, "1", http.MethodPost, "/announcements/admin/", `{"title": "Maintenance", "severity": "warning"}`)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		var response util.EntityResponse[models.Announcement]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		global = response.Data
+		assert.True(t, global.Dismissible)
+		assert.Equal(t, admin.AccountId, global.CreatedBy)
+
+		for _, body := range []string{
+			`{"title": "Org only", "targeting": {"orgIds": ["2"]}}`,
+			`{"title": "Insights only", "targeting": {"bundles": ["insights"], "entitlements": ["insights"]}}`,
+			`{"title": "Ansible customers", "targeting": {"entitlements": ["ansible"]}}`,
+			`{"title": "Expired", "startTime": "2020-01-01T00:00:00Z", "endTime": "2020-02-01T00:00:00Z"}`,
+		} {
+			recorder := announcementsRequest(t, admin, "1", http.MethodPost, "/announcements/admin/", body)
+			require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("Should notify the targeted organizations", func(t *testing.T) {
+		events := []cloudevents.KafkaEnvelope{}
+		service.SetEventPublisher(func(event cloudevents.KafkaEnvelope) error {
+			events = append(events, event)
+			return nil
+		})
+		t.Cleanup(func() { service.SetEventPublisher(nil) })
+
+		recorder := announcementsRequest(t, admin, "1", http.MethodPost, "/announcements/admin/", `{"title": "Org notified", "targeting": {"orgIds": ["3"]}}`)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		require.Len(t, events, 1)
+		assert.Equal(t, "com.redhat.console.chrome.announcements.updated", events[0].Type)
+		assert.Equal(t, []string{"3"}, events[0].Data.Organizations)
+		assert.False(t, events[0].Data.Broadcast)
+
+		// events are never broadcast, the replicas send events without organizations to every connected organization
+		recorder = announcementsRequest(t, admin, "1", http.MethodPost, "/announcements/admin/", `{"title": "Everyone", "startTime": "2020-01-01T00:00:00Z", "endTime": "2020-02-01T00:00:00Z"}`)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		require.Len(t, events, 2)
+		assert.Empty(t, events[1].Data.Organizations)
+		assert.False(t, events[1].Data.Broadcast)
+	})
+
+	t.Run("Should target announcements", func(t *testing.T) {
+		titles := func(announcements []models.Announcement) []string {
+			result := []string{}
+			for _, announcement := range announcements {
+				result = append(result, announcement.Title)
+			}
+			return result
+		}
+		assert.ElementsMatch(t, []string{"Maintenance", "Insights only"}, titles(listAnnouncements(t, "1", "")))
+		assert.ElementsMatch(t, []string{"Maintenance", "Org only", "Insights only"}, titles(listAnnouncements(t, "2", "")))
+		assert.ElementsMatch(t, []string{"Maintenance"}, titles(listAnnouncements(t, "1", "?bundle=openshift")))
+	})
+
+	t.Run("Should dismiss announcements", func(t *testing.T) {
+		recorder := announcementsRequest(t, user, "1", http.MethodPost, fmt.Sprintf("/announcements/%d/dismiss", global.ID), "")
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+		recorder = announcementsRequest(t, user, "1", http.MethodPost, fmt.Sprintf("/announcements/%d/dismiss", global.ID), "")
+		require.Equal(t, http.StatusNoContent, recorder.Code, "dismissing twice is allowed")
+
+		for _, announcement := range listAnnouncements(t, "1", "") {
+			assert.NotEqual(t, global.ID, announcement.ID)
+		}
+	})
+
+	t.Run("Should delete announcements", func(t *testing.T) {
+		recorder := announcementsRequest(t, admin, "1", http.MethodDelete, fmt.Sprintf("/announcements/admin/%d", global.ID), "")
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+		recorder = announcementsRequest(t, admin, "1", http.MethodDelete, fmt.Sprintf("/announcements/admin/%d", global.ID), "")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
@@ -28,17 +28,17 @@ func GetApiDocs(w http.ResponseWriter, r *http.Request) {
 	bundleFilter := r.URL.Query().Get("bundle")
 
 	if bundleFilter == "" {
-		serveSpecsFile(w)
+		serveSpecsFile(w, r)
 		return
 	}
 
-	serveFilteredSpecs(w, bundleFilter)
+	serveFilteredSpecs(w, r, bundleFilter)
 }
 
-func serveSpecsFile(w http.ResponseWriter) {
+func serveSpecsFile(w http.ResponseWriter, r *http.Request) {
 	f, err := os.Open(specsFilePath)
 	if err != nil {
-		handleFileError(w, err)
+		handleFileError(w, r, err)
 		return
 	}
 	defer f.Close()
@@ -48,21 +48,25 @@ func serveSpecsFile(w http.ResponseWriter) {
 	}
 }
 
-func serveFilteredSpecs(w http.ResponseWriter, bundle string) {
+func serveFilteredSpecs(w http.ResponseWriter, r *http.Request, bundle string) {
 	data, err := os.ReadFile(specsFilePath)
 	if err != nil {
-		handleFileError(w, err)
+		handleFileError(w, r, err)
 		return
 	}
 
 	var allSpecs map[string][]apiDocEntry
 	if err := json.Unmarshal(data, &allSpecs); err != nil {
 		logrus.Errorf("failed to parse specs file %s: %v", specsFilePath, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(util.ErrorResponse{Errors: []string{"failed to parse specs file"}})
+		util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "failed to parse specs file"))
 		return
 	}
 
+	json.NewEncoder(w).Encode(filterApiDocs(allSpecs, bundle))
+}
+
+// filterApiDocs keeps the documents labelled with bundle.
+func filterApiDocs(allSpecs map[string][]apiDocEntry, bundle string) map[string][]apiDocEntry {
 	filtered := make(map[string][]apiDocEntry)
 	for name, entries := range allSpecs {
 		for _, entry := range entries {
@@ -71,11 +75,35 @@ func serveFilteredSpecs(w http.ResponseWriter, bundle string) {
 			}
 		}
 	}
+	return filtered
+}
+
+// GetApiDocsV2 wraps the documents in an entity response, they are decoded instead of streamed from the file.
+func GetApiDocsV2(w http.ResponseWriter, r *http.Request) {
+	allSpecs := map[string][]apiDocEntry{}
+	data, err := os.ReadFile(specsFilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.Errorf("failed to open specs file %s: %v", specsFilePath, err)
+		util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "failed to read specs file"))
+		return
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &allSpecs); err != nil {
+			logrus.Errorf("failed to parse specs file %s: %v", specsFilePath, err)
+			util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "failed to parse specs file"))
+			return
+		}
+	}
+	if bundle := r.URL.Query().Get("bundle"); bundle != "" {
+		allSpecs = filterApiDocs(allSpecs, bundle)
+	}
 
-	json.NewEncoder(w).Encode(filtered)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=3600")
+	json.NewEncoder(w).Encode(util.EntityResponse[map[string][]apiDocEntry]{Data: allSpecs})
 }
 
-func handleFileError(w http.ResponseWriter, err error) {
+func handleFileError(w http.ResponseWriter, r *http.Request, err error) {
 	if errors.Is(err, os.ErrNotExist) {
 		logrus.Warnf("specs file not found at %s, returning empty object", specsFilePath)
 		if _, writeErr := w.Write([]byte("{}")); writeErr != nil {
@@ -84,8 +112,7 @@ func handleFileError(w http.ResponseWriter, err error) {
 		return
 	}
 	logrus.Errorf("failed to open specs file %s: %v", specsFilePath, err)
-	w.WriteHeader(http.StatusInternalServerError)
-	json.NewEncoder(w).Encode(util.ErrorResponse{Errors: []string{"failed to read specs file"}})
+	util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "failed to read specs file"))
 }
 
 func MakeApiDocsRoutes(sub chi.Router) {
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+)
+
+func parseAuditTime(r *http.Request, name string) (time.Time, error) {
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		return time.Time{}, nil
+	}
+	value, err := time.Parse(time.RFC3339, param)
+	if err != nil {
+		return value, fmt.Errorf("%w: invalid %s %q, expected an RFC 3339 time", util.ErrBadRequest, name, param)
+	}
+	return value, nil
+}
+
+func GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	listQuery, err := util.ParseListQuery(r.URL.Query(), service.AuditEventsListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	var timeRange service.AuditEventsRange
+	if timeRange.Since, err = parseAuditTime(r, "since"); err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	if timeRange.Until, err = parseAuditTime(r, "until"); err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	events, total, err := service.ListAuditEvents(listQuery, timeRange)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.NewListResponse(events, total, listQuery, r.URL))
+}
+
+// MakeAuditRoutes registers the audit trail, only the audit admins can read it.
+func MakeAuditRoutes(sub chi.Router) {
+	sub.Use(m.RequireAllowlistedUser("audit_event", func() []string {
+		return config.Get().AdminConfig.Allowlist
+	}))
+	sub.Get("/", GetAuditEvents)
+}
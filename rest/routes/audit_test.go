@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func auditEventsRequest(t *testing.T, userID string, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Use(validateResponses(t))
+	router.Route(apiBasePath+"/audit-events", MakeAuditRoutes)
+
+	request := httptest.NewRequest(http.MethodGet, apiBasePath+"/audit-events/"+query, nil)
+	xrhid := &identity.XRHID{
+		Identity: identity.Identity{
+			OrgID: "audit-routes-org",
+			User:  &identity.User{UserID: userID},
+		},
+	}
+	ctx := context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request.WithContext(ctx))
+	return recorder
+}
+
+func TestAuditEvents(t *testing.T) {
+	database.Init()
+	cfg := config.Get()
+	original := cfg.AdminConfig
+	cfg.AdminConfig = config.AdminConfig{Allowlist: []string{"audit-admin"}}
+	t.Cleanup(func() {
+		cfg.AdminConfig = original
+		database.DB.Where("org_id = ?", "audit-routes-org").Delete(&models.AuditEvent{})
+	})
+
+	now := time.Now().UTC()
+	for _, event := range []securitylog.Event{
+		{Time: now.Add(-time.Hour), Action: "UPDATE", ResourceType: "dashboard_template", ResourceID: "1", Outcome: "success", UserID: "user-1", OrgID: "audit-routes-org",
+			Diff: map[string]securitylog.Change{"default": {Before: false, After: true}}},
+		{Time: now, Action: "DELETE", ResourceType: "favorite_folder", ResourceID: "2", Outcome: "success", UserID: "user-1", OrgID: "audit-routes-org"},
+	} {
+		require.NoError(t, database.AuditSink{}.Write(context.Background(), event))
+	}
+
+	t.Run("Should reject users missing from the allowlist", func(t *testing.T) {
+		recorder := auditEventsRequest(t, "user-1", "")
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("Should list the audit events", func(t *testing.T) {
+		recorder := auditEventsRequest(t, "audit-admin", "?orgId=audit-routes-org&resourceType=dashboard_template&limit=1")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.ListResponse[models.AuditEvent]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Meta.Total)
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "UPDATE", response.Data[0].Action)
+		assert.JSONEq(t, `{"default": {"before": false, "after": true}}`, string(response.Data[0].Diff))
+	})
+
+	t.Run("Should reject invalid time ranges", func(t *testing.T) {
+		recorder := auditEventsRequest(t, "audit-admin", "?since=yesterday")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
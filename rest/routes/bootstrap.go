@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetBootstrap returns every section chrome needs on page load in a single document. Sections that
+// fail to load are reported in the errors map and the request still succeeds.
+func GetBootstrap(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	fields, err := service.ParseBootstrapFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	dashboard := models.AvailableTemplates(r.URL.Query().Get("dashboard"))
+	if err := dashboard.IsValid(); dashboard != "" && err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	response := service.Bootstrap(r.Context(), user, service.BootstrapOptions{
+		Fields:         fields,
+		OrgID:          requestOrgID(r),
+		IdentityHeader: r.Header.Get("x-rh-identity"),
+		Dashboard:      dashboard,
+		IntercomApps:   parseIntercomApps(r.URL.Query().Get("apps")),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func MakeBootstrapRoutes(sub chi.Router) {
+	sub.Get("/", GetBootstrap)
+}
@@ -2,7 +2,7 @@ package routes
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -12,67 +12,21 @@ import (
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/go-chi/chi/v5"
-	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
-func handleDashboardError(err error, w http.ResponseWriter) {
-	logrus.Errorln(err)
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		resp := util.ErrorResponse{
-			Errors: []string{err.Error()},
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(resp)
-		return
-	} else if err != nil && errors.Is(err, util.ErrNotAuthorized) {
-		resp := util.ErrorResponse{
-			Errors: []string{"not authorized"},
-		}
-		w.WriteHeader(http.StatusForbidden)
-		json.NewEncoder(w).Encode(resp)
-
-		return
-	} else if err != nil && errors.Is(err, util.ErrBadRequest) {
-		resp := util.ErrorResponse{
-			Errors: []string{"bad request"},
-		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
-
-		return
-	} else if err != nil {
-		logrus.Errorln(err)
-		resp := util.ErrorResponse{
-			Errors: []string{err.Error()},
-		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	resp := util.ErrorResponse{
-		Errors: []string{"internal server error"},
-	}
-
-	w.WriteHeader(http.StatusInternalServerError)
-	json.NewEncoder(w).Encode(resp)
-}
-
-func handleDashboardResponse[T interface{}, RespType util.ListResponse[T] | util.EntityResponse[T]](rep RespType, err error, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json")
+// handleDashboardResponse writes the response, or the problem of err when the request failed.
+func handleDashboardResponse[T interface{}, RespType util.ListResponse[T] | util.EntityResponse[T]](w http.ResponseWriter, r *http.Request, rep RespType, err error) {
 	if err != nil {
-		handleDashboardError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(rep)
 }
 
 func GetDashboardTemplates(w http.ResponseWriter, r *http.Request) {
-	var userDashboardTemplates []models.DashboardTemplate
 	var err error
 	dashboardParam := r.URL.Query().Get("dashboard")
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
@@ -81,20 +35,23 @@ func GetDashboardTemplates(w http.ResponseWriter, r *http.Request) {
 	dashboard := models.AvailableTemplates(dashboardParam)
 	err = dashboard.IsValid()
 	if dashboard != "" && err != nil {
-		handleDashboardError(err, w)
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
 		return
 
 	}
-	userDashboardTemplates, err = service.GetDashboardTemplate(userID, dashboard)
-
-	response := util.ListResponse[models.DashboardTemplate]{
-		Data: userDashboardTemplates,
-		Meta: util.ListMeta{
-			Count: len(userDashboardTemplates),
-		},
+	listQuery, err := util.ParseListQuery(r.URL.Query(), service.DashboardTemplatesListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
 	}
+	userDashboardTemplates, total, err := service.ListDashboardTemplates(userID, requestOrgID(r), dashboard, listQuery)
+	if err == nil && notModified(w, r, service.DashboardTemplatesETag(userDashboardTemplates, total)) {
+		return
+	}
+
+	response := util.NewListResponse(userDashboardTemplates, total, listQuery, r.URL)
 
-	handleDashboardResponse[models.DashboardTemplate, util.ListResponse[models.DashboardTemplate]](response, err, w)
+	handleDashboardResponse[models.DashboardTemplate, util.ListResponse[models.DashboardTemplate]](w, r, response, err)
 }
 
 func UpdateDashboardTemplate(w http.ResponseWriter, r *http.Request) {
@@ -107,28 +64,31 @@ func UpdateDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 	templateIdUint, err := strconv.ParseUint(templateID, 10, 64)
 
 	if err != nil {
-		handleDashboardError(errors.New("invalid template ID"), w)
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template ID", util.ErrBadRequest))
 		return
 	}
 
 	err = json.NewDecoder(r.Body).Decode(&dashboardTemplate)
 	if err != nil {
-		handleDashboardError(errors.New("unable to parse payload to dashboard template"), w)
+		util.WriteError(w, r, fmt.Errorf("%w: unable to parse payload to dashboard template", util.ErrBadRequest))
 		return
 	}
 
-	updatedTemplate, err := service.UpdateDashboardTemplate(uint(templateIdUint), userID, dashboardTemplate)
+	// a missing template is reported by the update
+	previousTemplate, _ := service.FindUserDashboardTemplate(userID, uint(templateIdUint))
+	updatedTemplate, err := service.UpdateDashboardTemplate(uint(templateIdUint), userID, dashboardTemplate, r.Header.Get("If-Match"))
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "dashboard_template", templateID, "failure", "update failed")
-		handleDashboardError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
-	securitylog.Log(r.Context(), "UPDATE", "dashboard_template", templateID, "success")
+	securitylog.LogChange(r.Context(), "UPDATE", "dashboard_template", templateID, "success", previousTemplate, updatedTemplate)
+	w.Header().Set("ETag", service.DashboardTemplateETag(updatedTemplate))
 	resp := util.EntityResponse[models.DashboardTemplate]{
 		Data: updatedTemplate,
 	}
-	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](resp, nil, w)
+	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](w, r, resp, nil)
 }
 
 func GetBaseDashboardTemplates(w http.ResponseWriter, r *http.Request) {
@@ -139,7 +99,7 @@ func GetBaseDashboardTemplates(w http.ResponseWriter, r *http.Request) {
 		resp := util.ListResponse[models.BaseDashboardTemplate]{
 			Data: templates,
 		}
-		handleDashboardResponse[models.BaseDashboardTemplate, util.ListResponse[models.BaseDashboardTemplate]](resp, nil, w)
+		handleDashboardResponse[models.BaseDashboardTemplate, util.ListResponse[models.BaseDashboardTemplate]](w, r, resp, nil)
 		return
 	}
 
@@ -149,11 +109,11 @@ func GetBaseDashboardTemplates(w http.ResponseWriter, r *http.Request) {
 		Data: template,
 	}
 
-	handleDashboardResponse[models.BaseDashboardTemplate, util.EntityResponse[models.BaseDashboardTemplate]](resp, err, w)
+	handleDashboardResponse[models.BaseDashboardTemplate, util.EntityResponse[models.BaseDashboardTemplate]](w, r, resp, err)
 }
 
-func CopyDashboardTemplate(w http.ResponseWriter, r *http.Request) {
-	var err error
+// copyDashboardTemplate copies the template of the templateId param. Errors are written to w, ok is false then.
+func copyDashboardTemplate(w http.ResponseWriter, r *http.Request) (dashboardTemplate models.DashboardTemplate, ok bool) {
 	templateID := chi.URLParam(r, "templateId")
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	userID := user.ID
@@ -161,22 +121,41 @@ func CopyDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 	templateIdUint, err := strconv.ParseUint(templateID, 10, 64)
 
 	if err != nil {
-		handleDashboardError(errors.New("invalid template ID"), w)
-		return
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template ID", util.ErrBadRequest))
+		return dashboardTemplate, false
 	}
 
-	dashboardTemplate, err := service.CopyDashboardTemplate(userID, uint(templateIdUint))
+	dashboardTemplate, err = service.CopyDashboardTemplate(userID, uint(templateIdUint))
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "CREATE", "dashboard_template", templateID, "failure", "copy failed")
-		handleDashboardError(err, w)
-		return
+		util.WriteError(w, r, err)
+		return dashboardTemplate, false
 	}
 
 	securitylog.Log(r.Context(), "CREATE", "dashboard_template", strconv.FormatUint(uint64(dashboardTemplate.ID), 10), "success")
+	return dashboardTemplate, true
+}
+
+func CopyDashboardTemplate(w http.ResponseWriter, r *http.Request) {
+	dashboardTemplate, ok := copyDashboardTemplate(w, r)
+	if !ok {
+		return
+	}
+
 	response := util.EntityResponse[models.DashboardTemplate]{
 		Data: dashboardTemplate,
 	}
-	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](response, nil, w)
+	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](w, r, response, nil)
+}
+
+// CopyDashboardTemplateV2 answers with 201 and the location of the copy.
+func CopyDashboardTemplateV2(w http.ResponseWriter, r *http.Request) {
+	dashboardTemplate, ok := copyDashboardTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	writeCreated(w, dashboardTemplateLocation(dashboardTemplate), dashboardTemplate)
 }
 
 func DeleteDashboardTemplate(w http.ResponseWriter, r *http.Request) {
@@ -186,14 +165,14 @@ func DeleteDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 
 	templateIdUint, err := strconv.ParseUint(templateID, 10, 64)
 	if err != nil {
-		handleDashboardError(errors.New("invalid template ID"), w)
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template ID", util.ErrBadRequest))
 		return
 	}
 
 	err = service.DeleteTemplate(userID, uint(templateIdUint))
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "DELETE", "dashboard_template", templateID, "failure", "delete failed")
-		handleDashboardError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
@@ -209,50 +188,126 @@ func ChangeDefaultTemplate(w http.ResponseWriter, r *http.Request) {
 	templateIdUint, err := strconv.ParseUint(templateID, 10, 64)
 
 	if err != nil {
-		handleDashboardError(errors.New("invalid template ID"), w)
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template ID", util.ErrBadRequest))
 		return
 	}
 
 	dashboardTemplate, err := service.ChangeDefaultTemplate(userID, uint(templateIdUint))
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "dashboard_template", templateID, "failure", "change default failed")
-		handleDashboardError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
 	securitylog.Log(r.Context(), "UPDATE", "dashboard_template", templateID, "success")
+	w.Header().Set("ETag", service.DashboardTemplateETag(dashboardTemplate))
 	resp := util.EntityResponse[models.DashboardTemplate]{
 		Data: dashboardTemplate,
 	}
-	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](resp, nil, w)
+	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](w, r, resp, nil)
 }
 
-func ForkBaseTemplate(w http.ResponseWriter, r *http.Request) {
-	var err error
-	dashboardParam := r.URL.Query().Get("dashboard")
+// forkBaseTemplate creates a template of the user from the base template of dashboard. Errors are written
+// to w, ok is false then.
+func forkBaseTemplate(w http.ResponseWriter, r *http.Request, dashboard string) (dashboardTemplate models.DashboardTemplate, ok bool) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	userID := user.ID
 
-	if dashboardParam == "" {
-		handleDashboardError(errors.New("invalid base template ID"), w)
-		return
+	if dashboard == "" {
+		util.WriteError(w, r, fmt.Errorf("%w: invalid base template ID", util.ErrBadRequest))
+		return dashboardTemplate, false
 	}
 
-	dashboardTemplate, err := service.ForkBaseTemplate(userID, models.AvailableTemplates(dashboardParam))
+	dashboardTemplate, err := service.ForkBaseTemplate(userID, requestOrgID(r), models.AvailableTemplates(dashboard))
 
 	if err != nil {
-		securitylog.LogWithReason(r.Context(), "CREATE", "dashboard_template", dashboardParam, "failure", "fork failed")
-		handleDashboardError(err, w)
-		return
+		securitylog.LogWithReason(r.Context(), "CREATE", "dashboard_template", dashboard, "failure", "fork failed")
+		util.WriteError(w, r, err)
+		return dashboardTemplate, false
 	}
 
 	securitylog.Log(r.Context(), "CREATE", "dashboard_template", strconv.FormatUint(uint64(dashboardTemplate.ID), 10), "success")
+	return dashboardTemplate, true
+}
+
+func ForkBaseTemplate(w http.ResponseWriter, r *http.Request) {
+	dashboardTemplate, ok := forkBaseTemplate(w, r, r.URL.Query().Get("dashboard"))
+	if !ok {
+		return
+	}
 
 	response := util.EntityResponse[models.DashboardTemplate]{
 		Data: dashboardTemplate,
 	}
 
-	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](response, err, w)
+	handleDashboardResponse[models.DashboardTemplate, util.EntityResponse[models.DashboardTemplate]](w, r, response, nil)
+}
+
+// CreateDashboardTemplatePayload names the base template a new template is forked from.
+type CreateDashboardTemplatePayload struct {
+	Dashboard string `json:"dashboard"`
+}
+
+// CreateDashboardTemplateV2 replaces the base-template/fork route, it answers with 201 and the location of
+// the new template.
+func CreateDashboardTemplateV2(w http.ResponseWriter, r *http.Request) {
+	var payload CreateDashboardTemplatePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	dashboardTemplate, ok := forkBaseTemplate(w, r, payload.Dashboard)
+	if !ok {
+		return
+	}
+
+	writeCreated(w, dashboardTemplateLocation(dashboardTemplate), dashboardTemplate)
+}
+
+func GetDashboardTemplateV2(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	templateIdUint, err := strconv.ParseUint(chi.URLParam(r, "templateId"), 10, 64)
+	if err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template ID", util.ErrBadRequest))
+		return
+	}
+
+	dashboardTemplate, err := service.FindUserDashboardTemplate(user.ID, uint(templateIdUint))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	if notModified(w, r, service.DashboardTemplateETag(dashboardTemplate)) {
+		return
+	}
+
+	resp := util.EntityResponse[models.DashboardTemplate]{
+		Data: dashboardTemplate,
+	}
+	handleDashboardResponse[models.DashboardTemplate](w, r, resp, nil)
+}
+
+func ListBaseDashboardTemplatesV2(w http.ResponseWriter, r *http.Request) {
+	templates := service.GetAllBaseTemplates()
+	resp := util.ListResponse[models.BaseDashboardTemplate]{
+		Data: templates,
+		Meta: util.ListMeta{
+			Count: len(templates),
+			Total: len(templates),
+		},
+	}
+	handleDashboardResponse[models.BaseDashboardTemplate](w, r, resp, nil)
+}
+
+func GetBaseDashboardTemplateV2(w http.ResponseWriter, r *http.Request) {
+	template, err := service.GetDashboardTemplateBase(models.AvailableTemplates(chi.URLParam(r, "dashboard")))
+
+	resp := util.EntityResponse[models.BaseDashboardTemplate]{
+		Data: template,
+	}
+
+	handleDashboardResponse[models.BaseDashboardTemplate](w, r, resp, err)
 }
 
 func EncodeDashboardTemplate(w http.ResponseWriter, r *http.Request) {
@@ -263,7 +318,7 @@ func EncodeDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 	templateIdUint, err := strconv.ParseUint(templateID, 10, 64)
 
 	if err != nil {
-		handleDashboardError(errors.New("invalid template ID"), w)
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template ID", util.ErrBadRequest))
 		return
 	}
 
@@ -273,7 +328,7 @@ func EncodeDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 		Data: encodedTemplate,
 	}
 
-	handleDashboardResponse[string](resp, err, w)
+	handleDashboardResponse[string](w, r, resp, err)
 }
 
 type decodeTemplateRequestBody struct {
@@ -284,7 +339,7 @@ func DecodeDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 	var payload decodeTemplateRequestBody
 	err := json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
-		handleDashboardError(err, w)
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
 		return
 	}
 
@@ -294,7 +349,7 @@ func DecodeDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 		Data: decodedTemplate,
 	}
 
-	handleDashboardResponse[models.DashboardTemplate](resp, err, w)
+	handleDashboardResponse[models.DashboardTemplate](w, r, resp, err)
 }
 
 func deepCopyJSON(metadata models.ModuleFederationMetadata) (models.ModuleFederationMetadata, error) {
@@ -354,7 +409,7 @@ func GetWidgetMappings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	handleDashboardResponse[models.WidgetModuleFederationMapping](resp, err, w)
+	handleDashboardResponse[models.WidgetModuleFederationMapping](w, r, resp, err)
 }
 
 func ResetDashboardTemplate(w http.ResponseWriter, r *http.Request) {
@@ -363,30 +418,42 @@ func ResetDashboardTemplate(w http.ResponseWriter, r *http.Request) {
 
 	dashboardIdQuery := chi.URLParam(r, "templateId")
 	if dashboardIdQuery == "" {
-		handleDashboardError(util.ErrBadRequest, w)
+		util.WriteError(w, r, util.ErrBadRequest)
 		return
 	}
 
 	dashboardId, err := strconv.ParseUint(dashboardIdQuery, 10, 64)
 	if err != nil {
-		handleDashboardError(util.ErrBadRequest, w)
+		util.WriteError(w, r, util.ErrBadRequest)
 		return
 	}
 
-	dashboard, err := service.ResetDashboardTemplate(userID, uint(dashboardId))
+	// a missing template is reported by the reset
+	previousTemplate, _ := service.FindUserDashboardTemplate(userID, uint(dashboardId))
+	var dashboard models.DashboardTemplate
+	switch r.URL.Query().Get("to") {
+	case "", "base":
+		dashboard, err = service.ResetDashboardTemplate(userID, uint(dashboardId), r.Header.Get("If-Match"))
+	case "org-default":
+		dashboard, err = service.ResetDashboardTemplateToOrgDefault(userID, requestOrgID(r), uint(dashboardId), r.Header.Get("If-Match"))
+	default:
+		util.WriteError(w, r, util.ErrBadRequest)
+		return
+	}
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "dashboard_template", dashboardIdQuery, "failure", "reset failed")
-		handleDashboardError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
-	securitylog.Log(r.Context(), "UPDATE", "dashboard_template", dashboardIdQuery, "success")
+	securitylog.LogChange(r.Context(), "UPDATE", "dashboard_template", dashboardIdQuery, "success", previousTemplate, dashboard)
+	w.Header().Set("ETag", service.DashboardTemplateETag(dashboard))
 
 	resp := util.EntityResponse[models.DashboardTemplate]{
 		Data: dashboard,
 	}
 
-	handleDashboardResponse[models.DashboardTemplate](resp, err, w)
+	handleDashboardResponse[models.DashboardTemplate](w, r, resp, err)
 }
 
 func MakeDashboardTemplateRoutes(sub chi.Router) {
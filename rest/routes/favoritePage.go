@@ -2,7 +2,9 @@ package routes
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
@@ -13,74 +15,160 @@ import (
 )
 
 func GetFavoritePage(w http.ResponseWriter, r *http.Request) {
-	var userFavoritePages []models.FavoritePage
-	var err error
 	getAllParam := r.URL.Query().Get(util.GET_ALL_PARAM)
 	getArchivedFavParam := r.URL.Query().Get(util.DEFAULT_PARAM)
+
+	if (getAllParam == "") && (getArchivedFavParam != "true" && getArchivedFavParam != "false") {
+		util.WriteError(w, r, fmt.Errorf("%w: there is a problem in your requests parameters. Please refer to docs", util.ErrBadRequest))
+		return
+	}
+
+	listFavoritePages(w, r, getArchivedFavParam == "false")
+}
+
+// GetFavoritePagesV2 lists every favorite page, the favorite filter replaces the getAll and default
+// params of v1.
+func GetFavoritePagesV2(w http.ResponseWriter, r *http.Request) {
+	listFavoritePages(w, r, false)
+}
+
+func listFavoritePages(w http.ResponseWriter, r *http.Request, archivedOnly bool) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	userID := user.ID
 
-	if getAllParam == "true" {
-		userFavoritePages, err = service.GetAllUserFavoritePages(userID)
+	listQuery, err := util.ParseListQuery(r.URL.Query(), service.FavoritePagesListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
 	}
 
-	if (getAllParam == "") && (getArchivedFavParam != "true" && getArchivedFavParam != "false") {
-		w.Write([]byte("There is a problem in your requests parameters. Please refer to docs."))
+	userFavoritePages, total, version, err := service.ListUserFavoritePages(userID, archivedOnly, listQuery)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	if notModified(w, r, service.FavoritePagesETag(version)) {
 		return
 	}
-	if getArchivedFavParam == "true" {
-		userFavoritePages, err = service.GetAllUserFavoritePages(userID)
-	} else if getArchivedFavParam == "false" {
-		userFavoritePages, err = service.GetUserArchivedFavoritePages(userID)
+
+	userFavoritePages = service.ResolveFavoritePages(userFavoritePages)
+	json.NewEncoder(w).Encode(util.NewListResponse(userFavoritePages, total, listQuery, r.URL))
+}
+
+// saveFavoritePage saves the favorite page of the request and returns the active favorite pages. Errors
+// are written to w, ok is false then.
+func saveFavoritePage(w http.ResponseWriter, r *http.Request) (response util.ListResponse[models.FavoritePage], ok bool) {
+	var currentNewFavoritePage models.FavoritePage
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	userID := user.ID
+
+	err := json.NewDecoder(r.Body).Decode(&currentNewFavoritePage)
+
+	currentNewFavoritePage.UserIdentityID = userID
+
+	if err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: invalid favorite page request, please refer to docs", util.ErrBadRequest))
+		return response, false
 	}
 
-	// Crude error handling for now, could return response instead
+	// Handling functions for updating of the user's favorite pages.
+	pages, version, err := service.SaveUserFavoritePage(userID, user.AccountId, currentNewFavoritePage, r.Header.Get("If-Match"))
+
 	if err != nil {
-		panic(err)
+		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_page", currentNewFavoritePage.Pathname, "failure", "save failed")
+		util.WriteError(w, r, err)
+		return response, false
 	}
+	securitylog.Log(r.Context(), "UPDATE", "favorite_page", currentNewFavoritePage.Pathname, "success")
 
-	response := util.ListResponse[models.FavoritePage]{
-		Data: userFavoritePages,
+	w.Header().Set("ETag", service.FavoritePagesETag(version))
+	pages = service.ResolveFavoritePages(pages)
+
+	response = util.ListResponse[models.FavoritePage]{
+		Data: pages,
 		Meta: util.ListMeta{
-			Count: len(userFavoritePages),
-			Total: len(userFavoritePages),
+			Count: len(pages),
+			Total: len(pages),
 		},
 	}
-	json.NewEncoder(w).Encode(response)
+	return response, true
 }
 
 func SetFavoritePage(w http.ResponseWriter, r *http.Request) {
-	var currentNewFavoritePage models.FavoritePage
-	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
-	userID := user.ID
+	response, ok := saveFavoritePage(w, r)
+	if !ok {
+		return
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&currentNewFavoritePage)
+	json.NewEncoder(w).Encode(response)
+}
 
-	currentNewFavoritePage.UserIdentityID = userID
+// SetFavoritePageV2 answers with 201 and the active favorite pages.
+func SetFavoritePageV2(w http.ResponseWriter, r *http.Request) {
+	response, ok := saveFavoritePage(w, r)
+	if !ok {
+		return
+	}
+
+	sendJSONResponse(w, http.StatusCreated, response)
+}
 
+func parseFavoritesID(r *http.Request, param string) (uint, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s", util.ErrBadRequest, param)
+	}
+	return uint(id), nil
+}
+
+func UpdateFavoritePage(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	favoriteID, err := parseFavoritesID(r, "favoriteId")
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Invalid favorite page request, please refer to docs. "))
+		util.WriteError(w, r, err)
 		return
 	}
 
-	// Handling functions for updating of the user's favorite pages.
-	err = service.SaveUserFavoritePage(userID, user.AccountId, currentNewFavoritePage)
+	var request models.FavoritePageUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
 
+	// the If-Match header and the entity tag describe the favorite page, not the list of favorites
+	favoritePage, err := service.UpdateFavoritePage(user.ID, favoriteID, request, r.Header.Get("If-Match"))
 	if err != nil {
-		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_page", currentNewFavoritePage.Pathname, "failure", "save failed")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Unable to save favorite page."))
+		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_page", chi.URLParam(r, "favoriteId"), "failure", "update failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "favorite_page", chi.URLParam(r, "favoriteId"), "success")
+
+	w.Header().Set("ETag", service.FavoritePageETag(favoritePage))
+
+	response := util.EntityResponse[models.FavoritePage]{
+		Data: favoritePage,
+	}
+	handleDashboardResponse[models.FavoritePage, util.EntityResponse[models.FavoritePage]](w, r, response, nil)
+}
+
+func ReorderFavoritePages(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request models.FavoritesReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
 		return
 	}
-	securitylog.Log(r.Context(), "UPDATE", "favorite_page", currentNewFavoritePage.Pathname, "success")
 
-	pages, err := service.GetUserActiveFavoritePages(userID)
+	pages, version, err := service.ReorderFavoritePages(user.ID, request, r.Header.Get("If-Match"))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Unable to retrieve favorite pages."))
+		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_page", user.AccountId, "failure", "reorder failed")
+		util.WriteError(w, r, err)
 		return
 	}
+	securitylog.Log(r.Context(), "UPDATE", "favorite_page", user.AccountId, "success")
+
+	w.Header().Set("ETag", service.FavoritePagesETag(version))
 
 	response := util.ListResponse[models.FavoritePage]{
 		Data: pages,
@@ -89,11 +177,226 @@ func SetFavoritePage(w http.ResponseWriter, r *http.Request) {
 			Total: len(pages),
 		},
 	}
+	handleDashboardResponse[models.FavoritePage, util.ListResponse[models.FavoritePage]](w, r, response, nil)
+}
 
-	json.NewEncoder(w).Encode(response)
+func MoveFavoritePages(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request models.FavoritesMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	pages, version, err := service.MoveFavoritePages(user.ID, request, r.Header.Get("If-Match"))
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_page", user.AccountId, "failure", "move failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "favorite_page", user.AccountId, "success")
+
+	w.Header().Set("ETag", service.FavoritePagesETag(version))
+
+	response := util.ListResponse[models.FavoritePage]{
+		Data: pages,
+		Meta: util.ListMeta{
+			Count: len(pages),
+			Total: len(pages),
+		},
+	}
+	handleDashboardResponse[models.FavoritePage, util.ListResponse[models.FavoritePage]](w, r, response, nil)
+}
+
+func GetFavoriteFolders(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	folders, err := service.GetUserFavoriteFolders(user.ID)
+	response := util.ListResponse[models.FavoriteFolder]{
+		Data: folders,
+		Meta: util.ListMeta{
+			Count: len(folders),
+			Total: len(folders),
+		},
+	}
+	handleDashboardResponse[models.FavoriteFolder, util.ListResponse[models.FavoriteFolder]](w, r, response, err)
+}
+
+// createFavoriteFolder creates the folder of the request payload. Errors are written to w, ok is false then.
+func createFavoriteFolder(w http.ResponseWriter, r *http.Request) (folder models.FavoriteFolder, ok bool) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request models.FavoriteFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return folder, false
+	}
+
+	folder, err := service.CreateFavoriteFolder(user.ID, request)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "CREATE", "favorite_folder", user.AccountId, "failure", "create failed")
+		util.WriteError(w, r, err)
+		return folder, false
+	}
+	securitylog.Log(r.Context(), "CREATE", "favorite_folder", strconv.FormatUint(uint64(folder.ID), 10), "success")
+	return folder, true
+}
+
+func CreateFavoriteFolder(w http.ResponseWriter, r *http.Request) {
+	folder, ok := createFavoriteFolder(w, r)
+	if !ok {
+		return
+	}
+
+	response := util.EntityResponse[models.FavoriteFolder]{
+		Data: folder,
+	}
+	handleDashboardResponse[models.FavoriteFolder, util.EntityResponse[models.FavoriteFolder]](w, r, response, nil)
+}
+
+// CreateFavoriteFolderV2 answers with 201 and the location of the folder.
+func CreateFavoriteFolderV2(w http.ResponseWriter, r *http.Request) {
+	folder, ok := createFavoriteFolder(w, r)
+	if !ok {
+		return
+	}
+
+	writeCreated(w, fmt.Sprintf("%s/favorite-pages/folders/%d", V2BasePath, folder.ID), folder)
+}
+
+func RenameFavoriteFolder(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	folderID, err := parseFavoritesID(r, "folderId")
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	var request models.FavoriteFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	folder, err := service.RenameFavoriteFolder(user.ID, folderID, request)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_folder", chi.URLParam(r, "folderId"), "failure", "rename failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "favorite_folder", chi.URLParam(r, "folderId"), "success")
+
+	response := util.EntityResponse[models.FavoriteFolder]{
+		Data: folder,
+	}
+	handleDashboardResponse[models.FavoriteFolder, util.EntityResponse[models.FavoriteFolder]](w, r, response, nil)
+}
+
+func DeleteFavoriteFolder(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	folderID, err := parseFavoritesID(r, "folderId")
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	err = service.DeleteFavoriteFolder(user.ID, folderID)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "DELETE", "favorite_folder", chi.URLParam(r, "folderId"), "failure", "delete failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "DELETE", "favorite_folder", chi.URLParam(r, "folderId"), "success")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func ReorderFavoriteFolders(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request models.FavoritesReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	folders, err := service.ReorderFavoriteFolders(user.ID, request)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "favorite_folder", user.AccountId, "failure", "reorder failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "favorite_folder", user.AccountId, "success")
+
+	response := util.ListResponse[models.FavoriteFolder]{
+		Data: folders,
+		Meta: util.ListMeta{
+			Count: len(folders),
+			Total: len(folders),
+		},
+	}
+	handleDashboardResponse[models.FavoriteFolder, util.ListResponse[models.FavoriteFolder]](w, r, response, nil)
+}
+
+func ExportFavoritePages(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	encodedFavorites, err := service.ExportFavorites(user.ID)
+
+	resp := util.EntityResponse[string]{
+		Data: encodedFavorites,
+	}
+
+	handleDashboardResponse[string](w, r, resp, err)
+}
+
+type importFavoritesRequestBody struct {
+	EncodedFavorites string                      `json:"encodedFavorites"`
+	Mode             service.FavoritesImportMode `json:"mode"`
+}
+
+func ImportFavoritePages(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var payload importFavoritesRequestBody
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+	if payload.Mode == "" {
+		payload.Mode = service.FavoritesImportMerge
+	}
+
+	document, err := service.DecodeFavorites(payload.EncodedFavorites)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "CREATE", "favorite_page", user.AccountId, "failure", "invalid import document")
+		util.WriteError(w, r, err)
+		return
+	}
+
+	result, err := service.ImportFavorites(user.ID, document, payload.Mode)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "CREATE", "favorite_page", user.AccountId, "failure", "import failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "CREATE", "favorite_page", user.AccountId, "success")
+
+	resp := util.EntityResponse[service.FavoritesImportResult]{
+		Data: result,
+	}
+	handleDashboardResponse[service.FavoritesImportResult](w, r, resp, nil)
 }
 
 func MakeFavoritePagesRoutes(sub chi.Router) {
 	sub.Post("/", SetFavoritePage)
 	sub.Get("/", GetFavoritePage)
+	sub.Post("/reorder", ReorderFavoritePages)
+	sub.Post("/move", MoveFavoritePages)
+	sub.Get("/export", ExportFavoritePages)
+	sub.Post("/import", ImportFavoritePages)
+	sub.Patch("/{favoriteId}", UpdateFavoritePage)
+	sub.Route("/folders", func(r chi.Router) {
+		r.Get("/", GetFavoriteFolders)
+		r.Post("/", CreateFavoriteFolder)
+		r.Post("/reorder", ReorderFavoriteFolders)
+		r.Patch("/{folderId}", RenameFavoriteFolder)
+		r.Delete("/{folderId}", DeleteFavoriteFolder)
+	})
 }
@@ -1,35 +1,22 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
 	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
-func handleIdentityError(err error, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		resp := util.ErrorResponse{
-			Errors: []string{err.Error()},
-		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	resp := util.ErrorResponse{
-		Errors: []string{"internal server error"},
-	}
-
-	w.WriteHeader(http.StatusInternalServerError)
-	json.NewEncoder(w).Encode(resp)
-}
-
 type AddVisitedBundlePayload struct {
 	Bundle string `json:"bundle"`
 }
@@ -37,23 +24,10 @@ type AddVisitedBundlePayload struct {
 // Use the user obj in context to pull full data row from DB
 func GetUserIdentity(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
-	updatedUser, err := service.GetUserIdentityData(user)
+	response, err := service.GetUserIdentityResponse(user, time.Now())
 	if err != nil {
-		panic(err)
-	}
-
-	response := models.UserIdentityResponse{
-		AccountId:        updatedUser.AccountId,
-		FirstLogin:       updatedUser.FirstLogin,
-		DayOne:           updatedUser.DayOne,
-		LastLogin:        updatedUser.LastLogin,
-		LastVisitedPages: updatedUser.LastVisitedPages.Data(),
-		FavoritePages:    updatedUser.FavoritePages,
-		SelfReport:       updatedUser.SelfReport,
-		VisitedBundles:   updatedUser.VisitedBundles,
-		UIPreview:        updatedUser.UIPreview,
-		UIPreviewSeen:    updatedUser.UIPreviewSeen,
-		ActiveWorkspace:  updatedUser.ActiveWorkspace,
+		util.WriteError(w, r, err)
+		return
 	}
 
 	resp := util.EntityResponse[models.UserIdentityResponse]{
@@ -63,21 +37,30 @@ func GetUserIdentity(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-func AddVisitedBundle(w http.ResponseWriter, r *http.Request) {
+// addVisitedBundle records the bundle of the request payload. Errors are written to w, ok is false then.
+func addVisitedBundle(w http.ResponseWriter, r *http.Request) (updatedUser models.UserIdentity, ok bool) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	var request AddVisitedBundlePayload
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		handleIdentityError(err, w)
-		return
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return updatedUser, false
 	}
-	updatedUser, err := service.AddVisitedBundle(user, request.Bundle)
+	updatedUser, err = service.AddVisitedBundle(user, request.Bundle)
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "visited_bundles", user.AccountId, "failure", "add bundle failed")
-		handleIdentityError(err, w)
-		return
+		util.WriteError(w, r, err)
+		return updatedUser, false
 	}
 	securitylog.Log(r.Context(), "UPDATE", "visited_bundles", user.AccountId, "success")
+	return updatedUser, true
+}
+
+func AddVisitedBundle(w http.ResponseWriter, r *http.Request) {
+	updatedUser, ok := addVisitedBundle(w, r)
+	if !ok {
+		return
+	}
 
 	resp := util.EntityResponse[models.UserIdentity]{
 		Data: updatedUser,
@@ -86,11 +69,35 @@ func AddVisitedBundle(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// AddVisitedBundleV2 answers with the visited bundles instead of the whole user.
+func AddVisitedBundleV2(w http.ResponseWriter, r *http.Request) {
+	updatedUser, ok := addVisitedBundle(w, r)
+	if !ok {
+		return
+	}
+
+	GetVisitedBundlesV2(w, r.WithContext(context.WithValue(r.Context(), util.USER_CTX_KEY, updatedUser)))
+}
+
+// GetVisitedBundles returns the legacy map of visited bundles. When the "sort" query param is
+// set to "recent" or "frequent", the visits history is returned instead, optionally trimmed by "limit".
 func GetVisitedBundles(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	if sortParam := r.URL.Query().Get("sort"); sortParam != "" {
+		getBundleVisits(w, r, user, service.BundleVisitsSort(sortParam))
+		return
+	}
+
+	GetVisitedBundlesV2(w, r)
+}
+
+// GetVisitedBundlesV2 only returns the map of visited bundles, the visits history has its own route.
+func GetVisitedBundlesV2(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	bundle, err := service.GetVisitedBundles(user)
 	if err != nil {
-		panic(err)
+		util.WriteError(w, r, err)
+		return
 	}
 	resp := util.EntityResponse[map[string]bool]{
 		Data: bundle,
@@ -99,8 +106,58 @@ func GetVisitedBundles(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+func getBundleVisits(w http.ResponseWriter, r *http.Request, user models.UserIdentity, order service.BundleVisitsSort) {
+	limit := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 0 {
+			util.WriteError(w, r, fmt.Errorf("%w: invalid limit %s, expected a positive number", util.ErrBadRequest, limitParam))
+			return
+		}
+	}
+
+	visits, err := service.GetBundleVisits(user, order, limit)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	resp := util.ListResponse[models.BundleVisit]{
+		Data: visits,
+		Meta: util.ListMeta{
+			Count: len(visits),
+			Limit: limit,
+		},
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func GetVisitedBundlesSummary(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	summary, err := service.GetBundleVisitsSummary(user)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	resp := util.EntityResponse[models.BundleVisitsSummary]{
+		Data: summary,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetIntercomHash returns the hash for a single "app" or, in batch mode, a map of hashes
+// for a comma separated list of "apps".
 func GetIntercomHash(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	if appsParam := r.URL.Query().Get("apps"); appsParam != "" {
+		getIntercomHashes(w, r, user, appsParam)
+		return
+	}
+
 	appParam := r.URL.Query()["app"]
 	app := ""
 
@@ -110,8 +167,7 @@ func GetIntercomHash(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := service.GetUserIntercomHash(user.AccountId, service.IntercomApp(app))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Internal server error."))
+		util.WriteError(w, r, err)
 		return
 	}
 
@@ -122,22 +178,52 @@ func GetIntercomHash(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// parseIntercomApps reads the comma separated list of intercom apps, skipping empty entries
+func parseIntercomApps(appsParam string) []service.IntercomApp {
+	apps := []service.IntercomApp{}
+	for _, app := range strings.Split(appsParam, ",") {
+		app = strings.TrimSpace(app)
+		if app != "" {
+			apps = append(apps, service.IntercomApp(app))
+		}
+	}
+	return apps
+}
+
+func getIntercomHashes(w http.ResponseWriter, r *http.Request, user models.UserIdentity, appsParam string) {
+	payload, err := service.GetUserIntercomHashes(user.AccountId, parseIntercomApps(appsParam))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	resp := util.EntityResponse[map[string]service.IntercomPayload]{
+		Data: payload,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
 type UpdateUserPreviewPayload struct {
 	UiPreview bool `json:"uiPreview"`
 }
 
+// UpdateUserPreview toggles the global UI preview flag.
+//
+// Deprecated: previews are enrolled into one by one with UpdatePreviewFeatureEnrollment, the global flag
+// does not change the enrollments.
 func UpdateUserPreview(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	var request UpdateUserPreviewPayload
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		handleIdentityError(err, w)
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
 		return
 	}
 	err = service.UpdateUserPreview(&user, request.UiPreview)
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "user_identity", user.AccountId, "failure", "update preview failed")
-		handleIdentityError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
@@ -150,12 +236,15 @@ func UpdateUserPreview(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// MarkPreviewSeen records that the user was shown the global UI preview.
+//
+// Deprecated: use MarkPreviewFeatureSeen to record it per preview feature.
 func MarkPreviewSeen(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	err := service.MarkPreviewSeen(&user)
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "user_identity", user.AccountId, "failure", "mark preview seen failed")
-		handleIdentityError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
@@ -167,6 +256,61 @@ func MarkPreviewSeen(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+func GetPreviewFeatures(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	features := service.GetPreviewFeatures(user)
+
+	resp := util.ListResponse[service.PreviewFeatureStatus]{
+		Data: features,
+		Meta: util.ListMeta{
+			Count: len(features),
+			Total: len(features),
+		},
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+type UpdatePreviewFeatureEnrollmentPayload struct {
+	Enrolled bool `json:"enrolled"`
+}
+
+func UpdatePreviewFeatureEnrollment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	feature := chi.URLParam(r, "feature")
+	var request UpdatePreviewFeatureEnrollmentPayload
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+	err = service.UpdatePreviewFeatureEnrollment(&user, feature, request.Enrolled)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "preview_features", user.AccountId, "failure", fmt.Sprintf("update %s enrollment failed", feature))
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.Log(r.Context(), "UPDATE", "preview_features", user.AccountId, "success")
+
+	GetPreviewFeatures(w, r.WithContext(context.WithValue(r.Context(), util.USER_CTX_KEY, user)))
+}
+
+func MarkPreviewFeatureSeen(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	feature := chi.URLParam(r, "feature")
+	err := service.MarkPreviewFeatureSeen(&user, feature)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "preview_features", user.AccountId, "failure", fmt.Sprintf("mark %s seen failed", feature))
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.Log(r.Context(), "UPDATE", "preview_features", user.AccountId, "success")
+
+	GetPreviewFeatures(w, r.WithContext(context.WithValue(r.Context(), util.USER_CTX_KEY, user)))
+}
+
 type UpdateActiveWorkspacePayload struct {
 	ActiveWorkspace string `json:"activeWorkspace"`
 }
@@ -176,13 +320,18 @@ func UpdateActiveWorkspace(w http.ResponseWriter, r *http.Request) {
 	var request UpdateActiveWorkspacePayload
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		handleIdentityError(err, w)
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+	if err := service.ValidateActiveWorkspace(r.Context(), r.Header.Get("x-rh-identity"), request.ActiveWorkspace); err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "user_identity", user.AccountId, "failure", "active workspace validation failed")
+		util.WriteError(w, r, err)
 		return
 	}
 	err = service.UpdateActiveWorkspace(&user, request.ActiveWorkspace)
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "user_identity", user.AccountId, "failure", "update active workspace failed")
-		handleIdentityError(err, w)
+		util.WriteError(w, r, err)
 		return
 	}
 
@@ -195,6 +344,110 @@ func UpdateActiveWorkspace(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// UpdateUserIdentityPayload lists the user fields changed by PATCH /user, fields that are not set keep their value.
+type UpdateUserIdentityPayload struct {
+	UiPreview       *bool   `json:"uiPreview"`
+	UiPreviewSeen   *bool   `json:"uiPreviewSeen"`
+	ActiveWorkspace *string `json:"activeWorkspace"`
+}
+
+// UpdateUserIdentityV2 replaces the update-ui-preview, mark-preview-seen and update-active-workspace routes
+// and answers with the updated user.
+func UpdateUserIdentityV2(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request UpdateUserIdentityPayload
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+	if request.UiPreviewSeen != nil && !*request.UiPreviewSeen {
+		util.WriteError(w, r, fmt.Errorf("%w: uiPreviewSeen can not be reset", util.ErrBadRequest))
+		return
+	}
+	if request.ActiveWorkspace != nil {
+		if err := service.ValidateActiveWorkspace(r.Context(), r.Header.Get("x-rh-identity"), *request.ActiveWorkspace); err != nil {
+			securitylog.LogWithReason(r.Context(), "UPDATE", "user_identity", user.AccountId, "failure", "active workspace validation failed")
+			util.WriteError(w, r, err)
+			return
+		}
+	}
+
+	var err error
+	if request.UiPreview != nil {
+		err = service.UpdateUserPreview(&user, *request.UiPreview)
+	}
+	if err == nil && request.UiPreviewSeen != nil {
+		err = service.MarkPreviewSeen(&user)
+	}
+	if err == nil && request.ActiveWorkspace != nil {
+		err = service.UpdateActiveWorkspace(&user, *request.ActiveWorkspace)
+	}
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "user_identity", user.AccountId, "failure", "update failed")
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.Log(r.Context(), "UPDATE", "user_identity", user.AccountId, "success")
+
+	GetUserIdentity(w, r.WithContext(context.WithValue(r.Context(), util.USER_CTX_KEY, user)))
+}
+
+// GetBundleVisitsV2 lists the visits history, the v1 route returns it from the visited bundles with a sort param.
+func GetBundleVisitsV2(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	order := service.BundleVisitsSort(r.URL.Query().Get("sort"))
+	if order == "" {
+		order = service.BundleVisitsRecent
+	}
+	getBundleVisits(w, r, user, order)
+}
+
+// UpdatePreviewFeaturePayload lists the preview feature fields changed by PATCH, fields that are not set keep their value.
+type UpdatePreviewFeaturePayload struct {
+	Enrolled *bool `json:"enrolled"`
+	Seen     *bool `json:"seen"`
+}
+
+// UpdatePreviewFeatureV2 replaces the enrollment and mark-seen routes and answers with the updated feature.
+func UpdatePreviewFeatureV2(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	feature := chi.URLParam(r, "feature")
+	var request UpdatePreviewFeaturePayload
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+	if request.Seen != nil && !*request.Seen {
+		util.WriteError(w, r, fmt.Errorf("%w: seen can not be reset", util.ErrBadRequest))
+		return
+	}
+
+	var err error
+	if request.Enrolled != nil {
+		err = service.UpdatePreviewFeatureEnrollment(&user, feature, *request.Enrolled)
+	}
+	if err == nil && request.Seen != nil {
+		err = service.MarkPreviewFeatureSeen(&user, feature)
+	}
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "preview_features", user.AccountId, "failure", fmt.Sprintf("update %s failed", feature))
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.Log(r.Context(), "UPDATE", "preview_features", user.AccountId, "success")
+
+	for _, status := range service.GetPreviewFeatures(user) {
+		if status.Name == feature {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(util.EntityResponse[service.PreviewFeatureStatus]{Data: status})
+			return
+		}
+	}
+	util.WriteError(w, r, fmt.Errorf("preview feature %s: %w", feature, gorm.ErrRecordNotFound))
+}
+
 func MakeUserIdentityRoutes(sub chi.Router) {
 	sub.Get("/", GetUserIdentity)
 	sub.Get("/intercom", GetIntercomHash)
@@ -204,5 +457,12 @@ func MakeUserIdentityRoutes(sub chi.Router) {
 	sub.Route("/visited-bundles", func(r chi.Router) {
 		r.Post("/", AddVisitedBundle)
 		r.Get("/", GetVisitedBundles)
+		r.Get("/summary", GetVisitedBundlesSummary)
+	})
+	sub.Route("/preferences", MakeUserPreferencesRoutes)
+	sub.Route("/preview-features", func(r chi.Router) {
+		r.Get("/", GetPreviewFeatures)
+		r.Post("/{feature}/enrollment", UpdatePreviewFeatureEnrollment)
+		r.Post("/{feature}/mark-seen", MarkPreviewFeatureSeen)
 	})
 }
@@ -2,7 +2,10 @@ package routes
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/logger"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
@@ -19,23 +22,26 @@ func StoreLastVisitedPages(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&recentPages)
 
 	if err != nil {
-		errString := "Invalid last visited pages request payload."
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(errString))
 		logger.LogFor(r.Context()).Errorf("unable to request body for last visited pages, %s", err.Error())
+		util.WriteError(w, r, fmt.Errorf("%w: invalid last visited pages request payload", util.ErrBadRequest))
 		return
 	}
 
-	err = service.HandlePostLastVisitedPages(recentPages.Pages, &user)
+	pages, err := service.ValidateLastVisitedRequest(recentPages)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	err = service.HandlePostLastVisitedPages(pages, &user)
 	if err != nil {
 		securitylog.LogWithReason(r.Context(), "UPDATE", "last_visited_pages", user.AccountId, "failure", "store failed")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Unable to store last visited pages."))
+		util.WriteError(w, r, err)
 		return
 	}
 	securitylog.Log(r.Context(), "UPDATE", "last_visited_pages", user.AccountId, "success")
 
-	pages := user.LastVisitedPages.Data()
+	pages = user.LastVisitedPages.Data()
 	resp := util.ListResponse[models.VisitedPage]{
 		Data: pages,
 		Meta: util.ListMeta{
@@ -46,19 +52,48 @@ func StoreLastVisitedPages(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// lastVisitedListOptions are the pagination and sort params of the last visited pages. The pages are stored
+// most recent first, which is the order used without a sort param.
+var lastVisitedListOptions = util.ListQueryOptions{
+	MaxLimit:   100,
+	SortFields: map[string]string{"timestamp": "timestamp", "title": "title", "pathname": "pathname"},
+}
+
+var lastVisitedCompare = map[string]func(a models.VisitedPage, b models.VisitedPage) int{
+	"timestamp": func(a models.VisitedPage, b models.VisitedPage) int {
+		switch {
+		case a.Timestamp == nil && b.Timestamp == nil:
+			return 0
+		case a.Timestamp == nil:
+			return -1
+		case b.Timestamp == nil:
+			return 1
+		}
+		return a.Timestamp.Compare(*b.Timestamp)
+	},
+	"title": func(a models.VisitedPage, b models.VisitedPage) int {
+		return strings.Compare(a.Title, b.Title)
+	},
+	"pathname": func(a models.VisitedPage, b models.VisitedPage) int {
+		return strings.Compare(a.Pathname, b.Pathname)
+	},
+}
+
+// GetLastVisitedPages returns the last visited pages, filtered by the optional "bundle" query param.
 func GetLastVisitedPages(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 
-	pages := user.LastVisitedPages.Data()
-
-	resp := util.ListResponse[models.VisitedPage]{
-		Data: pages,
-		Meta: util.ListMeta{
-			Count: len(pages),
-			Total: len(pages),
-		},
+	listQuery, err := util.ParseListQuery(r.URL.Query(), lastVisitedListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
 	}
-	json.NewEncoder(w).Encode(resp)
+
+	// sort a copy, the stored pages are shared with the identity cache
+	pages := slices.Clone(service.GetLastVisitedPages(user, r.URL.Query().Get("bundle")))
+	util.SortSlice(pages, listQuery, lastVisitedCompare)
+
+	json.NewEncoder(w).Encode(util.NewListResponse(util.PageSlice(pages, listQuery), len(pages), listQuery, r.URL))
 }
 
 func MakeLastVisitedRoutes(sub chi.Router) {
@@ -4,14 +4,26 @@ import (
 	"fmt"
 	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/openapi"
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"log"
+	"net/http"
 	"os"
 	"testing"
 	"time"
 )
 
+// apiBasePath is the server URL of the OpenAPI document, routes mounted under it have their responses validated.
+const apiBasePath = "/api/chrome-service/v1"
+
+var openApiSpec *openapi.Spec
+
+// openApiV2Spec is the generated document of the routes mounted under V2BasePath.
+var openApiV2Spec *openapi.Spec
+
 func TestMain(t *testing.M) {
 	cfg := config.Get()
 	cfg.Test = true
@@ -21,9 +33,20 @@ func TestMain(t *testing.M) {
 	dbName := fmt.Sprintf("%d-services.db", now)
 	config.Get().DbName = dbName
 	service.LoadBaseLayout()
+	util.InitUserIdentitiesCache(util.IdentityCacheOptions{})
+
+	var err error
+	openApiSpec, err = openapi.Load("../../spec/openapi.yaml")
+	if err != nil {
+		panic(err)
+	}
+	openApiV2Spec, err = openapi.Load("../../spec/openapi-v2.yaml")
+	if err != nil {
+		panic(err)
+	}
 
 	database.Init()
-	err := database.DB.AutoMigrate(&models.DashboardTemplate{}, &models.UserIdentity{})
+	err = database.DB.AutoMigrate(&models.DashboardTemplate{}, &models.UserIdentity{})
 	if err != nil {
 		panic(err)
 	}
@@ -38,3 +61,17 @@ func TestMain(t *testing.M) {
 
 	os.Exit(exitCode)
 }
+
+// validateResponses fails the test when a response does not match the OpenAPI document.
+func validateResponses(t *testing.T) func(http.Handler) http.Handler {
+	return m.ValidateResponse(openApiSpec, func(r *http.Request, err error) {
+		t.Errorf("%s %s does not match the OpenAPI document: %v", r.Method, r.URL.Path, err)
+	})
+}
+
+// validateV2Responses fails the test when a response does not match the v2 OpenAPI document.
+func validateV2Responses(t *testing.T) func(http.Handler) http.Handler {
+	return m.ValidateResponse(openApiV2Spec, func(r *http.Request, err error) {
+		t.Errorf("%s %s does not match the v2 OpenAPI document: %v", r.Method, r.URL.Path, err)
+	})
+}
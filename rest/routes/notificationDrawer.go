@@ -0,0 +1,146 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+)
+
+const (
+	defaultDrawerLimit = 20
+	maxDrawerLimit     = 100
+)
+
+func drawerAudience(r *http.Request) service.DrawerAudience {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	audience := service.DrawerAudience{UserID: user.ID, AccountID: user.AccountId}
+	if id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil {
+		audience.OrgID = id.Identity.OrgID
+		if id.Identity.User != nil {
+			audience.Username = id.Identity.User.Username
+		}
+	}
+	return audience
+}
+
+// drawerListOptions only paginates, the drawer items are always listed newest first.
+var drawerListOptions = util.ListQueryOptions{
+	DefaultLimit: defaultDrawerLimit,
+	MaxLimit:     maxDrawerLimit,
+}
+
+func GetDrawerItems(w http.ResponseWriter, r *http.Request) {
+	listQuery, err := util.ParseListQuery(r.URL.Query(), drawerListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	filter := service.DrawerFilter(r.URL.Query().Get("filter"))
+	items, total, err := service.ListDrawerItems(drawerAudience(r), filter, listQuery.Limit, listQuery.Offset)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.NewListResponse(items, int(total), listQuery, r.URL))
+}
+
+func updateDrawerItems(w http.ResponseWriter, r *http.Request, action string, update func(service.DrawerAudience, models.DrawerStateRequest) ([]uint, error)) {
+	audience := drawerAudience(r)
+	var request models.DrawerStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	ids, err := update(audience, request)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "notifications_drawer", audience.AccountID, "failure", action+" failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "notifications_drawer", audience.AccountID, "success")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[[]uint]{Data: ids})
+}
+
+func MarkDrawerItemsRead(w http.ResponseWriter, r *http.Request) {
+	updateDrawerItems(w, r, "mark read", func(audience service.DrawerAudience, request models.DrawerStateRequest) ([]uint, error) {
+		return service.MarkDrawerItemsRead(audience, request, true)
+	})
+}
+
+func MarkDrawerItemsUnread(w http.ResponseWriter, r *http.Request) {
+	updateDrawerItems(w, r, "mark unread", func(audience service.DrawerAudience, request models.DrawerStateRequest) ([]uint, error) {
+		return service.MarkDrawerItemsRead(audience, request, false)
+	})
+}
+
+func DismissDrawerItems(w http.ResponseWriter, r *http.Request) {
+	updateDrawerItems(w, r, "dismiss", service.DismissDrawerItems)
+}
+
+// UpdateDrawerItemsPayload selects drawer items like DrawerStateRequest and sets their state, states that are
+// not set keep their value.
+type UpdateDrawerItemsPayload struct {
+	models.DrawerStateRequest
+	Read      *bool `json:"read"`
+	Dismissed *bool `json:"dismissed"`
+}
+
+// UpdateDrawerItemsV2 replaces the mark-read, mark-unread and dismiss routes and answers with the IDs of the
+// updated items.
+func UpdateDrawerItemsV2(w http.ResponseWriter, r *http.Request) {
+	audience := drawerAudience(r)
+	var request UpdateDrawerItemsPayload
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+	if request.Read == nil && request.Dismissed == nil {
+		util.WriteError(w, r, fmt.Errorf("%w: read or dismissed has to be set", util.ErrBadRequest))
+		return
+	}
+	if request.Dismissed != nil && !*request.Dismissed {
+		util.WriteError(w, r, fmt.Errorf("%w: dismissed items can not be restored", util.ErrBadRequest))
+		return
+	}
+
+	var ids []uint
+	var err error
+	if request.Read != nil {
+		ids, err = service.MarkDrawerItemsRead(audience, request.DrawerStateRequest, *request.Read)
+	}
+	if err == nil && request.Dismissed != nil {
+		ids, err = service.DismissDrawerItems(audience, request.DrawerStateRequest)
+	}
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "notifications_drawer", audience.AccountID, "failure", "update failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "notifications_drawer", audience.AccountID, "success")
+	if ids == nil {
+		ids = []uint{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[[]uint]{Data: ids})
+}
+
+func MakeNotificationDrawerRoutes(sub chi.Router) {
+	sub.Get("/", GetDrawerItems)
+	sub.Post("/mark-read", MarkDrawerItemsRead)
+	sub.Post("/mark-unread", MarkDrawerItemsUnread)
+	sub.Post("/dismiss", DismissDrawerItems)
+}
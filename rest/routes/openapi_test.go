@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validatedRequest runs a request against the documented v1 routes as the given user, requests are
+// validated with the given body limit and responses are validated against the OpenAPI document.
+func validatedRequest(t *testing.T, userID string, maxBodySize int64, method string, path string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Use(validateResponses(t))
+	router.Route(apiBasePath, func(sub chi.Router) {
+		sub.Use(m.ValidateRequest(openApiSpec, maxBodySize))
+		sub.Use(m.InjectUser)
+		sub.Route("/last-visited", MakeLastVisitedRoutes)
+		sub.Route("/favorite-pages", MakeFavoritePagesRoutes)
+		sub.Route("/self-report", MakeSelfReportRoutes)
+		sub.Route("/user", MakeUserIdentityRoutes)
+		sub.Route("/notifications/drawer", MakeNotificationDrawerRoutes)
+		sub.Route("/quickstarts", MakeQuickstartRoutes)
+		sub.Route("/org-defaults", MakeOrgDefaultsRoutes)
+	})
+
+	request := httptest.NewRequest(method, apiBasePath+path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	xrhid := &identity.XRHID{
+		Identity: identity.Identity{
+			OrgID: "validated-routes-org",
+			User:  &identity.User{UserID: userID},
+		},
+	}
+	ctx := context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request.WithContext(ctx))
+	return recorder
+}
+
+func TestValidatedRoutes(t *testing.T) {
+	database.Init()
+	const userID = "validated-routes-user"
+	cfg := config.Get()
+	original := cfg.FavoritesConfig
+	cfg.FavoritesConfig.ExportSecret = "validated-routes-secret"
+	t.Cleanup(func() {
+		cfg.FavoritesConfig = original
+		database.DB.Unscoped().Where("account_id = ?", userID).Delete(&models.UserIdentity{})
+	})
+
+	t.Run("Should reject bodies larger than the limit", func(t *testing.T) {
+		body := `{"pathname": "/insights/dashboard", "title": "` + strings.Repeat("a", 512) + `"}`
+		recorder := validatedRequest(t, userID, 256, http.MethodPost, "/last-visited/", body)
+		require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		assert.Equal(t, util.PROBLEM_CONTENT_TYPE, recorder.Header().Get("Content-Type"))
+
+		var problem util.Problem
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+		assert.Contains(t, problem.Detail, "256 bytes")
+	})
+
+	t.Run("Should reject invalid requests before creating the user", func(t *testing.T) {
+		const invalidUserID = "validated-routes-invalid-user"
+		recorder := validatedRequest(t, invalidUserID, 1024, http.MethodPost, "/last-visited/", `{"page": {"pathname": 1}}`)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		var count int64
+		require.NoError(t, database.DB.Model(&models.UserIdentity{}).Where("account_id = ?", invalidUserID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Should pass bodies within the limit to the handler", func(t *testing.T) {
+		recorder := validatedRequest(t, userID, 1024, http.MethodPost, "/last-visited/", `{"pathname": "/insights/dashboard", "title": "Dashboard", "bundle": "insights"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	// the responses are validated by validateResponses, the statuses only check the handlers ran
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/last-visited/", "", http.StatusOK},
+		{http.MethodGet, "/favorite-pages/?userId=1&getAll=true", "", http.StatusOK},
+		{http.MethodGet, "/favorite-pages/export", "", http.StatusOK},
+		{http.MethodGet, "/favorite-pages/folders/", "", http.StatusOK},
+		{http.MethodGet, "/self-report/", "", http.StatusOK},
+		{http.MethodGet, "/user/", "", http.StatusOK},
+		{http.MethodGet, "/user/visited-bundles/", "", http.StatusOK},
+		{http.MethodGet, "/user/visited-bundles/summary", "", http.StatusOK},
+		{http.MethodGet, "/user/preview-features/", "", http.StatusOK},
+		{http.MethodGet, "/notifications/drawer/", "", http.StatusOK},
+		{http.MethodGet, "/quickstarts/progress/", "", http.StatusOK},
+		{http.MethodGet, "/quickstarts/summary", "", http.StatusOK},
+		{http.MethodGet, "/org-defaults/favorites", "", http.StatusOK},
+		{http.MethodPost, "/favorite-pages/folders/", `{"name": "Validated"}`, http.StatusOK},
+		{http.MethodPost, "/favorite-pages/reorder", `{"ids": []}`, http.StatusOK},
+		{http.MethodPost, "/favorite-pages/move", `{"ids": []}`, http.StatusBadRequest},
+		{http.MethodPost, "/favorite-pages/folders/reorder", `{"ids": []}`, http.StatusOK},
+		{http.MethodPatch, "/self-report/", `{"jobRole": "developer"}`, http.StatusOK},
+		{http.MethodPost, "/user/visited-bundles/", `{"bundle": "insights"}`, http.StatusOK},
+		{http.MethodGet, "/user/visited-bundles/?sort=recent", "", http.StatusOK},
+		{http.MethodPost, "/notifications/drawer/mark-read", `{"ids": []}`, http.StatusBadRequest},
+		{http.MethodPut, "/quickstarts/progress/validated-quickstart", `{"bundle": "insights", "progress": {}}`, http.StatusOK},
+		{http.MethodGet, "/quickstarts/progress/validated-quickstart", "", http.StatusOK},
+		{http.MethodDelete, "/quickstarts/progress/validated-quickstart", "", http.StatusNoContent},
+		{http.MethodPut, "/quickstarts/progress/summary", `{"bundle": "insights", "progress": {}}`, http.StatusOK},
+		{http.MethodGet, "/quickstarts/progress/summary", "", http.StatusOK},
+		{http.MethodDelete, "/quickstarts/progress/summary", "", http.StatusNoContent},
+		{http.MethodPut, "/org-defaults/favorites", `{"pages": []}`, http.StatusForbidden},
+	} {
+		t.Run("Should document "+tc.method+" "+tc.path, func(t *testing.T) {
+			recorder := validatedRequest(t, userID, 1024, tc.method, tc.path, tc.body)
+			assert.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+)
+
+func requestOrgID(r *http.Request) string {
+	if id, ok := r.Context().Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil {
+		return id.Identity.OrgID
+	}
+	return ""
+}
+
+func GetOrgDashboardTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := service.GetOrgDashboardTemplate(requestOrgID(r), models.AvailableTemplates(chi.URLParam(r, "dashboard")))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.OrgDashboardTemplate]{Data: template})
+}
+
+func SetOrgDashboardTemplate(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	orgID := requestOrgID(r)
+	dashboard := chi.URLParam(r, "dashboard")
+	var templateConfig models.TemplateConfig
+	if err := json.NewDecoder(r.Body).Decode(&templateConfig); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	template, err := service.SetOrgDashboardTemplate(orgID, models.AvailableTemplates(dashboard), templateConfig, user.AccountId)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "org_dashboard_template", orgID+"/"+dashboard, "failure", "publish org default failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "org_dashboard_template", orgID+"/"+dashboard, "success")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.OrgDashboardTemplate]{Data: template})
+}
+
+func DeleteOrgDashboardTemplate(w http.ResponseWriter, r *http.Request) {
+	orgID := requestOrgID(r)
+	dashboard := chi.URLParam(r, "dashboard")
+	if err := service.DeleteOrgDashboardTemplate(orgID, models.AvailableTemplates(dashboard)); err != nil {
+		securitylog.LogWithReason(r.Context(), "DELETE", "org_dashboard_template", orgID+"/"+dashboard, "failure", "delete org default failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "DELETE", "org_dashboard_template", orgID+"/"+dashboard, "success")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetOrgStarterFavorites(w http.ResponseWriter, r *http.Request) {
+	favorites, err := service.GetOrgStarterFavorites(requestOrgID(r))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.OrgStarterFavorite]{
+		Data: favorites,
+		Meta: util.ListMeta{Count: len(favorites), Total: len(favorites)},
+	})
+}
+
+func SetOrgStarterFavorites(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	orgID := requestOrgID(r)
+	var request models.OrgStarterFavoritesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	favorites, err := service.SetOrgStarterFavorites(orgID, request, user.AccountId)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "org_starter_favorites", orgID, "failure", "publish starter favorites failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.Log(r.Context(), "UPDATE", "org_starter_favorites", orgID, "success")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.OrgStarterFavorite]{
+		Data: favorites,
+		Meta: util.ListMeta{Count: len(favorites), Total: len(favorites)},
+	})
+}
+
+// MakeOrgDefaultsRoutes registers the org defaults managed by org admins. Every user of the organization
+// can read them, only org admins can change them.
+func MakeOrgDefaultsRoutes(sub chi.Router) {
+	sub.Get("/dashboard-templates/{dashboard}", GetOrgDashboardTemplate)
+	sub.Get("/favorites", GetOrgStarterFavorites)
+	sub.Group(func(r chi.Router) {
+		r.Use(m.RequireOrgAdmin("org_defaults"))
+		r.Put("/dashboard-templates/{dashboard}", SetOrgDashboardTemplate)
+		r.Delete("/dashboard-templates/{dashboard}", DeleteOrgDashboardTemplate)
+		r.Put("/favorites", SetOrgStarterFavorites)
+	})
+}
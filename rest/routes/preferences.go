@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+)
+
+func GetUserPreferences(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	preference, err := service.GetUserPreferences(user.ID, chi.URLParam(r, "namespace"))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.UserPreference]{Data: preference})
+}
+
+func updateUserPreferences(w http.ResponseWriter, r *http.Request, patch bool) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	namespace := chi.URLParam(r, "namespace")
+
+	var request models.UserPreferenceRequest
+	// leave room for the request envelope around the document
+	r.Body = http.MaxBytesReader(w, r.Body, int64(2*config.Get().PreferencesConfig.MaxSize))
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			util.WriteError(w, r, util.ErrTooLarge)
+			return
+		}
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	// an unknown namespace is reported by the update
+	previous, _ := service.GetUserPreferences(user.ID, namespace)
+	preference, err := service.UpdateUserPreferences(user.ID, namespace, request, patch)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "user_preferences", user.AccountId, "failure", fmt.Sprintf("update %s preferences failed", namespace))
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.LogChange(r.Context(), "UPDATE", "user_preferences", user.AccountId, "success", previous, preference)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.UserPreference]{Data: preference})
+}
+
+func PutUserPreferences(w http.ResponseWriter, r *http.Request) {
+	updateUserPreferences(w, r, false)
+}
+
+func PatchUserPreferences(w http.ResponseWriter, r *http.Request) {
+	updateUserPreferences(w, r, true)
+}
+
+func MakeUserPreferencesRoutes(sub chi.Router) {
+	sub.Get("/{namespace}", GetUserPreferences)
+	sub.Put("/{namespace}", PutUserPreferences)
+	sub.Patch("/{namespace}", PatchUserPreferences)
+}
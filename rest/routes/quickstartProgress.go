@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+)
+
+func ListQuickstartProgress(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	progress, err := service.ListQuickstartProgress(user.ID, r.URL.Query().Get("bundle"))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.QuickstartProgress]{
+		Data: progress,
+		Meta: util.ListMeta{Count: len(progress), Total: len(progress)},
+	})
+}
+
+func GetQuickstartBundleSummary(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	summary, err := service.GetQuickstartBundleSummary(user.ID)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.QuickstartBundleSummary]{
+		Data: summary,
+		Meta: util.ListMeta{Count: len(summary), Total: len(summary)},
+	})
+}
+
+func GetQuickstartProgress(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	progress, err := service.GetQuickstartProgress(user.ID, chi.URLParam(r, "quickstartId"))
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.QuickstartProgress]{Data: progress})
+}
+
+func SaveQuickstartProgress(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var request models.QuickstartProgressRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return
+	}
+
+	progress, err := service.SaveQuickstartProgress(user.ID, chi.URLParam(r, "quickstartId"), request)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.QuickstartProgress]{Data: progress})
+}
+
+func ResetQuickstartProgress(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	if err := service.ResetQuickstartProgress(user.ID, chi.URLParam(r, "quickstartId")); err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetQuickstartStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := service.GetQuickstartStats()
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.ListResponse[models.QuickstartStats]{
+		Data: stats,
+		Meta: util.ListMeta{Count: len(stats), Total: len(stats)},
+	})
+}
+
+// MakeQuickstartRoutes registers the quickstart routes. The summaries are not nested in /progress so
+// they can not be taken for a quickstart ID.
+func MakeQuickstartRoutes(sub chi.Router) {
+	sub.Route("/progress", func(r chi.Router) {
+		r.Get("/", ListQuickstartProgress)
+		r.Get("/{quickstartId}", GetQuickstartProgress)
+		r.Put("/{quickstartId}", SaveQuickstartProgress)
+		r.Delete("/{quickstartId}", ResetQuickstartProgress)
+	})
+	sub.Get("/summary", GetQuickstartBundleSummary)
+	sub.With(m.RequireAllowlistedUser("quickstart-stats", func() []string {
+		return config.Get().AdminConfig.Allowlist
+	})).Get("/admin/stats", GetQuickstartStats)
+}
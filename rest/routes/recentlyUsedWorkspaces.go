@@ -2,6 +2,7 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
@@ -131,27 +132,62 @@ func sendJSONResponse(w http.ResponseWriter, status int, body any) {
 	}
 }
 
+// recentlyUsedWorkspacesListOptions are the pagination, sort and filter params of the recently used workspaces. The
+// workspaces are stored most recently used first, which is the order used without a sort param.
+var recentlyUsedWorkspacesListOptions = util.ListQueryOptions{
+	MaxLimit:   100,
+	SortFields: map[string]string{"name": "name", "type": "type"},
+	Filters:    map[string]util.ListFilter{"type": util.StringFilter("type")},
+}
+
+var recentlyUsedWorkspacesCompare = map[string]func(a models.Workspace, b models.Workspace) int{
+	"name": func(a models.Workspace, b models.Workspace) int {
+		return strings.Compare(a.Name, b.Name)
+	},
+	"type": func(a models.Workspace, b models.Workspace) int {
+		return strings.Compare(a.Type, b.Type)
+	},
+}
+
 // GetRecentlyUsedWorkspaces returns the given principal's most recently used workspaces.
 func GetRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 	// Get the user's identity object.
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 
-	recentlyUsedWorkspaces := user.RecentlyUsedWorkspaces.Data()
+	// Parse the pagination, sort and filter params before doing any work.
+	listQuery, err := util.ParseListQuery(r.URL.Query(), recentlyUsedWorkspacesListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
 
-	// Make sure we return a proper response when the user does not have anything in the column.
-	var responseBody = util.ListResponse[models.Workspace]{}
-	if recentlyUsedWorkspaces == nil {
-		responseBody.Data = []models.Workspace{}
-		responseBody.Meta = util.ListMeta{Count: 0, Total: 0}
-	} else {
-		responseBody.Data = recentlyUsedWorkspaces
-		responseBody.Meta = util.ListMeta{
-			Count: len(recentlyUsedWorkspaces),
-			Total: len(recentlyUsedWorkspaces),
+		return
+	}
+
+	// Refresh the workspaces' metadata and prune the ones the user can no longer use. The stored list is returned
+	// when the refreshed list cannot be saved.
+	recentlyUsedWorkspaces, version, err := service.RefreshRecentlyUsedWorkspaces(r.Context(), &user, r.Header.Get("x-rh-identity"))
+	if err != nil {
+		util.WriteError(w, r, err)
+
+		return
+	}
+
+	// The entity tag belongs to the workspaces that were just read, so unchanged workspaces are not sent again.
+	if notModified(w, r, service.RecentlyUsedWorkspacesETag(version)) {
+		return
+	}
+
+	// Filter and sort a copy of the workspaces, since the stored ones are shared with the identity cache. Make sure
+	// we return a proper response when the user does not have anything in the column.
+	workspaces := []models.Workspace{}
+	workspaceType, filterByType := listQuery.Filter("type")
+	for _, workspace := range recentlyUsedWorkspaces {
+		if !filterByType || workspace.Type == workspaceType {
+			workspaces = append(workspaces, workspace)
 		}
 	}
+	util.SortSlice(workspaces, listQuery, recentlyUsedWorkspacesCompare)
 
-	sendJSONResponse(w, http.StatusOK, responseBody)
+	sendJSONResponse(w, http.StatusOK, util.NewListResponse(util.PageSlice(workspaces, listQuery), len(workspaces), listQuery, r.URL))
 }
 
 // SaveRecentlyUsedWorkspaces grabs the recently used workspaces from the payload and stores them in the user's profile
@@ -162,9 +198,7 @@ func SaveRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		logrus.Errorf(`Unable to obtain the user identity from request %#v`, r)
 
-		sendJSONResponse(w, http.StatusInternalServerError, util.ErrorResponse{
-			Errors: []string{"Internal server error"},
-		})
+		util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "Internal server error"))
 
 		return
 	}
@@ -173,9 +207,7 @@ func SaveRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 	if r.Body == http.NoBody {
 		logrus.Debug(`Returning a "bad requestWorkspaces" response to a "save recently used workspaces" requestWorkspaces because the incoming body is empty`)
 
-		sendJSONResponse(w, http.StatusBadRequest, util.ErrorResponse{
-			Errors: []string{"Request body is empty"},
-		})
+		util.WriteProblem(w, r, util.NewProblem(http.StatusBadRequest, "Request body is empty"))
 
 		return
 	}
@@ -185,9 +217,7 @@ func SaveRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&requestWorkspaces); err != nil {
 		logrus.Debugf(`unable to decode the body from the incoming "store recently used workspaces" requestWorkspaces: %s`, err)
 
-		sendJSONResponse(w, http.StatusBadRequest, util.ErrorResponse{
-			Errors: []string{"Unexpected body specified. Please send a list of workspaces."},
-		})
+		util.WriteProblem(w, r, util.NewProblem(http.StatusBadRequest, "Unexpected body specified. Please send a list of workspaces."))
 
 		return
 	}
@@ -196,9 +226,7 @@ func SaveRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 	if len(requestWorkspaces) == 0 {
 		logrus.Debug(`Returning a "bad requestWorkspaces" response to a "save recently used workspaces" requestWorkspaces because the incoming body does not contain a single workspace we can save`)
 
-		sendJSONResponse(w, http.StatusBadRequest, util.ErrorResponse{
-			Errors: []string{"At least one workspace needs to be specified in the request"},
-		})
+		util.WriteProblem(w, r, util.NewProblem(http.StatusBadRequest, "At least one workspace needs to be specified in the request"))
 
 		return
 	}
@@ -208,9 +236,7 @@ func SaveRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 		if errors := validateWorkspace(incomingWorkspace); len(errors) > 0 {
 			logrus.Debugf(`Returning a "bad requestWorkspaces" response to a "save recently used workspaces" requestWorkspaces because the input has the following validation problems: %v`, errors)
 
-			sendJSONResponse(w, http.StatusBadRequest, util.ErrorResponse{
-				Errors: errors,
-			})
+			util.WriteProblem(w, r, util.NewProblem(http.StatusBadRequest, "").WithErrors(errors))
 
 			return
 		}
@@ -220,19 +246,41 @@ func SaveRecentlyUsedWorkspaces(w http.ResponseWriter, r *http.Request) {
 	// recently used workspaces we are allowed to store in the database.
 	workspacesToSave := cleanIncomingWorkspaces(requestWorkspaces)
 
-	// Save the most recently used workspaces in the database.
-	if err := service.SaveRecentlyUsedWorkspaces(&user, workspacesToSave); err != nil {
+	// Make sure the workspaces exist and that the user has access to them, when a workspace service is configured.
+	workspacesToSave, err := service.ValidateWorkspaces(r.Context(), r.Header.Get("x-rh-identity"), workspacesToSave)
+	if errors.Is(err, util.ErrBadRequest) {
+		logrus.Debugf(`Returning a "bad requestWorkspaces" response to a "save recently used workspaces" requestWorkspaces because the workspace validation failed: %s`, err)
+
+		util.WriteError(w, r, err)
+
+		return
+	} else if err != nil {
+		logrus.Errorf(`unable to validate the recently used workspaces: %s`, err)
+
+		util.WriteProblem(w, r, util.NewProblem(http.StatusServiceUnavailable, "Unable to validate the recently used workspaces"))
+
+		return
+	}
+
+	// Save the most recently used workspaces in the database, unless they changed since the If-Match entity tag.
+	version, err := service.SaveRecentlyUsedWorkspaces(&user, workspacesToSave, r.Header.Get("If-Match"))
+	if errors.Is(err, util.ErrPreconditionFailed) {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "recently_used_workspaces", user.AccountId, "failure", "precondition failed")
+
+		util.WriteError(w, r, err)
+
+		return
+	} else if err != nil {
 		logrus.Errorf(`unable to save the recently used workspaces in the database: %s`, err)
 		securitylog.LogWithReason(r.Context(), "UPDATE", "recently_used_workspaces", user.AccountId, "failure", "save failed")
 
-		sendJSONResponse(w, http.StatusInternalServerError, util.ErrorResponse{
-			Errors: []string{"Unable to save recently used workspaces"},
-		})
+		util.WriteProblem(w, r, util.NewProblem(http.StatusInternalServerError, "Unable to save recently used workspaces"))
 
 		return
 	}
 
 	securitylog.Log(r.Context(), "UPDATE", "recently_used_workspaces", user.AccountId, "success")
+	w.Header().Set("ETag", service.RecentlyUsedWorkspacesETag(version))
 
 	responseBody := util.ListResponse[models.Workspace]{
 		Data: workspacesToSave,
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/RedHatInsights/chrome-service-backend/rest/workspaces"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"io"
 	"net/http"
@@ -140,7 +143,7 @@ func TestFetchRecentlyUsedWorkspaces(t *testing.T) {
 
 	// Save some workspaces for the user.
 	generatedWorkspaces := generateWorkspace(t, configuration.MaximumNumberRecentlyUsedWorkspaces)
-	if err := service.SaveRecentlyUsedWorkspaces(&user, generatedWorkspaces); err != nil {
+	if _, err := service.SaveRecentlyUsedWorkspaces(&user, generatedWorkspaces, ""); err != nil {
 		t.Fatalf(`unable to save the recently used workspaces for user: %s`, err)
 	}
 
@@ -451,3 +454,190 @@ func TestSaveWorkspacesWithRepeatedAndOverLimit(t *testing.T) {
 		}
 	}
 }
+
+// TestSaveWorkspacesValidatesAccess tests that workspaces the user cannot access are rejected when a workspace
+// service is configured, and that the saved workspaces take the workspace service's metadata.
+func TestSaveWorkspacesValidatesAccess(t *testing.T) {
+	database.Init()
+
+	generatedWorkspaces := generateWorkspace(t, 2)
+	accessibleWorkspace := generatedWorkspaces[0]
+	accessibleWorkspace.Name = "Workspace service name"
+
+	fake := workspaces.NewFakeClient(accessibleWorkspace)
+	service.SetWorkspaceClient(fake)
+	t.Cleanup(func() {
+		service.SetWorkspaceClient(nil)
+	})
+
+	user := models.UserIdentity{AccountId: "validated-workspaces-user"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Errorf("unable to save the mock user identity in the database: %s", err)
+	}
+
+	saveWorkspaces := func(body []models.Workspace) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf(`unable to marshal the list of workspaces: %s`, err)
+		}
+
+		request, err := http.NewRequest("POST", "/recently-used-workspaces", bytes.NewReader(requestBody))
+		if err != nil {
+			t.Fatalf("unable to create a request for the test: %s", err)
+		}
+
+		requestRecorder := httptest.NewRecorder()
+		request = request.WithContext(context.WithValue(context.Background(), util.USER_CTX_KEY, user))
+		http.HandlerFunc(SaveRecentlyUsedWorkspaces).ServeHTTP(requestRecorder, request)
+		return requestRecorder
+	}
+
+	// The second workspace does not exist in the workspace service.
+	requestRecorder := saveWorkspaces(generatedWorkspaces)
+	if requestRecorder.Code != http.StatusBadRequest {
+		t.Fatalf(`unexpected status code received when saving an unknown workspace. Want "%d", got "%d"`, http.StatusBadRequest, requestRecorder.Code)
+	}
+	assertErrors(t, []string{fmt.Sprintf("bad request: workspace %s does not exist or is not accessible", generatedWorkspaces[1].Id)}, requestRecorder.Body)
+
+	// The workspace service being unavailable must not let unchecked workspaces through.
+	fake.Err = errors.New("workspace service unavailable")
+	requestRecorder = saveWorkspaces(generatedWorkspaces[:1])
+	if requestRecorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf(`unexpected status code received when the workspace service is unavailable. Want "%d", got "%d"`, http.StatusServiceUnavailable, requestRecorder.Code)
+	}
+
+	fake.Err = nil
+	requestRecorder = saveWorkspaces(generatedWorkspaces[:1])
+	if requestRecorder.Code != http.StatusCreated {
+		t.Fatalf(`unexpected status code received when saving an accessible workspace. Want "%d", got "%d"`, http.StatusCreated, requestRecorder.Code)
+	}
+
+	var responseBody util.ListResponse[models.Workspace]
+	if err := json.Unmarshal(requestRecorder.Body.Bytes(), &responseBody); err != nil {
+		t.Fatalf(`unable to unmarshal the response body: %s`, err)
+	}
+	if len(responseBody.Data) != 1 || responseBody.Data[0].Name != accessibleWorkspace.Name {
+		t.Errorf(`unexpected saved workspaces. Want "%v", got "%v"`, accessibleWorkspace, responseBody.Data)
+	}
+}
+
+// TestFetchRecentlyUsedWorkspacesPaginated tests that the recently used workspaces can be paginated, and that invalid
+// pagination params are rejected.
+func TestFetchRecentlyUsedWorkspacesPaginated(t *testing.T) {
+	database.Init()
+
+	user := models.UserIdentity{AccountId: "paginated-workspaces-user"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Errorf("unable to save the mock user identity in the database: %s", err)
+	}
+
+	generatedWorkspaces := generateWorkspace(t, 5)
+	if _, err := service.SaveRecentlyUsedWorkspaces(&user, generatedWorkspaces, ""); err != nil {
+		t.Fatalf(`unable to save the recently used workspaces for user: %s`, err)
+	}
+
+	fetchWorkspaces := func(query string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("GET", "/recently-used-workspaces?"+query, nil)
+		if err != nil {
+			t.Fatalf("unable to create a request for the test: %s", err)
+		}
+
+		requestRecorder := httptest.NewRecorder()
+		request = request.WithContext(context.WithValue(context.Background(), util.USER_CTX_KEY, user))
+		http.HandlerFunc(GetRecentlyUsedWorkspaces).ServeHTTP(requestRecorder, request)
+		return requestRecorder
+	}
+
+	requestRecorder := fetchWorkspaces("limit=2&offset=2")
+	if requestRecorder.Code != http.StatusOK {
+		t.Fatalf(`unexpected status code received when fetching a page of workspaces. Want "%d", got "%d"`, http.StatusOK, requestRecorder.Code)
+	}
+
+	var responseBody util.ListResponse[models.Workspace]
+	if err := json.Unmarshal(requestRecorder.Body.Bytes(), &responseBody); err != nil {
+		t.Fatalf(`unable to unmarshal the response body: %s`, err)
+	}
+
+	if len(responseBody.Data) != 2 || responseBody.Data[0].Id != generatedWorkspaces[2].Id {
+		t.Errorf(`unexpected page of workspaces. Want the third and fourth workspaces, got "%v"`, responseBody.Data)
+	}
+
+	if responseBody.Meta.Count != 2 || responseBody.Meta.Total != 5 || responseBody.Meta.Limit != 2 || responseBody.Meta.Offset != 2 {
+		t.Errorf(`unexpected metadata received in the response: %#v`, responseBody.Meta)
+	}
+
+	if responseBody.Links == nil || responseBody.Links.Next != "/recently-used-workspaces?limit=2&offset=4" || responseBody.Links.Previous != "/recently-used-workspaces?limit=2&offset=0" {
+		t.Errorf(`unexpected links received in the response: %#v`, responseBody.Links)
+	}
+
+	requestRecorder = fetchWorkspaces("limit=0")
+	if requestRecorder.Code != http.StatusBadRequest {
+		t.Fatalf(`unexpected status code received when sending an invalid limit. Want "%d", got "%d"`, http.StatusBadRequest, requestRecorder.Code)
+	}
+	assertErrors(t, []string{"bad request: invalid limit 0, expected a number between 1 and 100"}, requestRecorder.Body)
+}
+
+// TestRecentlyUsedWorkspacesETag tests that the workspaces carry an entity tag, that unchanged workspaces are not sent
+// again, and that writes based on stale workspaces are rejected.
+func TestRecentlyUsedWorkspacesETag(t *testing.T) {
+	database.Init()
+
+	user := models.UserIdentity{AccountId: "etag-workspaces-user"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Errorf("unable to save the mock user identity in the database: %s", err)
+	}
+
+	router := chi.NewRouter()
+	MakeRecentlyUsedWorkspacesRoutes(router)
+
+	sendRequest := func(method string, body []models.Workspace, headers map[string]string) *httptest.ResponseRecorder {
+		var requestBody io.Reader = http.NoBody
+		if body != nil {
+			encoded, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf(`unable to marshal the list of workspaces: %s`, err)
+			}
+			requestBody = bytes.NewReader(encoded)
+		}
+
+		request, err := http.NewRequest(method, "/", requestBody)
+		if err != nil {
+			t.Fatalf("unable to create a request for the test: %s", err)
+		}
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+
+		requestRecorder := httptest.NewRecorder()
+		router.ServeHTTP(requestRecorder, request.WithContext(context.WithValue(context.Background(), util.USER_CTX_KEY, user)))
+		return requestRecorder
+	}
+
+	requestRecorder := sendRequest("GET", nil, nil)
+	etag := requestRecorder.Header().Get("ETag")
+	if requestRecorder.Code != http.StatusOK || etag == "" {
+		t.Fatalf(`unexpected response when fetching the workspaces. Want "%d" with an ETag, got "%d" with "%s"`, http.StatusOK, requestRecorder.Code, etag)
+	}
+
+	requestRecorder = sendRequest("GET", nil, map[string]string{"If-None-Match": etag})
+	if requestRecorder.Code != http.StatusNotModified || requestRecorder.Body.Len() != 0 {
+		t.Errorf(`unexpected response when fetching unchanged workspaces. Want "%d" without a body, got "%d" with "%s"`, http.StatusNotModified, requestRecorder.Code, requestRecorder.Body)
+	}
+
+	requestRecorder = sendRequest("POST", generateWorkspace(t, 1), map[string]string{"If-Match": etag})
+	newETag := requestRecorder.Header().Get("ETag")
+	if requestRecorder.Code != http.StatusCreated || newETag == "" || newETag == etag {
+		t.Fatalf(`unexpected response when saving the workspaces. Want "%d" with a new ETag, got "%d" with "%s"`, http.StatusCreated, requestRecorder.Code, newETag)
+	}
+
+	// The first ETag no longer describes the stored workspaces.
+	requestRecorder = sendRequest("POST", generateWorkspace(t, 1), map[string]string{"If-Match": etag})
+	if requestRecorder.Code != http.StatusPreconditionFailed {
+		t.Errorf(`unexpected status code received when saving stale workspaces. Want "%d", got "%d"`, http.StatusPreconditionFailed, requestRecorder.Code)
+	}
+
+	requestRecorder = sendRequest("GET", nil, map[string]string{"If-None-Match": etag})
+	if requestRecorder.Code != http.StatusOK || requestRecorder.Header().Get("ETag") != newETag {
+		t.Errorf(`unexpected response when fetching changed workspaces. Want "%d" with "%s", got "%d" with "%s"`, http.StatusOK, newETag, requestRecorder.Code, requestRecorder.Header().Get("ETag"))
+	}
+}
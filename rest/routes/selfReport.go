@@ -2,66 +2,91 @@ package routes
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
-	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
 	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
 	"github.com/RedHatInsights/chrome-service-backend/rest/service"
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/go-chi/chi/v5"
-	"github.com/sirupsen/logrus"
 )
 
 func GetUserSelfReport(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
 	selfReport, err := service.GetSelfReport(user.ID)
-
 	if err != nil {
-		panic(err)
+		util.WriteError(w, r, err)
+		return
+	}
+	if notModified(w, r, service.SelfReportETag(selfReport)) {
+		return
 	}
 
 	json.NewEncoder(w).Encode(selfReport)
 }
 
-func UpdateUserSelfReport(w http.ResponseWriter, r *http.Request) {
-	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
-	userID := user.ID
-	var updatedSelfReport models.SelfReport
-	err := database.DB.Model(&models.SelfReport{}).Where("user_identity_id = ?", userID).Find(&updatedSelfReport).Error
+// UpdateSelfReportPayload lists the self report fields users can change, fields that are not set keep their value.
+type UpdateSelfReportPayload struct {
+	ProductsOfInterest *[]string `json:"productsOfInterest"`
+	JobRole            *string   `json:"jobRole"`
+}
 
-	if err != nil {
-		updatedSelfReport = models.SelfReport{}
-		panic(err)
+// updateSelfReport applies the payload of the request to the self report of the user. Errors are written
+// to w, ok is false then.
+func updateSelfReport(w http.ResponseWriter, r *http.Request) (selfReport models.SelfReport, ok bool) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	var payload UpdateSelfReportPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error()))
+		return selfReport, false
 	}
 
-	err = json.NewDecoder(r.Body).Decode(&updatedSelfReport)
+	previous, selfReport, err := service.UpdateSelfReport(user.ID, service.SelfReportUpdate(payload), r.Header.Get("If-Match"))
 	if err != nil {
-		updatedSelfReport = models.SelfReport{}
-		panic(err)
+		securitylog.LogWithReason(r.Context(), "UPDATE", "self_report", user.AccountId, "failure", "update failed")
+		util.WriteError(w, r, err)
+		return selfReport, false
 	}
-	updatedSelfReport.UserIdentityID = userID
 
-	err = database.DB.Model(user).Preload("SelfReport").Find(&user).Error
-	if err == nil {
-		user.SelfReport = updatedSelfReport
-		err = database.DB.Save(&updatedSelfReport).Error
+	securitylog.LogChange(r.Context(), "UPDATE", "self_report", user.AccountId, "success", previous, selfReport)
+	w.Header().Set("ETag", service.SelfReportETag(selfReport))
+	return selfReport, true
+}
+
+func UpdateUserSelfReport(w http.ResponseWriter, r *http.Request) {
+	selfReport, ok := updateSelfReport(w, r)
+	if !ok {
+		return
 	}
 
+	json.NewEncoder(w).Encode(selfReport)
+}
+
+// GetUserSelfReportV2 answers with the self report wrapped in an entity response, unlike the v1 route.
+func GetUserSelfReportV2(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(util.USER_CTX_KEY).(models.UserIdentity)
+	selfReport, err := service.GetSelfReport(user.ID)
 	if err != nil {
-		errString := "Unable to update self report."
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(errString))
-		logrus.Errorf("unable to update self report, %s", err.Error())
-		securitylog.LogWithReason(r.Context(), "UPDATE", "self_report", user.AccountId, "failure", "update failed")
+		util.WriteError(w, r, err)
+		return
+	}
+	if notModified(w, r, service.SelfReportETag(selfReport)) {
 		return
 	}
 
-	securitylog.Log(r.Context(), "UPDATE", "self_report", user.AccountId, "success")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.SelfReport]{Data: selfReport})
+}
 
-	resp := user.SelfReport
+func UpdateUserSelfReportV2(w http.ResponseWriter, r *http.Request) {
+	selfReport, ok := updateSelfReport(w, r)
+	if !ok {
+		return
+	}
 
-	json.NewEncoder(w).Encode(resp)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.SelfReport]{Data: selfReport})
 }
 
 func MakeSelfReportRoutes(sub chi.Router) {
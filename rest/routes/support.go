@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// SupportBasePath is the prefix of the support API. It is not part of the versioned APIs because its
+// callers are associates and service accounts, which do not have a user identity.
+const SupportBasePath = "/api/chrome-service/support"
+
+// SupportReasonHeader carries the justification of a support action, it is required on every request
+// and recorded in the audit trail.
+const SupportReasonHeader = "X-Support-Reason"
+
+const maxSupportReasonLength = 500
+
+// supportUser returns the user the support request targets and the supplied reason. The response is
+// written and ok is false when the reason is missing or the user does not exist.
+func supportUser(w http.ResponseWriter, r *http.Request, action, resourceType string) (user models.UserIdentity, reason string, ok bool) {
+	accountId := chi.URLParam(r, "accountId")
+	reason = strings.TrimSpace(r.Header.Get(SupportReasonHeader))
+	if reason == "" || len(reason) > maxSupportReasonLength {
+		securitylog.LogWithReason(r.Context(), action, resourceType, accountId, "failure", "missing or invalid support reason")
+		util.WriteError(w, r, fmt.Errorf("%w: the %s header is required and limited to %d characters", util.ErrBadRequest, SupportReasonHeader, maxSupportReasonLength))
+		return user, reason, false
+	}
+
+	user, err := service.FindUserIdentity(accountId)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), action, resourceType, accountId, "failure", "user lookup failed: "+reason)
+		util.WriteError(w, r, err)
+		return user, reason, false
+	}
+	return user, reason, true
+}
+
+func GetSupportUser(w http.ResponseWriter, r *http.Request) {
+	user, reason, ok := supportUser(w, r, "READ", "user_identity")
+	if !ok {
+		return
+	}
+
+	securitylog.LogWithReason(r.Context(), "READ", "user_identity", user.AccountId, "success", reason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.UserIdentity]{Data: user})
+}
+
+func GetSupportUserFavorites(w http.ResponseWriter, r *http.Request) {
+	listQuery, err := util.ParseListQuery(r.URL.Query(), service.FavoritePagesListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	user, reason, ok := supportUser(w, r, "READ", "favorite_page")
+	if !ok {
+		return
+	}
+
+	favorites, total, _, err := service.ListUserFavoritePages(user.ID, false, listQuery)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "READ", "favorite_page", user.AccountId, "failure", "listing failed: "+reason)
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.LogWithReason(r.Context(), "READ", "favorite_page", user.AccountId, "success", reason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.NewListResponse(favorites, total, listQuery, r.URL))
+}
+
+func GetSupportUserDashboardTemplates(w http.ResponseWriter, r *http.Request) {
+	listQuery, err := util.ParseListQuery(r.URL.Query(), service.DashboardTemplatesListOptions)
+	if err != nil {
+		util.WriteError(w, r, err)
+		return
+	}
+	user, reason, ok := supportUser(w, r, "READ", "dashboard_template")
+	if !ok {
+		return
+	}
+
+	// without a dashboard no base template is forked, the user's templates are left as they are
+	templates, total, err := service.ListDashboardTemplates(user.ID, "", "", listQuery)
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "READ", "dashboard_template", user.AccountId, "failure", "listing failed: "+reason)
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.LogWithReason(r.Context(), "READ", "dashboard_template", user.AccountId, "success", reason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.NewListResponse(templates, total, listQuery, r.URL))
+}
+
+func ResetSupportUserDashboardTemplate(w http.ResponseWriter, r *http.Request) {
+	templateIdParam := chi.URLParam(r, "templateId")
+	templateId, err := strconv.ParseUint(templateIdParam, 10, 64)
+	if err != nil {
+		util.WriteError(w, r, fmt.Errorf("%w: invalid template id %q", util.ErrBadRequest, templateIdParam))
+		return
+	}
+	user, reason, ok := supportUser(w, r, "UPDATE", "dashboard_template")
+	if !ok {
+		return
+	}
+
+	previousTemplate, err := service.FindUserDashboardTemplate(user.ID, uint(templateId))
+	if errors.Is(err, util.ErrNotAuthorized) {
+		// templates of other users do not exist from the point of view of this user
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "dashboard_template", templateIdParam, "failure", "template lookup failed: "+reason)
+		util.WriteError(w, r, err)
+		return
+	}
+
+	template, err := service.ResetDashboardTemplate(user.ID, uint(templateId), r.Header.Get("If-Match"))
+	if err != nil {
+		securitylog.LogWithReason(r.Context(), "UPDATE", "dashboard_template", templateIdParam, "failure", "reset failed: "+reason)
+		util.WriteError(w, r, err)
+		return
+	}
+
+	securitylog.LogChangeWithReason(r.Context(), "UPDATE", "dashboard_template", templateIdParam, "success", reason, previousTemplate, template)
+	w.Header().Set("ETag", service.DashboardTemplateETag(template))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(util.EntityResponse[models.DashboardTemplate]{Data: template})
+}
+
+func DeleteSupportUserCache(w http.ResponseWriter, r *http.Request) {
+	user, reason, ok := supportUser(w, r, "DELETE", "identity_cache")
+	if !ok {
+		return
+	}
+
+	service.EvictCachedIdentity(user.AccountId)
+	securitylog.LogWithReason(r.Context(), "DELETE", "identity_cache", user.AccountId, "success", reason)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DeleteSupportUserConnections(w http.ResponseWriter, r *http.Request) {
+	user, reason, ok := supportUser(w, r, "DELETE", "websocket")
+	if !ok {
+		return
+	}
+
+	if err := service.DisconnectUser(user.AccountId); err != nil {
+		securitylog.LogWithReason(r.Context(), "DELETE", "websocket", user.AccountId, "failure", "disconnect failed: "+reason)
+		util.WriteError(w, r, err)
+		return
+	}
+	securitylog.LogWithReason(r.Context(), "DELETE", "websocket", user.AccountId, "success", reason)
+	// the replicas close the connections once they consume the event
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// MakeSupportRoutes registers the support API, only the allowlisted associates and service accounts
+// can use it. Every request has to supply the SupportReasonHeader.
+func MakeSupportRoutes(sub chi.Router) {
+	sub.Use(m.RequireAllowlistedStaff("support", func() []string {
+		return config.Get().AdminConfig.Allowlist
+	}))
+	sub.Route("/users/{accountId}", func(r chi.Router) {
+		r.Get("/", GetSupportUser)
+		r.Get("/favorites", GetSupportUserFavorites)
+		r.Get("/dashboard-templates", GetSupportUserDashboardTemplates)
+		r.Post("/dashboard-templates/{templateId}/reset", ResetSupportUserDashboardTemplate)
+		r.Delete("/cache", DeleteSupportUserCache)
+		r.Delete("/connections", DeleteSupportUserConnections)
+	})
+}
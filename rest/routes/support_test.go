@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/service"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const supportAssociate = "support-associate@redhat.com"
+
+func supportRequest(t *testing.T, xrhid *identity.XRHID, method string, path string, reason string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Route(SupportBasePath, MakeSupportRoutes)
+
+	request := httptest.NewRequest(method, SupportBasePath+path, nil)
+	if reason != "" {
+		request.Header.Set(SupportReasonHeader, reason)
+	}
+	ctx := context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request.WithContext(ctx))
+	return recorder
+}
+
+func associateIdentity(email string) *identity.XRHID {
+	return &identity.XRHID{
+		Identity: identity.Identity{
+			Type:      "Associate",
+			Associate: &identity.Associate{Email: email},
+		},
+	}
+}
+
+func TestSupportRoutes(t *testing.T) {
+	database.Init()
+	cfg := config.Get()
+	original := cfg.AdminConfig
+	cfg.AdminConfig = config.AdminConfig{Allowlist: []string{supportAssociate, "support-user"}}
+	securitylog.RegisterSink(database.AuditSink{})
+	t.Cleanup(func() {
+		cfg.AdminConfig = original
+		securitylog.ResetSinks()
+		database.DB.Where("user_id = ?", supportAssociate).Delete(&models.AuditEvent{})
+	})
+
+	user, err := service.CreateIdentity("support-target", "support-org", false)
+	require.NoError(t, err)
+	template, err := service.ForkBaseTemplate(user.ID, "support-org", models.LandingPage)
+	require.NoError(t, err)
+	template, err = service.UpdateDashboardTemplate(template.ID, user.ID, models.DashboardTemplate{
+		TemplateConfig: models.TemplateConfig{Sm: service.ConvertToJson([]models.GridItem{})},
+	}, "")
+	require.NoError(t, err)
+	associate := associateIdentity(supportAssociate)
+
+	t.Run("Should reject customer users even when allowlisted", func(t *testing.T) {
+		customer := &identity.XRHID{Identity: identity.Identity{User: &identity.User{UserID: "support-user"}}}
+		recorder := supportRequest(t, customer, http.MethodGet, "/users/support-target", "ticket 1")
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("Should reject associates missing from the allowlist", func(t *testing.T) {
+		recorder := supportRequest(t, associateIdentity("someone@redhat.com"), http.MethodGet, "/users/support-target", "ticket 1")
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("Should require a reason", func(t *testing.T) {
+		recorder := supportRequest(t, associate, http.MethodGet, "/users/support-target", "")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should report unknown users", func(t *testing.T) {
+		recorder := supportRequest(t, associate, http.MethodGet, "/users/nobody", "ticket 1")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Should return the identity of the user", func(t *testing.T) {
+		recorder := supportRequest(t, associate, http.MethodGet, "/users/support-target", "ticket 1")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.EntityResponse[models.UserIdentity]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, user.ID, response.Data.ID)
+	})
+
+	t.Run("Should list the dashboard templates of the user", func(t *testing.T) {
+		recorder := supportRequest(t, associate, http.MethodGet, "/users/support-target/dashboard-templates", "ticket 2")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.ListResponse[models.DashboardTemplate]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, template.ID, response.Data[0].ID)
+	})
+
+	t.Run("Should list the favorites of the user", func(t *testing.T) {
+		recorder := supportRequest(t, associate, http.MethodGet, "/users/support-target/favorites", "ticket 2")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Should reset the dashboard template and audit the reason", func(t *testing.T) {
+		recorder := supportRequest(t, associate, http.MethodPost, "/users/support-target/dashboard-templates/"+strconv.FormatUint(uint64(template.ID), 10)+"/reset", "ticket 3")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.EntityResponse[models.DashboardTemplate]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.Data.TemplateConfig.Sm.Data())
+
+		var event models.AuditEvent
+		require.NoError(t, database.DB.Where("user_id = ? AND reason = ?", supportAssociate, "ticket 3").First(&event).Error)
+		assert.Equal(t, "UPDATE", event.Action)
+		assert.Equal(t, "dashboard_template", event.ResourceType)
+		assert.NotEmpty(t, event.Diff)
+	})
+
+	t.Run("Should not reset templates of other users", func(t *testing.T) {
+		other, err := service.CreateIdentity("support-other", "support-org", false)
+		require.NoError(t, err)
+		otherTemplate, err := service.ForkBaseTemplate(other.ID, "support-org", models.LandingPage)
+		require.NoError(t, err)
+
+		recorder := supportRequest(t, associate, http.MethodPost, "/users/support-target/dashboard-templates/"+strconv.FormatUint(uint64(otherTemplate.ID), 10)+"/reset", "ticket 3")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Should clear the cached identity", func(t *testing.T) {
+		util.UsersCache.Set("support-target", user)
+		recorder := supportRequest(t, associate, http.MethodDelete, "/users/support-target/cache", "ticket 4")
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+
+		_, ok := util.UsersCache.Get("support-target")
+		assert.False(t, ok)
+	})
+
+	t.Run("Should disconnect the sockets of the user on every replica", func(t *testing.T) {
+		events := []cloudevents.KafkaEnvelope{}
+		service.SetEventPublisher(func(event cloudevents.KafkaEnvelope) error {
+			events = append(events, event)
+			return nil
+		})
+		t.Cleanup(func() { service.SetEventPublisher(nil) })
+
+		recorder := supportRequest(t, associate, http.MethodDelete, "/users/support-target/connections", "ticket 5")
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+		require.Len(t, events, 1)
+		assert.Equal(t, service.ConnectionsDisconnectEventType, events[0].Type)
+		assert.Equal(t, []string{"support-target"}, events[0].Data.Users)
+	})
+
+	var count int64
+	require.NoError(t, database.DB.Model(&models.AuditEvent{}).Where("user_id = ? AND outcome = ?", supportAssociate, "success").Count(&count).Error)
+	assert.Equal(t, int64(6), count)
+}
@@ -0,0 +1,367 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/openapi"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// V2BasePath is the prefix of the v2 API. It replaces the verb-style routes of v1 with resources and wraps
+// every response body in an entity or list response.
+const V2BasePath = "/api/chrome-service/v2"
+
+// V2Route is a route of the v2 API along with its documentation, spec/openapi-v2.yaml is generated from
+// the routes so the document can not drift from the router.
+type V2Route struct {
+	openapi.GeneratedOperation
+	Handler     http.HandlerFunc
+	Middlewares []func(http.Handler) http.Handler
+}
+
+// v2ErrorResponses are the response components of the error statuses, NotModified is listed with them as
+// it is documented the same way.
+var v2ErrorResponses = map[int]string{
+	http.StatusNotModified:           "NotModified",
+	http.StatusBadRequest:            "BadRequest",
+	http.StatusForbidden:             "Unauthorized",
+	http.StatusNotFound:              "NotFound",
+	http.StatusConflict:              "Conflict",
+	http.StatusPreconditionFailed:    "PreconditionFailed",
+	http.StatusRequestEntityTooLarge: "PayloadTooLarge",
+	http.StatusUnprocessableEntity:   "Unprocessable",
+	http.StatusTooManyRequests:       "TooManyRequests",
+	http.StatusInternalServerError:   "InternalError",
+}
+
+// V2SpecGenerator is the command regenerating the v2 document.
+const V2SpecGenerator = "make generate-spec-v2"
+
+// GenerateV2Spec builds the v2 document from the v1 document, whose components are shared, the v2-only
+// components and the v2 routes.
+func GenerateV2Spec(source []byte, components []byte) ([]byte, error) {
+	operations := []openapi.GeneratedOperation{}
+	for _, route := range V2Routes() {
+		operations = append(operations, route.GeneratedOperation)
+	}
+	return openapi.Generate(source, openapi.GenerateOptions{
+		Generator:   V2SpecGenerator,
+		Title:       "Chrome service backend",
+		Description: "Chrome service backend API Documentation. Every response body is wrapped in a data envelope, lists carry their meta and links.",
+		Version:     "2.0.0",
+		Server:      V2BasePath + "/",
+		Components:  components,
+		PathParameterTypes: map[string]string{
+			"favoriteId":     "integer",
+			"folderId":       "integer",
+			"templateId":     "integer",
+			"announcementId": "integer",
+		},
+		ErrorResponses: v2ErrorResponses,
+		DefaultErrors:  []int{http.StatusBadRequest, http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError},
+	}, operations)
+}
+
+// writeCreated answers a request that created entity with 201 and its location.
+func writeCreated[T any](w http.ResponseWriter, location string, entity T) {
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(util.EntityResponse[T]{Data: entity})
+}
+
+func dashboardTemplateLocation(dashboardTemplate models.DashboardTemplate) string {
+	return fmt.Sprintf("%s/dashboard-templates/%d", V2BasePath, dashboardTemplate.ID)
+}
+
+func v2Route(method string, path string, handler http.HandlerFunc, operation openapi.GeneratedOperation, middlewares ...func(http.Handler) http.Handler) V2Route {
+	operation.Method = method
+	operation.Path = path
+	return V2Route{GeneratedOperation: operation, Handler: handler, Middlewares: middlewares}
+}
+
+// V2Routes lists the routes of the v2 API. Routes sharing the contract of a v1 route reuse its handler,
+// the others have a V2 handler next to their v1 counterpart. Both use the same service layer.
+func V2Routes() []V2Route {
+	announcementsAdmin := m.RequireAllowlistedUser("announcement", func() []string {
+		return config.Get().AdminConfig.Allowlist
+	})
+	quickstartsAdmin := m.RequireAllowlistedUser("quickstart-stats", func() []string {
+		return config.Get().AdminConfig.Allowlist
+	})
+	auditAdmin := m.RequireAllowlistedUser("audit_event", func() []string {
+		return config.Get().AdminConfig.Allowlist
+	})
+	orgAdmin := m.RequireOrgAdmin("org_defaults")
+
+	entity := openapi.EntityEnvelope
+	list := openapi.ListEnvelope
+	ok := http.StatusOK
+	created := http.StatusCreated
+	noContent := http.StatusNoContent
+	notFound := []int{http.StatusNotFound}
+
+	return []V2Route{
+		// user
+		v2Route(http.MethodGet, "/user", GetUserIdentity, openapi.GeneratedOperation{
+			Description: "Get the user", Status: ok, Response: "User", Envelope: entity,
+		}),
+		v2Route(http.MethodPatch, "/user", UpdateUserIdentityV2, openapi.GeneratedOperation{
+			Description: "Update the preview settings and the active workspace of the user",
+			Request:     "UserUpdateRequest", Status: ok, Response: "User", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/user/intercom", GetIntercomHash, openapi.GeneratedOperation{
+			From: "GET /user/intercom", Status: ok, Response: "IntercomHashes", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/user/visited-bundles", GetVisitedBundlesV2, openapi.GeneratedOperation{
+			Description: "Get the bundles visited by the user", Status: ok, Response: "VisitedBundles", Envelope: entity,
+		}),
+		v2Route(http.MethodPost, "/user/visited-bundles", AddVisitedBundleV2, openapi.GeneratedOperation{
+			Description: "Record a visit of a bundle",
+			Request:     "VisitedBundleRequest", Status: ok, Response: "VisitedBundles", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/user/visited-bundles/summary", GetVisitedBundlesSummary, openapi.GeneratedOperation{
+			From: "GET /user/visited-bundles/summary", Status: ok, Response: "BundleVisitsSummary", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/user/bundle-visits", GetBundleVisitsV2, openapi.GeneratedOperation{
+			From:        "GET /user/visited-bundles",
+			Description: "Get the visits history of the bundles, most recent first unless sorted by frequency",
+			Status:      ok, Response: "BundleVisit", Envelope: list,
+		}),
+		v2Route(http.MethodGet, "/user/preferences/{namespace}", GetUserPreferences, openapi.GeneratedOperation{
+			From: "GET /user/preferences/{namespace}", Status: ok, Response: "UserPreference", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodPut, "/user/preferences/{namespace}", PutUserPreferences, openapi.GeneratedOperation{
+			From: "PUT /user/preferences/{namespace}", Request: "UserPreferenceRequest", Status: ok, Response: "UserPreference", Envelope: entity,
+			Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge},
+		}),
+		v2Route(http.MethodPatch, "/user/preferences/{namespace}", PatchUserPreferences, openapi.GeneratedOperation{
+			From: "PATCH /user/preferences/{namespace}", Request: "UserPreferenceRequest", Status: ok, Response: "UserPreference", Envelope: entity,
+			Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge},
+		}),
+		v2Route(http.MethodGet, "/user/preview-features", GetPreviewFeatures, openapi.GeneratedOperation{
+			From: "GET /user/preview-features", Status: ok, Response: "PreviewFeature", Envelope: list,
+		}),
+		v2Route(http.MethodPatch, "/user/preview-features/{feature}", UpdatePreviewFeatureV2, openapi.GeneratedOperation{
+			Description: "Enroll into a preview feature, leave it or mark it as seen",
+			Request:     "PreviewFeatureUpdateRequest", Status: ok, Response: "PreviewFeature", Envelope: entity, Errors: notFound,
+		}),
+
+		// self report
+		v2Route(http.MethodGet, "/self-report", GetUserSelfReportV2, openapi.GeneratedOperation{
+			From: "GET /self-report", Parameters: []string{"IfNoneMatch"}, Status: ok, Response: "SelfReport", Envelope: entity,
+			Errors: []int{http.StatusNotModified},
+		}),
+		v2Route(http.MethodPatch, "/self-report", UpdateUserSelfReportV2, openapi.GeneratedOperation{
+			From: "PATCH /self-report", Request: "UserSelfReportRequest", Status: ok, Response: "SelfReport", Envelope: entity,
+			Errors: []int{http.StatusPreconditionFailed},
+		}),
+
+		// last visited pages and workspaces
+		v2Route(http.MethodGet, "/last-visited", GetLastVisitedPages, openapi.GeneratedOperation{
+			From: "GET /last-visited", Status: ok, Response: "LastVisitedPage", Envelope: list,
+		}),
+		v2Route(http.MethodPost, "/last-visited", StoreLastVisitedPages, openapi.GeneratedOperation{
+			From: "POST /last-visited", Request: "LastVisitedRequest", Status: ok, Response: "LastVisitedPage", Envelope: list,
+		}),
+		v2Route(http.MethodGet, "/recently-used-workspaces", GetRecentlyUsedWorkspaces, openapi.GeneratedOperation{
+			Description: "Get the workspaces recently used by the user, most recent first",
+			Parameters:  []string{"Limit", "Offset", "IfNoneMatch"}, Status: ok, Response: "Workspace", Envelope: list,
+			Errors: []int{http.StatusNotModified},
+		}),
+		v2Route(http.MethodPost, "/recently-used-workspaces", SaveRecentlyUsedWorkspaces, openapi.GeneratedOperation{
+			Description: "Record the use of workspaces",
+			Parameters:  []string{"IfMatch"}, Request: "WorkspacesRequest", Status: created, Response: "Workspace", Envelope: list,
+			Errors: []int{http.StatusPreconditionFailed},
+		}),
+
+		// favorite pages
+		v2Route(http.MethodGet, "/favorite-pages", GetFavoritePagesV2, openapi.GeneratedOperation{
+			Description: "Get the favorite pages of the user, favorited or not unless filtered",
+			Parameters:  []string{"Limit", "Offset", "FavoritesSort", "PathnameFilter", "FavoriteFilter", "IfNoneMatch"},
+			Status:      ok, Response: "FavoritePage", Envelope: list, Errors: []int{http.StatusNotModified},
+		}),
+		v2Route(http.MethodPost, "/favorite-pages", SetFavoritePageV2, openapi.GeneratedOperation{
+			From: "POST /favorite-pages", Request: "FavoritePage", Status: created, Response: "FavoritePage", Envelope: list,
+			Errors: []int{http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity},
+		}),
+		v2Route(http.MethodPatch, "/favorite-pages", MoveFavoritePages, openapi.GeneratedOperation{
+			From: "POST /favorite-pages/move", Request: "FavoritesReorderRequest", Status: ok, Response: "FavoritePage", Envelope: list,
+			Errors: []int{http.StatusPreconditionFailed},
+		}),
+		v2Route(http.MethodPut, "/favorite-pages/order", ReorderFavoritePages, openapi.GeneratedOperation{
+			From: "POST /favorite-pages/reorder", Request: "FavoritesReorderRequest", Status: ok, Response: "FavoritePage", Envelope: list,
+			Errors: []int{http.StatusPreconditionFailed},
+		}),
+		v2Route(http.MethodGet, "/favorite-pages/export", ExportFavoritePages, openapi.GeneratedOperation{
+			From: "GET /favorite-pages/export", Status: ok, Response: "FavoritesExport", Envelope: entity,
+		}),
+		v2Route(http.MethodPost, "/favorite-pages/import", ImportFavoritePages, openapi.GeneratedOperation{
+			From: "POST /favorite-pages/import", Request: "FavoritesImportRequest", Status: ok, Response: "FavoritesImportResult", Envelope: entity,
+		}),
+		v2Route(http.MethodPatch, "/favorite-pages/{favoriteId}", UpdateFavoritePage, openapi.GeneratedOperation{
+			From: "PATCH /favorite-pages/{favoriteId}", Request: "FavoritePageUpdateRequest", Status: ok, Response: "FavoritePage", Envelope: entity,
+			Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed},
+		}),
+		v2Route(http.MethodGet, "/favorite-pages/folders", GetFavoriteFolders, openapi.GeneratedOperation{
+			From: "GET /favorite-pages/folders", Status: ok, Response: "FavoriteFolder", Envelope: list,
+		}),
+		v2Route(http.MethodPost, "/favorite-pages/folders", CreateFavoriteFolderV2, openapi.GeneratedOperation{
+			From: "POST /favorite-pages/folders", Request: "FavoriteFolderRequest", Status: created, Response: "FavoriteFolder", Envelope: entity,
+		}),
+		v2Route(http.MethodPut, "/favorite-pages/folders/order", ReorderFavoriteFolders, openapi.GeneratedOperation{
+			From: "POST /favorite-pages/folders/reorder", Request: "FavoritesReorderRequest", Status: ok, Response: "FavoriteFolder", Envelope: list,
+		}),
+		v2Route(http.MethodPatch, "/favorite-pages/folders/{folderId}", RenameFavoriteFolder, openapi.GeneratedOperation{
+			From: "PATCH /favorite-pages/folders/{folderId}", Request: "FavoriteFolderRequest", Status: ok, Response: "FavoriteFolder", Envelope: entity,
+			Errors: notFound,
+		}),
+		v2Route(http.MethodDelete, "/favorite-pages/folders/{folderId}", DeleteFavoriteFolder, openapi.GeneratedOperation{
+			From: "DELETE /favorite-pages/folders/{folderId}", Status: noContent, Errors: notFound,
+		}),
+
+		// dashboard templates
+		v2Route(http.MethodGet, "/dashboard-templates", GetDashboardTemplates, openapi.GeneratedOperation{
+			Description: "List the dashboard templates of the user",
+			Parameters:  []string{"DashboardFilter", "Limit", "Offset", "IfNoneMatch"}, Status: ok, Response: "DashboardTemplate", Envelope: list,
+			Errors: []int{http.StatusNotModified},
+		}),
+		v2Route(http.MethodPost, "/dashboard-templates", CreateDashboardTemplateV2, openapi.GeneratedOperation{
+			Description: "Create a dashboard template from a base template",
+			Request:     "DashboardTemplateCreateRequest", Status: created, Response: "DashboardTemplate", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodPost, "/dashboard-templates/decode", DecodeDashboardTemplate, openapi.GeneratedOperation{
+			Description: "Decode a shared dashboard template",
+			Request:     "DashboardTemplateDecodeRequest", Status: ok, Response: "DashboardTemplate", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/dashboard-templates/base-templates", ListBaseDashboardTemplatesV2, openapi.GeneratedOperation{
+			Description: "List the base dashboard templates", Status: ok, Response: "BaseDashboardTemplate", Envelope: list,
+		}),
+		v2Route(http.MethodGet, "/dashboard-templates/base-templates/{dashboard}", GetBaseDashboardTemplateV2, openapi.GeneratedOperation{
+			Description: "Get the base template of a dashboard", Status: ok, Response: "BaseDashboardTemplate", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodGet, "/dashboard-templates/widget-mapping", GetWidgetMappings, openapi.GeneratedOperation{
+			Description: "Get the module federation metadata of the dashboard widgets", Status: ok, Response: "WidgetMapping", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/dashboard-templates/{templateId}", GetDashboardTemplateV2, openapi.GeneratedOperation{
+			Description: "Get a dashboard template of the user",
+			Parameters:  []string{"IfNoneMatch"}, Status: ok, Response: "DashboardTemplate", Envelope: entity,
+			Errors: []int{http.StatusNotModified, http.StatusNotFound},
+		}),
+		v2Route(http.MethodPatch, "/dashboard-templates/{templateId}", UpdateDashboardTemplate, openapi.GeneratedOperation{
+			Description: "Update a dashboard template of the user",
+			Parameters:  []string{"IfMatch"}, Request: "DashboardTemplateRequest", Status: ok, Response: "DashboardTemplate", Envelope: entity,
+			Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed},
+		}),
+		v2Route(http.MethodDelete, "/dashboard-templates/{templateId}", DeleteDashboardTemplate, openapi.GeneratedOperation{
+			Description: "Delete a dashboard template of the user", Status: noContent, Errors: notFound,
+		}),
+		v2Route(http.MethodPost, "/dashboard-templates/{templateId}/copies", CopyDashboardTemplateV2, openapi.GeneratedOperation{
+			Description: "Copy a dashboard template of the user", Status: created, Response: "DashboardTemplate", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodPut, "/dashboard-templates/{templateId}/default", ChangeDefaultTemplate, openapi.GeneratedOperation{
+			Description: "Make the template the default template of its dashboard",
+			Status:      ok, Response: "DashboardTemplate", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodPost, "/dashboard-templates/{templateId}/reset", ResetDashboardTemplate, openapi.GeneratedOperation{
+			Description: "Reset the layout of a dashboard template",
+			Parameters:  []string{"ResetTarget", "IfMatch"}, Status: ok, Response: "DashboardTemplate", Envelope: entity,
+			Errors: []int{http.StatusNotFound, http.StatusPreconditionFailed},
+		}),
+		v2Route(http.MethodGet, "/dashboard-templates/{templateId}/encoded", EncodeDashboardTemplate, openapi.GeneratedOperation{
+			Description: "Encode a dashboard template to share it", Status: ok, Response: "EncodedDashboardTemplate", Envelope: entity, Errors: notFound,
+		}),
+
+		// announcements
+		v2Route(http.MethodGet, "/announcements", GetAnnouncements, openapi.GeneratedOperation{
+			From: "GET /announcements", Status: ok, Response: "Announcement", Envelope: list,
+		}),
+		v2Route(http.MethodPut, "/announcements/{announcementId}/dismissal", DismissAnnouncement, openapi.GeneratedOperation{
+			From: "POST /announcements/{announcementId}/dismiss", Status: noContent, Errors: notFound,
+		}),
+		v2Route(http.MethodGet, "/announcements/admin", ListAllAnnouncements, openapi.GeneratedOperation{
+			From: "GET /announcements/admin", Status: ok, Response: "Announcement", Envelope: list,
+		}, announcementsAdmin),
+		v2Route(http.MethodPost, "/announcements/admin", CreateAnnouncement, openapi.GeneratedOperation{
+			From: "POST /announcements/admin", Request: "AnnouncementRequest", Status: created, Response: "Announcement", Envelope: entity,
+		}, announcementsAdmin),
+		v2Route(http.MethodPut, "/announcements/admin/{announcementId}", UpdateAnnouncement, openapi.GeneratedOperation{
+			From: "PUT /announcements/admin/{announcementId}", Request: "AnnouncementRequest", Status: ok, Response: "Announcement", Envelope: entity,
+			Errors: notFound,
+		}, announcementsAdmin),
+		v2Route(http.MethodDelete, "/announcements/admin/{announcementId}", DeleteAnnouncement, openapi.GeneratedOperation{
+			From: "DELETE /announcements/admin/{announcementId}", Status: noContent, Errors: notFound,
+		}, announcementsAdmin),
+
+		// notifications drawer
+		v2Route(http.MethodGet, "/notifications/drawer", GetDrawerItems, openapi.GeneratedOperation{
+			From: "GET /notifications/drawer", Status: ok, Response: "DrawerItem", Envelope: list,
+		}),
+		v2Route(http.MethodPatch, "/notifications/drawer", UpdateDrawerItemsV2, openapi.GeneratedOperation{
+			Description: "Mark drawer items as read or unread, or dismiss them. Returns the IDs of the updated items",
+			Request:     "DrawerItemsUpdateRequest", Status: ok, Response: "DrawerItemIds", Envelope: entity, Errors: notFound,
+		}),
+
+		// quickstarts
+		v2Route(http.MethodGet, "/quickstarts/progress", ListQuickstartProgress, openapi.GeneratedOperation{
+			From: "GET /quickstarts/progress", Status: ok, Response: "QuickstartProgress", Envelope: list,
+		}),
+		v2Route(http.MethodGet, "/quickstarts/summary", GetQuickstartBundleSummary, openapi.GeneratedOperation{
+			From: "GET /quickstarts/summary", Status: ok, Response: "QuickstartBundleSummary", Envelope: list,
+		}),
+		v2Route(http.MethodGet, "/quickstarts/admin/stats", GetQuickstartStats, openapi.GeneratedOperation{
+			From: "GET /quickstarts/admin/stats", Status: ok, Response: "QuickstartStats", Envelope: list,
+		}, quickstartsAdmin),
+		v2Route(http.MethodGet, "/quickstarts/progress/{quickstartId}", GetQuickstartProgress, openapi.GeneratedOperation{
+			From: "GET /quickstarts/progress/{quickstartId}", Status: ok, Response: "QuickstartProgress", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodPut, "/quickstarts/progress/{quickstartId}", SaveQuickstartProgress, openapi.GeneratedOperation{
+			From: "PUT /quickstarts/progress/{quickstartId}", Request: "QuickstartProgressRequest", Status: ok, Response: "QuickstartProgress", Envelope: entity,
+		}),
+		v2Route(http.MethodDelete, "/quickstarts/progress/{quickstartId}", ResetQuickstartProgress, openapi.GeneratedOperation{
+			From: "DELETE /quickstarts/progress/{quickstartId}", Status: noContent, Errors: notFound,
+		}),
+
+		// organization defaults
+		v2Route(http.MethodGet, "/org-defaults/dashboard-templates/{dashboard}", GetOrgDashboardTemplate, openapi.GeneratedOperation{
+			From: "GET /org-defaults/dashboard-templates/{dashboard}", Status: ok, Response: "OrgDashboardTemplate", Envelope: entity, Errors: notFound,
+		}),
+		v2Route(http.MethodPut, "/org-defaults/dashboard-templates/{dashboard}", SetOrgDashboardTemplate, openapi.GeneratedOperation{
+			From: "PUT /org-defaults/dashboard-templates/{dashboard}", Request: "DashboardTemplateConfig", Status: ok, Response: "OrgDashboardTemplate", Envelope: entity,
+		}, orgAdmin),
+		v2Route(http.MethodDelete, "/org-defaults/dashboard-templates/{dashboard}", DeleteOrgDashboardTemplate, openapi.GeneratedOperation{
+			From: "DELETE /org-defaults/dashboard-templates/{dashboard}", Status: noContent, Errors: notFound,
+		}, orgAdmin),
+		v2Route(http.MethodGet, "/org-defaults/favorites", GetOrgStarterFavorites, openapi.GeneratedOperation{
+			From: "GET /org-defaults/favorites", Status: ok, Response: "OrgStarterFavorite", Envelope: list,
+		}),
+		v2Route(http.MethodPut, "/org-defaults/favorites", SetOrgStarterFavorites, openapi.GeneratedOperation{
+			From: "PUT /org-defaults/favorites", Request: "OrgStarterFavoritesRequest", Status: ok, Response: "OrgStarterFavorite", Envelope: list,
+		}, orgAdmin),
+
+		// page load, API documents and audit trail
+		v2Route(http.MethodGet, "/bootstrap", GetBootstrap, openapi.GeneratedOperation{
+			From: "GET /bootstrap", Status: ok, Response: "Bootstrap", Envelope: openapi.RawBody,
+		}),
+		v2Route(http.MethodGet, "/api-docs", GetApiDocsV2, openapi.GeneratedOperation{
+			Description: "Get the API documents of the applications",
+			Parameters:  []string{"BundleFilter"}, Status: ok, Response: "ApiDocs", Envelope: entity,
+		}),
+		v2Route(http.MethodGet, "/audit-events", GetAuditEvents, openapi.GeneratedOperation{
+			From: "GET /audit-events", Status: ok, Response: "AuditEvent", Envelope: list,
+		}, auditAdmin),
+	}
+}
+
+// MakeV2Routes registers the v2 routes, sub is mounted at V2BasePath.
+func MakeV2Routes(sub chi.Router) {
+	for _, route := range V2Routes() {
+		sub.With(route.Middlewares...).Method(route.Method, route.Path, route.Handler)
+	}
+}
@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	m "github.com/RedHatInsights/chrome-service-backend/rest/middleware"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// v2Request runs a request against the v2 routes as the given user, requests and responses are validated
+// against the v2 document.
+func v2Request(t *testing.T, user models.UserIdentity, method string, path string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Use(validateV2Responses(t))
+	router.Route(V2BasePath, func(sub chi.Router) {
+		sub.Use(m.ValidateRequest(openApiV2Spec, config.DefaultRequestValidationMaxBodySize))
+		MakeV2Routes(sub)
+	})
+
+	request := httptest.NewRequest(method, V2BasePath+path, strings.NewReader(body))
+	xrhid := &identity.XRHID{
+		Identity: identity.Identity{
+			OrgID: "v2-routes-org",
+			User:  &identity.User{UserID: user.AccountId},
+		},
+	}
+	ctx := context.WithValue(request.Context(), util.IDENTITY_CTX_KEY, xrhid)
+	ctx = context.WithValue(ctx, util.USER_CTX_KEY, user)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request.WithContext(ctx))
+	return recorder
+}
+
+func TestV2SpecIsGenerated(t *testing.T) {
+	source, err := os.ReadFile("../../spec/openapi.yaml")
+	require.NoError(t, err)
+	components, err := os.ReadFile("../../spec/openapi-v2-components.yaml")
+	require.NoError(t, err)
+	committed, err := os.ReadFile("../../spec/openapi-v2.yaml")
+	require.NoError(t, err)
+
+	generated, err := GenerateV2Spec(source, components)
+	require.NoError(t, err)
+	assert.Equal(t, string(generated), string(committed), "spec/openapi-v2.yaml is outdated, run %s", V2SpecGenerator)
+}
+
+func TestV2Routes(t *testing.T) {
+	database.Init()
+	user := models.UserIdentity{AccountId: "v2-routes-user"}
+	require.NoError(t, database.DB.Create(&user).Error)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.SelfReport{})
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.DashboardTemplate{})
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.FavoritePage{})
+		database.DB.Unscoped().Delete(&user)
+	})
+
+	t.Run("Should wrap the user in an entity response", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodGet, "/user", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.EntityResponse[models.UserIdentityResponse]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, user.AccountId, response.Data.AccountId)
+	})
+
+	t.Run("Should update the preview settings of the user", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodPatch, "/user", `{"uiPreview": true, "uiPreviewSeen": true}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.EntityResponse[models.UserIdentityResponse]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.True(t, response.Data.UIPreview)
+		assert.True(t, response.Data.UIPreviewSeen)
+	})
+
+	t.Run("Should reject undocumented user fields", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodPatch, "/user", `{"accountId": "other"}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should wrap the self report in an entity response", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodPatch, "/self-report", `{"jobRole": "developer"}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.NotEmpty(t, recorder.Header().Get("ETag"))
+
+		recorder = v2Request(t, user, http.MethodGet, "/self-report", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var response util.EntityResponse[models.SelfReport]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, "developer", response.Data.JobRole)
+	})
+
+	t.Run("Should create dashboard templates with 201 and their location", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodPost, "/dashboard-templates", fmt.Sprintf(`{"dashboard": %q}`, models.LandingPage))
+		require.Equal(t, http.StatusCreated, recorder.Code)
+
+		var response util.EntityResponse[models.DashboardTemplate]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		location := fmt.Sprintf("%s/dashboard-templates/%d", V2BasePath, response.Data.ID)
+		assert.Equal(t, location, recorder.Header().Get("Location"))
+
+		recorder = v2Request(t, user, http.MethodGet, strings.TrimPrefix(location, V2BasePath), "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		recorder = v2Request(t, user, http.MethodPost, fmt.Sprintf("/dashboard-templates/%d/copies", response.Data.ID), "")
+		require.Equal(t, http.StatusCreated, recorder.Code)
+		assert.NotEqual(t, location, recorder.Header().Get("Location"))
+	})
+
+	t.Run("Should reject drawer updates without a state", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodPatch, "/notifications/drawer", `{"all": true}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should create favorite pages with 201", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodPost, "/favorite-pages", `{"pathname": "/insights/v2-favorite", "favorite": true}`)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+
+		var response util.ListResponse[models.FavoritePage]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "/insights/v2-favorite", response.Data[0].Pathname)
+	})
+
+	t.Run("Should list favorite pages without the v1 query parameters", func(t *testing.T) {
+		recorder := v2Request(t, user, http.MethodGet, "/favorite-pages", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response util.ListResponse[models.FavoritePage]
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Meta.Total)
+	})
+}
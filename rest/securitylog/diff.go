@@ -0,0 +1,68 @@
+package securitylog
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Change is the value of a field before and after a modification.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+func toJSONValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var converted interface{}
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil
+	}
+	return converted
+}
+
+// Diff compares the JSON representations of before and after and returns the changed fields keyed
+// by their dotted path. Nested objects are compared field by field, other values as a whole. A nil
+// side of an object counts as an empty object, values that are not objects are reported under the
+// "value" key.
+func Diff(before, after interface{}) map[string]Change {
+	changes := map[string]Change{}
+	beforeValue, afterValue := toJSONValue(before), toJSONValue(after)
+	beforeObject, beforeIsObject := beforeValue.(map[string]interface{})
+	afterObject, afterIsObject := afterValue.(map[string]interface{})
+	switch {
+	case (beforeIsObject || beforeValue == nil) && (afterIsObject || afterValue == nil):
+		diffObjects("", beforeObject, afterObject, changes)
+	case !reflect.DeepEqual(beforeValue, afterValue):
+		changes["value"] = Change{Before: beforeValue, After: afterValue}
+	}
+	return changes
+}
+
+func diffObjects(path string, before, after map[string]interface{}, changes map[string]Change) {
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	for key := range keys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		beforeObject, beforeIsObject := before[key].(map[string]interface{})
+		afterObject, afterIsObject := after[key].(map[string]interface{})
+		if beforeIsObject && afterIsObject {
+			diffObjects(fieldPath, beforeObject, afterObject, changes)
+		} else if !reflect.DeepEqual(before[key], after[key]) {
+			changes[fieldPath] = Change{Before: before[key], After: after[key]}
+		}
+	}
+}
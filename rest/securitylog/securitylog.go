@@ -7,12 +7,16 @@
 //   - resource_id:   identifier of the specific object
 //   - outcome:       "success" or "failure"
 //   - principal:     user_id and org_id extracted from request context
+//
+// Events are also handed to the registered sinks, for example to keep a queryable audit trail.
 package securitylog
 
 import (
 	"context"
+	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
 	"github.com/sirupsen/logrus"
 )
@@ -28,25 +32,61 @@ func Log(ctx context.Context, action, resourceType, resourceID, outcome string)
 // SEC-MON-REQ-1 compliance (EOI-7 invalid_login, EOI-8 authorization_failure,
 // EOI-11 warnings_or_errors)
 func LogWithReason(ctx context.Context, action, resourceType, resourceID, outcome, reason string) {
+	emit(ctx, Event{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Outcome:      outcome,
+		Reason:       reason,
+	})
+}
+
+// LogChange emits a security event for a modification together with the fields that changed
+// between before and after.
+// SEC-MON-REQ-1 compliance (EOI-1 pii_manipulation, EOI-2 system_object_manipulation)
+func LogChange(ctx context.Context, action, resourceType, resourceID, outcome string, before, after interface{}) {
+	LogChangeWithReason(ctx, action, resourceType, resourceID, outcome, "", before, after)
+}
+
+// LogChangeWithReason emits a modification event like LogChange with an additional reason field,
+// for example the justification of an admin action.
+// SEC-MON-REQ-1 compliance (EOI-1 pii_manipulation, EOI-3 admin_action)
+func LogChangeWithReason(ctx context.Context, action, resourceType, resourceID, outcome, reason string, before, after interface{}) {
+	emit(ctx, Event{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Outcome:      outcome,
+		Reason:       reason,
+		Diff:         Diff(before, after),
+	})
+}
+
+func emit(ctx context.Context, event Event) {
+	event.Time = time.Now()
+	addEventPrincipal(ctx, &event)
+
 	fields := logrus.Fields{
 		"event":         "security",
-		"action":        action,
-		"resource_type": resourceType,
-		"resource_id":   resourceID,
-		"outcome":       outcome,
+		"action":        event.Action,
+		"resource_type": event.ResourceType,
+		"resource_id":   event.ResourceID,
+		"outcome":       event.Outcome,
 	}
 
-	if reason != "" {
-		fields["reason"] = reason
+	if event.Reason != "" {
+		fields["reason"] = event.Reason
 	}
 
 	addPrincipal(ctx, fields)
 
-	if outcome == "failure" {
+	if event.Outcome == "failure" {
 		logrus.WithFields(fields).Warn("security_event")
 	} else {
 		logrus.WithFields(fields).Info("security_event")
 	}
+
+	writeToSinks(ctx, event)
 }
 
 // LogStartup emits a process startup event.
@@ -76,16 +116,29 @@ func LogShutdown(serviceName, reason string) {
 }
 
 // addPrincipal extracts user_id and org_id from the request context and adds
-// them to the log fields. When identity is not available (e.g. unauthenticated
-// requests), the principal fields are omitted.
+// them to the log fields, associates and service accounts are logged with their
+// principal ID. When identity is not available (e.g. unauthenticated requests),
+// the principal fields are omitted.
 func addPrincipal(ctx context.Context, fields logrus.Fields) {
 	if ctx == nil {
 		return
 	}
 	if id, ok := ctx.Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil {
-		if id.Identity.User != nil {
-			fields["user_id"] = id.Identity.User.UserID
+		if principal := util.PrincipalID(id); principal != "" {
+			fields["user_id"] = principal
 		}
 		fields["org_id"] = id.Identity.OrgID
 	}
 }
+
+// addEventPrincipal copies the principal and the request ID of the request context to the event.
+func addEventPrincipal(ctx context.Context, event *Event) {
+	if ctx == nil {
+		return
+	}
+	event.RequestID = middleware.GetReqID(ctx)
+	if id, ok := ctx.Value(util.IDENTITY_CTX_KEY).(*identity.XRHID); ok && id != nil {
+		event.UserID = util.PrincipalID(id)
+		event.OrgID = id.Identity.OrgID
+	}
+}
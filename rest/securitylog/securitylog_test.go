@@ -112,6 +112,22 @@ func TestLogWithPrincipal(t *testing.T) {
 	assert.Contains(t, output, "org-123")
 }
 
+func TestLogAssociatePrincipal(t *testing.T) {
+	ctx := context.WithValue(context.Background(), util.IDENTITY_CTX_KEY, &identity.XRHID{
+		Identity: identity.Identity{
+			Type:      "Associate",
+			Associate: &identity.Associate{Email: "support@redhat.com"},
+		},
+	})
+
+	output := captureOutput(func() {
+		LogWithReason(ctx, "READ", "user_identity", "user-456", "success", "ticket 42")
+	})
+
+	assert.Contains(t, output, "user_id=support@redhat.com")
+	assert.Contains(t, output, "ticket 42")
+}
+
 func TestLogServiceAccountIdentity(t *testing.T) {
 	// Service accounts have nil User — must not panic
 	ctx := context.WithValue(context.Background(), util.IDENTITY_CTX_KEY, &identity.XRHID{
@@ -0,0 +1,60 @@
+package securitylog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Event is a security event as it is handed to the sinks.
+type Event struct {
+	Time         time.Time
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Outcome      string
+	Reason       string
+	UserID       string
+	OrgID        string
+	RequestID    string
+	// Diff lists the changed fields of modifications logged with LogChange, nil otherwise
+	Diff map[string]Change
+}
+
+// Sink receives every security event in addition to the log output. Sinks are called synchronously
+// from the request that emitted the event, so they should not block on I/O. A failing sink does not
+// fail the request.
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+var (
+	sinksMu sync.RWMutex
+	sinks   []Sink
+)
+
+// RegisterSink adds a sink receiving every following security event.
+func RegisterSink(sink Sink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append(sinks, sink)
+}
+
+// ResetSinks removes every registered sink.
+func ResetSinks() {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = nil
+}
+
+func writeToSinks(ctx context.Context, event Event) {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			logrus.Errorf("Unable to write %s %s security event to sink: %v", event.Action, event.ResourceType, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package securitylog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	events []Event
+	err    error
+}
+
+func (rs *recordingSink) Write(ctx context.Context, event Event) error {
+	rs.events = append(rs.events, event)
+	return rs.err
+}
+
+func registerRecordingSink(t *testing.T) *recordingSink {
+	t.Helper()
+	sink := &recordingSink{}
+	RegisterSink(sink)
+	t.Cleanup(ResetSinks)
+	return sink
+}
+
+func TestSinkReceivesEvents(t *testing.T) {
+	sink := registerRecordingSink(t)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "request-1")
+	ctx = context.WithValue(ctx, util.IDENTITY_CTX_KEY, &identity.XRHID{
+		Identity: identity.Identity{
+			OrgID: "org-123",
+			User:  &identity.User{UserID: "user-456"},
+		},
+	})
+
+	captureOutput(func() {
+		LogWithReason(ctx, "DELETE", "favorite_folder", "7", "failure", "delete failed")
+	})
+
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, "DELETE", event.Action)
+	assert.Equal(t, "favorite_folder", event.ResourceType)
+	assert.Equal(t, "7", event.ResourceID)
+	assert.Equal(t, "failure", event.Outcome)
+	assert.Equal(t, "delete failed", event.Reason)
+	assert.Equal(t, "user-456", event.UserID)
+	assert.Equal(t, "org-123", event.OrgID)
+	assert.Equal(t, "request-1", event.RequestID)
+	assert.False(t, event.Time.IsZero())
+	assert.Nil(t, event.Diff)
+}
+
+func TestSinkErrorsDoNotStopLogging(t *testing.T) {
+	failing := registerRecordingSink(t)
+	failing.err = errors.New("database unavailable")
+	sink := registerRecordingSink(t)
+
+	output := captureOutput(func() {
+		Log(context.Background(), "CREATE", "dashboard_template", "1", "success")
+	})
+
+	assert.Contains(t, output, "database unavailable")
+	assert.Len(t, sink.events, 1)
+}
+
+func TestLogChange(t *testing.T) {
+	sink := registerRecordingSink(t)
+	type selfReport struct {
+		JobRole string   `json:"jobRole"`
+		Topics  []string `json:"topics"`
+	}
+
+	output := captureOutput(func() {
+		LogChange(context.Background(), "UPDATE", "self_report", "user1", "success",
+			selfReport{JobRole: "developer", Topics: []string{"a"}},
+			selfReport{JobRole: "admin", Topics: []string{"a"}})
+	})
+
+	assert.Contains(t, output, "security_event")
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, map[string]Change{"jobRole": {Before: "developer", After: "admin"}}, sink.events[0].Diff)
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("should report nested fields by path", func(t *testing.T) {
+		before := map[string]interface{}{"config": map[string]interface{}{"sm": []int{1}, "lg": []int{2}}, "name": "landing"}
+		after := map[string]interface{}{"config": map[string]interface{}{"sm": []int{1}, "lg": []int{3}}, "name": "landing"}
+		assert.Equal(t, map[string]Change{
+			"config.lg": {Before: []interface{}{float64(2)}, After: []interface{}{float64(3)}},
+		}, Diff(before, after))
+	})
+
+	t.Run("should report added and removed fields", func(t *testing.T) {
+		assert.Equal(t, map[string]Change{
+			"a": {Before: "1", After: nil},
+			"b": {Before: nil, After: "2"},
+		}, Diff(map[string]string{"a": "1"}, map[string]string{"b": "2"}))
+		assert.Equal(t, map[string]Change{"a": {Before: nil, After: "1"}}, Diff(nil, map[string]string{"a": "1"}))
+	})
+
+	t.Run("should compare other values as a whole", func(t *testing.T) {
+		assert.Equal(t, map[string]Change{"value": {Before: "a", After: "b"}}, Diff("a", "b"))
+		assert.Empty(t, Diff([]string{"a"}, []string{"a"}))
+	})
+}
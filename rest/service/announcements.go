@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/connectionhub"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	AnnouncementsEventType   = "com.redhat.console.chrome.announcements.updated"
+	announcementsEventSource = "https://console.redhat.com/api/chrome-service/v1/announcements"
+)
+
+// AnnouncementAudience describes the user requesting announcements.
+type AnnouncementAudience struct {
+	User   models.UserIdentity
+	OrgID  string
+	Bundle string
+	// Entitlements lists the services the organization is entitled to
+	Entitlements []string
+}
+
+func matchesAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if slices.Contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnnouncementAudience checks the announcement targeting. Bundle targeting is only applied
+// when the audience requests announcements of a single bundle.
+func matchesAnnouncementAudience(announcement models.Announcement, audience AnnouncementAudience) bool {
+	targeting := announcement.Targeting.Data()
+	if len(targeting.OrgIds) > 0 && !slices.Contains(targeting.OrgIds, audience.OrgID) {
+		return false
+	}
+	if len(targeting.Bundles) > 0 && audience.Bundle != "" && !slices.Contains(targeting.Bundles, audience.Bundle) {
+		return false
+	}
+	if len(targeting.Entitlements) > 0 && !matchesAny(targeting.Entitlements, audience.Entitlements) {
+		return false
+	}
+	if targeting.FeatureFlag != "" && !IsFeatureFlagEnabledForUser(audience.User, targeting.FeatureFlag) {
+		return false
+	}
+	return true
+}
+
+// GetUserAnnouncements returns the active announcements targeting the audience the user has not dismissed.
+func GetUserAnnouncements(audience AnnouncementAudience) ([]models.Announcement, error) {
+	now := time.Now()
+	var candidates []models.Announcement
+	err := database.DB.
+		Where("start_time IS NULL OR start_time <= ?", now).
+		Where("end_time IS NULL OR end_time > ?", now).
+		Where("id NOT IN (?)", database.DB.Model(&models.AnnouncementDismissal{}).Select("announcement_id").Where("user_identity_id = ?", audience.User.ID)).
+		Order("start_time DESC, id DESC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := []models.Announcement{}
+	for _, announcement := range candidates {
+		if announcement.IsActive(now) && matchesAnnouncementAudience(announcement, audience) {
+			announcements = append(announcements, announcement)
+		}
+	}
+	return announcements, nil
+}
+
+// DismissAnnouncement hides an announcement for the user. Dismissing twice is not an error.
+func DismissAnnouncement(userID uint, announcementID uint) error {
+	var announcement models.Announcement
+	result := database.DB.Find(&announcement, announcementID)
+	if result.RowsAffected == 0 || result.Error != nil {
+		return gorm.ErrRecordNotFound
+	}
+	if !announcement.Dismissible {
+		return fmt.Errorf("%w: announcement %d cannot be dismissed", util.ErrBadRequest, announcementID)
+	}
+
+	dismissal := models.AnnouncementDismissal{AnnouncementID: announcementID, UserIdentityID: userID}
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&dismissal).Error
+}
+
+// ListAnnouncements returns every announcement, including the scheduled and expired ones.
+func ListAnnouncements() ([]models.Announcement, error) {
+	announcements := []models.Announcement{}
+	err := database.DB.Order("id DESC").Find(&announcements).Error
+	return announcements, err
+}
+
+func applyAnnouncementRequest(announcement *models.Announcement, request models.AnnouncementRequest) error {
+	announcement.Title = request.Title
+	announcement.Content = request.Content
+	announcement.Severity = request.Severity
+	if announcement.Severity == "" {
+		announcement.Severity = models.AnnouncementInfo
+	}
+	announcement.StartTime = request.StartTime
+	announcement.EndTime = request.EndTime
+	announcement.Dismissible = request.Dismissible == nil || *request.Dismissible
+	announcement.Targeting = datatypes.NewJSONType(request.Targeting)
+	if err := announcement.IsValid(); err != nil {
+		return fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+	return nil
+}
+
+func CreateAnnouncement(request models.AnnouncementRequest, createdBy string) (models.Announcement, error) {
+	announcement := models.Announcement{CreatedBy: createdBy}
+	if err := applyAnnouncementRequest(&announcement, request); err != nil {
+		return announcement, err
+	}
+	if err := database.DB.Create(&announcement).Error; err != nil {
+		return announcement, err
+	}
+	notifyAnnouncementChange(announcement)
+	return announcement, nil
+}
+
+func UpdateAnnouncement(id uint, request models.AnnouncementRequest) (models.Announcement, error) {
+	var announcement models.Announcement
+	result := database.DB.Find(&announcement, id)
+	if result.RowsAffected == 0 || result.Error != nil {
+		return announcement, gorm.ErrRecordNotFound
+	}
+	if err := applyAnnouncementRequest(&announcement, request); err != nil {
+		return announcement, err
+	}
+	if err := database.DB.Save(&announcement).Error; err != nil {
+		return announcement, err
+	}
+	notifyAnnouncementChange(announcement)
+	return announcement, nil
+}
+
+// DeleteAnnouncement removes the announcement and its dismissals.
+func DeleteAnnouncement(id uint) error {
+	var announcement models.Announcement
+	result := database.DB.Find(&announcement, id)
+	if result.RowsAffected == 0 || result.Error != nil {
+		return gorm.ErrRecordNotFound
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("announcement_id = ?", id).Delete(&models.AnnouncementDismissal{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&announcement).Error
+	})
+	if err == nil {
+		notifyAnnouncementChange(announcement)
+	}
+	return err
+}
+
+// notifyAnnouncementChange tells the connected clients of the targeted organizations to reload their
+// announcements. The content is not pushed, so targeting that depends on the request (bundle,
+// entitlements, flags) is still evaluated by GetUserAnnouncements. Websocket events are never
+// broadcast, the event of an announcement without targeted organizations has no organizations and
+// every replica sends it to the organizations connected to it.
+func notifyAnnouncementChange(announcement models.Announcement) {
+	message := connectionhub.WsMessage{
+		Organizations: announcement.Targeting.Data().OrgIds,
+		Payload:       map[string]interface{}{"announcementId": announcement.ID},
+	}
+	if err := publishEvent(announcementsEventSource, AnnouncementsEventType, message); err != nil {
+		logrus.Errorf("Unable to notify announcement %d change: %v", announcement.ID, err)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditEventsListOptions are the pagination, sort and filter params of the audit trail. The newest
+// events come first.
+var AuditEventsListOptions = util.ListQueryOptions{
+	DefaultLimit: 50,
+	MaxLimit:     500,
+	SortFields: map[string]string{
+		"created": "created_at",
+	},
+	DefaultSort: "-created",
+	TieBreaker:  "id",
+	Filters: map[string]util.ListFilter{
+		"action":       util.StringFilter("action"),
+		"resourceType": util.StringFilter("resource_type"),
+		"resourceId":   util.StringFilter("resource_id"),
+		"outcome":      util.StringFilter("outcome"),
+		"userId":       util.StringFilter("user_id"),
+		"orgId":        util.StringFilter("org_id"),
+		"requestId":    util.StringFilter("request_id"),
+	},
+}
+
+// AuditEventsRange limits the audit trail to the events created in [Since, Until), zero times are
+// not applied.
+type AuditEventsRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ListAuditEvents returns a page of the audit trail and the number of matching events.
+func ListAuditEvents(query util.ListQuery, timeRange AuditEventsRange) ([]models.AuditEvent, int, error) {
+	db := database.DB.Model(&models.AuditEvent{})
+	if !timeRange.Since.IsZero() {
+		db = db.Where("created_at >= ?", timeRange.Since)
+	}
+	if !timeRange.Until.IsZero() {
+		db = db.Where("created_at < ?", timeRange.Until)
+	}
+	return util.FindPage[models.AuditEvent](db, query)
+}
+
+// RemoveExpiredAuditEvents deletes the audit events created before the retention cutoff.
+func RemoveExpiredAuditEvents(cutoff time.Time) (int64, error) {
+	result := database.DB.Where("created_at < ?", cutoff).Delete(&models.AuditEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// RunAuditRetention removes the audit events older than retention on every interval, it does not return.
+func RunAuditRetention(interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if removed, err := RemoveExpiredAuditEvents(now.Add(-retention)); err != nil {
+			logrus.Errorf("Unable to remove expired audit events: %v", err)
+		} else if removed > 0 {
+			logrus.Debugf("Removed %d expired audit events", removed)
+		}
+	}
+}
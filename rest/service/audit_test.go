@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/securitylog"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuditEvents(t *testing.T, events ...securitylog.Event) {
+	t.Helper()
+	database.Init()
+	for _, event := range events {
+		require.NoError(t, database.AuditSink{}.Write(context.Background(), event))
+	}
+	t.Cleanup(func() {
+		database.DB.Where("org_id = ?", "audit-org").Delete(&models.AuditEvent{})
+	})
+}
+
+func listAuditEvents(t *testing.T, query string, timeRange AuditEventsRange) ([]models.AuditEvent, int) {
+	t.Helper()
+	values, err := url.ParseQuery(query + "&orgId=audit-org")
+	require.NoError(t, err)
+	listQuery, err := util.ParseListQuery(values, AuditEventsListOptions)
+	require.NoError(t, err)
+	events, total, err := ListAuditEvents(listQuery, timeRange)
+	require.NoError(t, err)
+	return events, total
+}
+
+func TestListAuditEvents(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	setupAuditEvents(t,
+		securitylog.Event{Time: now.Add(-2 * time.Hour), Action: "CREATE", ResourceType: "dashboard_template", ResourceID: "1", Outcome: "success", UserID: "user-1", OrgID: "audit-org"},
+		securitylog.Event{Time: now.Add(-time.Hour), Action: "UPDATE", ResourceType: "dashboard_template", ResourceID: "1", Outcome: "success", UserID: "user-1", OrgID: "audit-org", RequestID: "request-1",
+			Diff: map[string]securitylog.Change{"name": {Before: "a", After: "b"}}},
+		securitylog.Event{Time: now, Action: "DELETE", ResourceType: "favorite_folder", ResourceID: "2", Outcome: "failure", Reason: "delete failed", UserID: "user-2", OrgID: "audit-org"},
+	)
+
+	t.Run("should return the newest events first", func(t *testing.T) {
+		events, total := listAuditEvents(t, "", AuditEventsRange{})
+		assert.Equal(t, 3, total)
+		require.Len(t, events, 3)
+		assert.Equal(t, []string{"DELETE", "UPDATE", "CREATE"}, []string{events[0].Action, events[1].Action, events[2].Action})
+		assert.Equal(t, "delete failed", events[0].Reason)
+	})
+
+	t.Run("should keep the diff", func(t *testing.T) {
+		events, _ := listAuditEvents(t, "requestId=request-1", AuditEventsRange{})
+		require.Len(t, events, 1)
+		var diff map[string]securitylog.Change
+		require.NoError(t, json.Unmarshal(events[0].Diff, &diff))
+		assert.Equal(t, map[string]securitylog.Change{"name": {Before: "a", After: "b"}}, diff)
+	})
+
+	t.Run("should filter events", func(t *testing.T) {
+		events, total := listAuditEvents(t, "resourceType=dashboard_template&resourceId=1&userId=user-1", AuditEventsRange{})
+		assert.Equal(t, 2, total)
+		assert.Len(t, events, 2)
+
+		_, total = listAuditEvents(t, "outcome=failure", AuditEventsRange{})
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("should filter by time range", func(t *testing.T) {
+		events, total := listAuditEvents(t, "", AuditEventsRange{Since: now.Add(-time.Hour), Until: now})
+		assert.Equal(t, 1, total)
+		require.Len(t, events, 1)
+		assert.Equal(t, "UPDATE", events[0].Action)
+	})
+
+	t.Run("should paginate events", func(t *testing.T) {
+		events, total := listAuditEvents(t, "limit=2&offset=2", AuditEventsRange{})
+		assert.Equal(t, 3, total)
+		require.Len(t, events, 1)
+		assert.Equal(t, "CREATE", events[0].Action)
+	})
+}
+
+func TestRemoveExpiredAuditEvents(t *testing.T) {
+	now := time.Now().UTC()
+	setupAuditEvents(t,
+		securitylog.Event{Time: now.Add(-48 * time.Hour), Action: "CREATE", ResourceType: "dashboard_template", Outcome: "success", OrgID: "audit-org"},
+		securitylog.Event{Time: now, Action: "UPDATE", ResourceType: "dashboard_template", Outcome: "success", OrgID: "audit-org"},
+	)
+
+	removed, err := RemoveExpiredAuditEvents(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, removed, int64(1))
+
+	events, total := listAuditEvents(t, "", AuditEventsRange{})
+	assert.Equal(t, 1, total)
+	require.Len(t, events, 1)
+	assert.Equal(t, "UPDATE", events[0].Action)
+}
+
+func TestAsyncAuditSink(t *testing.T) {
+	setupAuditEvents(t)
+	sink := database.NewAsyncAuditSink(database.AuditSinkOptions{BatchSize: 2, FlushInterval: time.Hour})
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, sink.Write(context.Background(), securitylog.Event{
+			Time: time.Now(), Action: "AUTHENTICATE", ResourceType: "api_request", ResourceID: id, Outcome: "failure", OrgID: "audit-org",
+		}))
+	}
+
+	// the last event does not fill a batch, it is written when the sink is closed
+	sink.Close()
+	_, total := listAuditEvents(t, "action=AUTHENTICATE", AuditEventsRange{})
+	assert.Equal(t, 3, total)
+}
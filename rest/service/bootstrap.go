@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+)
+
+// BootstrapField is a section of the bootstrap document.
+type BootstrapField string
+
+const (
+	BootstrapUser                   BootstrapField = "user"
+	BootstrapFavoritePages          BootstrapField = "favoritePages"
+	BootstrapLastVisited            BootstrapField = "lastVisited"
+	BootstrapRecentlyUsedWorkspaces BootstrapField = "recentlyUsedWorkspaces"
+	BootstrapDashboardTemplates     BootstrapField = "dashboardTemplates"
+	BootstrapVisitedBundles         BootstrapField = "visitedBundles"
+	BootstrapIntercom               BootstrapField = "intercom"
+)
+
+// BootstrapFields are every section of the bootstrap document, returned when no fields are selected.
+var BootstrapFields = []BootstrapField{
+	BootstrapUser,
+	BootstrapFavoritePages,
+	BootstrapLastVisited,
+	BootstrapRecentlyUsedWorkspaces,
+	BootstrapDashboardTemplates,
+	BootstrapVisitedBundles,
+	BootstrapIntercom,
+}
+
+// ParseBootstrapFields reads the comma separated fields selector. An empty selector selects every section.
+func ParseBootstrapFields(param string) ([]BootstrapField, error) {
+	if strings.TrimSpace(param) == "" {
+		return BootstrapFields, nil
+	}
+
+	fields := []BootstrapField{}
+	for _, name := range strings.Split(param, ",") {
+		field := BootstrapField(strings.TrimSpace(name))
+		if !slices.Contains(BootstrapFields, field) {
+			return nil, fmt.Errorf("%w: invalid bootstrap field %q, expected one of %s", util.ErrBadRequest, field, joinBootstrapFields())
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func joinBootstrapFields() string {
+	names := make([]string, len(BootstrapFields))
+	for i, field := range BootstrapFields {
+		names[i] = string(field)
+	}
+	return strings.Join(names, ", ")
+}
+
+// BootstrapOptions are the selected sections and the request data some of them need.
+type BootstrapOptions struct {
+	Fields         []BootstrapField
+	OrgID          string
+	IdentityHeader string
+	// Dashboard limits the dashboard templates to one dashboard, forking it when the user has none yet
+	Dashboard    models.AvailableTemplates
+	IntercomApps []IntercomApp
+}
+
+// BootstrapResponse holds every section that loaded. A failed section is reported in Errors and does
+// not fail the other ones.
+type BootstrapResponse struct {
+	Data   map[BootstrapField]interface{}   `json:"data"`
+	Errors map[BootstrapField]*util.Problem `json:"errors,omitempty"`
+}
+
+type bootstrapLoader func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error)
+
+var bootstrapLoaders = map[BootstrapField]bootstrapLoader{
+	BootstrapUser: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		return GetUserIdentityResponse(user, time.Now())
+	},
+	BootstrapFavoritePages: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		pages, err := GetUserActiveFavoritePages(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return ResolveFavoritePages(pages), nil
+	},
+	BootstrapLastVisited: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		pages := slices.Clone(user.LastVisitedPages.Data())
+		if pages == nil {
+			pages = []models.VisitedPage{}
+		}
+		return pages, nil
+	},
+	BootstrapRecentlyUsedWorkspaces: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		workspaces, _, err := RefreshRecentlyUsedWorkspaces(ctx, &user, options.IdentityHeader)
+		if err != nil {
+			return nil, err
+		}
+		if workspaces == nil {
+			workspaces = []models.Workspace{}
+		}
+		return workspaces, nil
+	},
+	BootstrapDashboardTemplates: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		return GetDashboardTemplate(user.ID, options.OrgID, options.Dashboard)
+	},
+	BootstrapVisitedBundles: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		bundles, err := GetVisitedBundles(user)
+		if bundles == nil {
+			bundles = map[string]bool{}
+		}
+		return bundles, err
+	},
+	BootstrapIntercom: func(ctx context.Context, user models.UserIdentity, options BootstrapOptions) (interface{}, error) {
+		return GetUserIntercomHashes(user.AccountId, options.IntercomApps)
+	},
+}
+
+// loadBootstrapSection loads one section, turning panics into errors so they stay isolated.
+func loadBootstrapSection(ctx context.Context, field BootstrapField, user models.UserIdentity, options BootstrapOptions) (data interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("bootstrap section %s panicked: %v", field, rec)
+		}
+	}()
+	return bootstrapLoaders[field](ctx, user, options)
+}
+
+// Bootstrap assembles the selected sections concurrently. Every section gets its own copy of the user.
+func Bootstrap(ctx context.Context, user models.UserIdentity, options BootstrapOptions) BootstrapResponse {
+	response := BootstrapResponse{
+		Data:   make(map[BootstrapField]interface{}, len(options.Fields)),
+		Errors: make(map[BootstrapField]*util.Problem),
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, field := range options.Fields {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := loadBootstrapSection(ctx, field, user, options)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				problem := util.ProblemFromError(err)
+				if problem.Status >= 500 {
+					logrus.Errorf("Unable to load the bootstrap section %s of user %s: %v", field, user.AccountId, err)
+				}
+				response.Errors[field] = problem
+				return
+			}
+			response.Data[field] = data
+		}()
+	}
+	wg.Wait()
+
+	if len(response.Errors) == 0 {
+		response.Errors = nil
+	}
+	return response
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBootstrapFields(t *testing.T) {
+	t.Run("should select every field when empty", func(t *testing.T) {
+		fields, err := ParseBootstrapFields("")
+		require.NoError(t, err)
+		assert.Equal(t, BootstrapFields, fields)
+	})
+
+	t.Run("should remove duplicate fields", func(t *testing.T) {
+		fields, err := ParseBootstrapFields("user, lastVisited,user")
+		require.NoError(t, err)
+		assert.Equal(t, []BootstrapField{BootstrapUser, BootstrapLastVisited}, fields)
+	})
+
+	t.Run("should reject unknown fields", func(t *testing.T) {
+		_, err := ParseBootstrapFields("user,nope")
+		assert.ErrorContains(t, err, `invalid bootstrap field "nope"`)
+	})
+}
+
+func TestBootstrap(t *testing.T) {
+	user := setupTestUser(t)
+
+	t.Run("should load the selected sections", func(t *testing.T) {
+		response := Bootstrap(context.Background(), *user, BootstrapOptions{
+			Fields: []BootstrapField{BootstrapUser, BootstrapLastVisited, BootstrapVisitedBundles},
+		})
+
+		assert.Nil(t, response.Errors)
+		assert.Len(t, response.Data, 3)
+		identity, ok := response.Data[BootstrapUser].(models.UserIdentityResponse)
+		require.True(t, ok)
+		assert.Equal(t, testAccountID, identity.AccountId)
+		assert.Equal(t, []models.VisitedPage{}, response.Data[BootstrapLastVisited])
+		assert.NotContains(t, response.Data, BootstrapFavoritePages)
+	})
+
+	t.Run("should isolate failed sections", func(t *testing.T) {
+		response := Bootstrap(context.Background(), *user, BootstrapOptions{
+			Fields:    []BootstrapField{BootstrapUser, BootstrapDashboardTemplates},
+			Dashboard: models.AvailableTemplates("nope"),
+		})
+
+		assert.Contains(t, response.Data, BootstrapUser)
+		assert.NotContains(t, response.Data, BootstrapDashboardTemplates)
+		require.Contains(t, response.Errors, BootstrapDashboardTemplates)
+		assert.NotNil(t, response.Errors[BootstrapDashboardTemplates])
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+)
+
+type BundleVisitsSort string
+
+const (
+	BundleVisitsRecent   BundleVisitsSort = "recent"
+	BundleVisitsFrequent BundleVisitsSort = "frequent"
+)
+
+func (bs BundleVisitsSort) IsValid() error {
+	switch bs {
+	case BundleVisitsRecent, BundleVisitsFrequent:
+		return nil
+	}
+
+	return fmt.Errorf("%w: invalid visited bundles sort. Expected one of %s, %s, got %s", util.ErrBadRequest, BundleVisitsRecent, BundleVisitsFrequent, bs)
+}
+
+// parseBundleVisits returns a copy of the visit history of the user that is safe to change, the stored
+// map is shared with the cached identity. Users that were not migrated yet get their history seeded
+// from the legacy visited bundles map.
+func parseBundleVisits(user models.UserIdentity) (map[string]models.BundleVisit, error) {
+	if visits := user.BundleVisits.Data(); len(visits) > 0 {
+		return maps.Clone(visits), nil
+	}
+
+	visits := make(map[string]models.BundleVisit)
+	bundles, err := parseUserBundles(user)
+	if err != nil {
+		return visits, err
+	}
+	for bundle, visited := range bundles {
+		if visited {
+			visits[bundle] = models.BundleVisit{Bundle: bundle, VisitCount: 1}
+		}
+	}
+	return visits, nil
+}
+
+// recordBundleVisit updates the visit history entry of a bundle.
+func recordBundleVisit(visits map[string]models.BundleVisit, bundle string, now time.Time) models.BundleVisit {
+	visit, ok := visits[bundle]
+	if !ok {
+		visit = models.BundleVisit{Bundle: bundle}
+	}
+
+	visit.ReturningAfterDays = 0
+	if visit.LastVisit != nil {
+		visit.ReturningAfterDays = int(now.Sub(*visit.LastVisit).Hours() / 24)
+	}
+	if visit.FirstVisit == nil {
+		visit.FirstVisit = &now
+	}
+	visit.LastVisit = &now
+	visit.VisitCount++
+	visits[bundle] = visit
+	return visit
+}
+
+// lastVisitBefore reports whether visit a happened before visit b. Visits without a timestamp are the oldest.
+func lastVisitBefore(a models.BundleVisit, b models.BundleVisit) bool {
+	if a.LastVisit == nil {
+		return b.LastVisit != nil
+	}
+	if b.LastVisit == nil {
+		return false
+	}
+	return a.LastVisit.Before(*b.LastVisit)
+}
+
+func sortBundleVisits(visits []models.BundleVisit, order BundleVisitsSort) {
+	sort.SliceStable(visits, func(i, j int) bool {
+		if order == BundleVisitsFrequent && visits[i].VisitCount != visits[j].VisitCount {
+			return visits[i].VisitCount > visits[j].VisitCount
+		}
+		if lastVisitBefore(visits[j], visits[i]) {
+			return true
+		}
+		if lastVisitBefore(visits[i], visits[j]) {
+			return false
+		}
+		return visits[i].Bundle < visits[j].Bundle
+	})
+}
+
+// GetBundleVisits returns the visited bundles history ordered by recency or frequency.
+// A limit lower than one returns all bundles.
+func GetBundleVisits(user models.UserIdentity, order BundleVisitsSort, limit int) ([]models.BundleVisit, error) {
+	if err := order.IsValid(); err != nil {
+		return nil, err
+	}
+
+	visitsMap, err := parseBundleVisits(user)
+	if err != nil {
+		return nil, err
+	}
+
+	visits := make([]models.BundleVisit, 0, len(visitsMap))
+	for _, visit := range visitsMap {
+		visits = append(visits, visit)
+	}
+	sortBundleVisits(visits, order)
+
+	if limit > 0 && len(visits) > limit {
+		visits = visits[:limit]
+	}
+	return visits, nil
+}
+
+// GetBundleVisitsSummary returns the signals used by the landing page and onboarding flows.
+func GetBundleVisitsSummary(user models.UserIdentity) (models.BundleVisitsSummary, error) {
+	summary := models.BundleVisitsSummary{}
+	frequent, err := GetBundleVisits(user, BundleVisitsFrequent, 0)
+	if err != nil || len(frequent) == 0 {
+		return summary, err
+	}
+
+	summary.BundleCount = len(frequent)
+	for _, visit := range frequent {
+		summary.TotalVisits += visit.VisitCount
+	}
+	summary.MostUsedBundle = &frequent[0]
+
+	recent, err := GetBundleVisits(user, BundleVisitsRecent, 1)
+	if err != nil {
+		return summary, err
+	}
+	summary.MostRecentBundle = &recent[0]
+	summary.ReturningAfterDays = recent[0].ReturningAfterDays
+	return summary, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func TestParseBundleVisits(t *testing.T) {
+	t.Run("Should seed history from legacy visited bundles", func(t *testing.T) {
+		user := models.UserIdentity{VisitedBundles: datatypes.JSON(`{"insights": true, "openshift": false}`)}
+		visits, err := parseBundleVisits(user)
+		require.NoError(t, err)
+		assert.Len(t, visits, 1)
+		assert.Equal(t, 1, visits["insights"].VisitCount)
+		assert.Nil(t, visits["insights"].LastVisit)
+	})
+}
+
+func TestRecordBundleVisit(t *testing.T) {
+	visits := map[string]models.BundleVisit{}
+	firstVisit := time.Now().Add(-time.Hour * 24 * 10)
+	recordBundleVisit(visits, "insights", firstVisit)
+	visit := recordBundleVisit(visits, "insights", time.Now())
+
+	assert.Equal(t, 2, visit.VisitCount)
+	assert.Equal(t, 10, visit.ReturningAfterDays)
+	assert.True(t, visit.FirstVisit.Equal(firstVisit))
+	assert.True(t, visit.LastVisit.After(firstVisit))
+}
+
+func TestGetBundleVisits(t *testing.T) {
+	now := time.Now()
+	yesterday := now.Add(-time.Hour * 24)
+	user := models.UserIdentity{
+		BundleVisits: datatypes.NewJSONType(map[string]models.BundleVisit{
+			"insights":  {Bundle: "insights", LastVisit: &yesterday, VisitCount: 5},
+			"openshift": {Bundle: "openshift", LastVisit: &now, VisitCount: 2},
+			"legacy":    {Bundle: "legacy", VisitCount: 1},
+		}),
+	}
+
+	t.Run("Should sort by recency", func(t *testing.T) {
+		visits, err := GetBundleVisits(user, BundleVisitsRecent, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"openshift", "insights", "legacy"}, []string{visits[0].Bundle, visits[1].Bundle, visits[2].Bundle})
+	})
+
+	t.Run("Should sort by frequency and apply limit", func(t *testing.T) {
+		visits, err := GetBundleVisits(user, BundleVisitsFrequent, 2)
+		require.NoError(t, err)
+		assert.Len(t, visits, 2)
+		assert.Equal(t, "insights", visits[0].Bundle)
+		assert.Equal(t, "openshift", visits[1].Bundle)
+	})
+
+	t.Run("Should reject unknown sort", func(t *testing.T) {
+		_, err := GetBundleVisits(user, "alphabetical", 0)
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+
+	t.Run("Should summarize visits", func(t *testing.T) {
+		summary, err := GetBundleVisitsSummary(user)
+		require.NoError(t, err)
+		assert.Equal(t, 3, summary.BundleCount)
+		assert.Equal(t, 8, summary.TotalVisits)
+		assert.Equal(t, "insights", summary.MostUsedBundle.Bundle)
+		assert.Equal(t, "openshift", summary.MostRecentBundle.Bundle)
+	})
+}
+
+func TestAddVisitedBundleHistory(t *testing.T) {
+	user := models.UserIdentity{AccountId: "bundle-visits-user", VisitedBundles: datatypes.JSON(`{}`)}
+	require.NoError(t, database.DB.Create(&user).Error)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Delete(&user)
+	})
+
+	user, err := AddVisitedBundle(user, "insights")
+	require.NoError(t, err)
+	user, err = AddVisitedBundle(user, "insights")
+	require.NoError(t, err)
+
+	var stored models.UserIdentity
+	require.NoError(t, database.DB.First(&stored, user.ID).Error)
+	visits := stored.BundleVisits.Data()
+	assert.Equal(t, 2, visits["insights"].VisitCount)
+
+	bundles, err := GetVisitedBundles(stored)
+	require.NoError(t, err)
+	assert.True(t, bundles["insights"])
+}
+
+func TestAddVisitedBundleKeepsCallerCopy(t *testing.T) {
+	user := models.UserIdentity{AccountId: "bundle-visits-copy-user", VisitedBundles: datatypes.JSON(`{}`)}
+	require.NoError(t, database.DB.Create(&user).Error)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Delete(&user)
+	})
+
+	first, err := AddVisitedBundle(user, "insights")
+	require.NoError(t, err)
+	second, err := AddVisitedBundle(first, "insights")
+	require.NoError(t, err)
+
+	// the history of the previous identity, which may be held by the cache, is left untouched
+	assert.Equal(t, 1, first.BundleVisits.Data()["insights"].VisitCount)
+	assert.Equal(t, 2, second.BundleVisits.Data()["insights"].VisitCount)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"github.com/RedHatInsights/chrome-service-backend/rest/connectionhub"
+)
+
+const (
+	// ConnectionsDisconnectEventType asks every replica to close the connections of the listed users.
+	// The event is consumed by the replicas and never emitted to the clients.
+	ConnectionsDisconnectEventType = "com.redhat.console.chrome.connections.disconnect"
+	connectionsEventSource         = "https://console.redhat.com/api/chrome-service/support/users"
+)
+
+// DisconnectUser closes the websocket connections of the user on every replica. The replicas close
+// the connections once they consume the event, so the request does not wait for them. Only the
+// connections of this replica are closed while the events are not published.
+func DisconnectUser(accountId string) error {
+	if eventPublisher == nil {
+		connectionhub.ConnectionHub.DisconnectUser(accountId)
+		return nil
+	}
+	return publishEvent(connectionsEventSource, ConnectionsDisconnectEventType, connectionhub.WsMessage{
+		Users:   []string{accountId},
+		Payload: map[string]interface{}{"accountId": accountId},
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
@@ -227,13 +228,18 @@ var (
 	}
 )
 
-func ForkBaseTemplate(userId uint, dashboard models.AvailableTemplates) (models.DashboardTemplate, error) {
+// ForkBaseTemplate creates a user dashboard from the org default of the dashboard, or from the base
+// template when the organization did not publish one.
+func ForkBaseTemplate(userId uint, orgId string, dashboard models.AvailableTemplates) (models.DashboardTemplate, error) {
 	err := dashboard.IsValid()
 	if err != nil {
-		return models.DashboardTemplate{}, err
+		return models.DashboardTemplate{}, util.BadRequest(err)
 	}
 
-	baseTemplate := BaseTemplates[dashboard]
+	templateConfig, err := dashboardTemplateConfig(orgId, dashboard)
+	if err != nil {
+		return models.DashboardTemplate{}, err
+	}
 
 	templateBase := models.DashboardTemplateBase{
 		Name:        dashboard.String(),
@@ -244,7 +250,7 @@ func ForkBaseTemplate(userId uint, dashboard models.AvailableTemplates) (models.
 		UserIdentityID: userId,
 		Default:        true,
 		TemplateBase:   templateBase,
-		TemplateConfig: baseTemplate.TemplateConfig,
+		TemplateConfig: templateConfig,
 	}
 
 	result := database.DB.Create(&dashboardTemplate)
@@ -262,7 +268,7 @@ func GetAllUserDashboardTemplates(userId uint) ([]models.DashboardTemplate, erro
 	return userDashboardTemplates, result.Error
 }
 
-func GetUserDashboardTemplate(userId uint, dashboard models.AvailableTemplates) ([]models.DashboardTemplate, error) {
+func GetUserDashboardTemplate(userId uint, orgId string, dashboard models.AvailableTemplates) ([]models.DashboardTemplate, error) {
 	var userDashboardTemplates []models.DashboardTemplate
 
 	result := database.DB.Where("user_identity_id = ? AND name = ?", userId, dashboard).Find(&userDashboardTemplates)
@@ -272,7 +278,7 @@ func GetUserDashboardTemplate(userId uint, dashboard models.AvailableTemplates)
 			return userDashboardTemplates, result.Error
 		}
 
-		dashboardTemplate, err := ForkBaseTemplate(userId, dashboard)
+		dashboardTemplate, err := ForkBaseTemplate(userId, orgId, dashboard)
 
 		if err != nil {
 			return nil, err
@@ -284,19 +290,85 @@ func GetUserDashboardTemplate(userId uint, dashboard models.AvailableTemplates)
 	return userDashboardTemplates, result.Error
 }
 
-func GetDashboardTemplate(userId uint, dashboard models.AvailableTemplates) ([]models.DashboardTemplate, error) {
+func GetDashboardTemplate(userId uint, orgId string, dashboard models.AvailableTemplates) ([]models.DashboardTemplate, error) {
 	var userDashboardTemplates []models.DashboardTemplate
 	var err error
 	if dashboard.String() == "" {
 		userDashboardTemplates, err = GetAllUserDashboardTemplates(userId)
 	} else {
-		userDashboardTemplates, err = GetUserDashboardTemplate(userId, dashboard)
+		userDashboardTemplates, err = GetUserDashboardTemplate(userId, orgId, dashboard)
 	}
 
 	return userDashboardTemplates, err
 }
 
-func UpdateDashboardTemplate(templateId uint, userId uint, dashboardTemplate models.DashboardTemplate) (models.DashboardTemplate, error) {
+// DashboardTemplatesListOptions are the pagination, sort and filter params of the dashboard templates list.
+var DashboardTemplatesListOptions = util.ListQueryOptions{
+	MaxLimit: 100,
+	SortFields: map[string]string{
+		"name":    "name",
+		"default": "default",
+		"created": "created_at",
+		"updated": "updated_at",
+	},
+	TieBreaker: "id",
+	Filters: map[string]util.ListFilter{
+		"default": util.BoolFilter("default"),
+	},
+}
+
+// ListDashboardTemplates returns a page of the user's dashboard templates and the number of matching templates.
+// When a dashboard is requested, its template is forked first if the user does not have one yet.
+func ListDashboardTemplates(userId uint, orgId string, dashboard models.AvailableTemplates, query util.ListQuery) ([]models.DashboardTemplate, int, error) {
+	db := database.DB.Model(&models.DashboardTemplate{}).Where("user_identity_id = ?", userId)
+	if dashboard.String() != "" {
+		if _, err := GetUserDashboardTemplate(userId, orgId, dashboard); err != nil {
+			return nil, 0, err
+		}
+		db = db.Where("name = ?", dashboard)
+	}
+	return util.FindPage[models.DashboardTemplate](db, query)
+}
+
+// saveDashboardTemplate writes the changes and bumps the template version. With an If-Match header the
+// write only applies while the stored version is the one the client read.
+func saveDashboardTemplate(template *models.DashboardTemplate, ifMatch string, changes models.DashboardTemplate) error {
+	if err := util.CheckIfMatch(ifMatch, DashboardTemplateETag(*template)); err != nil {
+		return err
+	}
+
+	query := database.DB.Model(template)
+	if ifMatch != "" {
+		query = query.Where("version = ?", template.Version)
+	}
+	changes.Version = template.Version + 1
+	result := query.Updates(changes)
+	if result.Error != nil {
+		return result.Error
+	}
+	if ifMatch != "" && result.RowsAffected == 0 {
+		return fmt.Errorf("%w: the resource was modified, fetch it again and retry", util.ErrPreconditionFailed)
+	}
+	return nil
+}
+
+// FindUserDashboardTemplate returns the dashboard template with templateId when it belongs to the user.
+func FindUserDashboardTemplate(userId uint, templateId uint) (models.DashboardTemplate, error) {
+	var dashboardTemplate models.DashboardTemplate
+	result := database.DB.Find(&dashboardTemplate, templateId)
+	if result.Error != nil {
+		return dashboardTemplate, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return dashboardTemplate, gorm.ErrRecordNotFound
+	}
+	if dashboardTemplate.UserIdentityID != userId {
+		return dashboardTemplate, util.ErrNotAuthorized
+	}
+	return dashboardTemplate, nil
+}
+
+func UpdateDashboardTemplate(templateId uint, userId uint, dashboardTemplate models.DashboardTemplate, ifMatch string) (models.DashboardTemplate, error) {
 	var userDashboardTemplate models.DashboardTemplate
 	var err error
 
@@ -327,7 +399,7 @@ func UpdateDashboardTemplate(templateId uint, userId uint, dashboardTemplate mod
 
 			err = gi.IsValid(models.GridSizes(layoutSize))
 			if err != nil {
-				return userDashboardTemplate, err
+				return userDashboardTemplate, util.BadRequest(err)
 			}
 		}
 
@@ -338,11 +410,11 @@ func UpdateDashboardTemplate(templateId uint, userId uint, dashboardTemplate mod
 	}
 	err = dashboardTemplate.TemplateConfig.IsValid()
 	if err != nil {
-		return userDashboardTemplate, err
+		return userDashboardTemplate, util.BadRequest(err)
 	}
 
 	// Update only the templates, no other fields are allowed to be updated
-	database.DB.Model(&userDashboardTemplate).Updates(models.DashboardTemplate{
+	err = saveDashboardTemplate(&userDashboardTemplate, ifMatch, models.DashboardTemplate{
 		TemplateConfig: dashboardTemplate.TemplateConfig,
 	})
 
@@ -364,7 +436,7 @@ func GetDashboardTemplateBase(dashboard models.AvailableTemplates) (models.BaseD
 	err := dashboard.IsValid()
 
 	if err != nil {
-		return baseTemplate, err
+		return baseTemplate, util.BadRequest(err)
 	}
 
 	baseTemplate = BaseTemplates[dashboard]
@@ -422,20 +494,23 @@ func ChangeDefaultTemplate(accountId uint, dashboardId uint) (models.DashboardTe
 
 	dashboardType := dashboardTemplate.TemplateBase.Name
 
-	result = database.DB.Model(models.DashboardTemplate{}).Where("user_identity_id = ? AND name = ?", accountId, dashboardType).Update("default", false)
+	result = database.DB.Model(models.DashboardTemplate{}).
+		Where("user_identity_id = ? AND name = ? AND id <> ?", accountId, dashboardType, dashboardTemplate.ID).
+		Where(map[string]interface{}{"default": true}).
+		Updates(map[string]interface{}{"default": false, "version": gorm.Expr("version + 1")})
 
 	if result.Error != nil {
 		return dashboardTemplate, result.Error
 	}
 
-	result = database.DB.Model(&dashboardTemplate).Updates(models.DashboardTemplate{
+	err := saveDashboardTemplate(&dashboardTemplate, "", models.DashboardTemplate{
 		Default: true,
 	})
 
-	return dashboardTemplate, result.Error
+	return dashboardTemplate, err
 }
 
-func ResetDashboardTemplate(accountId uint, dashboardId uint) (models.DashboardTemplate, error) {
+func ResetDashboardTemplate(accountId uint, dashboardId uint, ifMatch string) (models.DashboardTemplate, error) {
 	var dashboardTemplate models.DashboardTemplate
 
 	result := database.DB.First(&dashboardTemplate, dashboardId)
@@ -449,14 +524,40 @@ func ResetDashboardTemplate(accountId uint, dashboardId uint) (models.DashboardT
 
 	baseTemplate := BaseTemplates[models.AvailableTemplates(dashboardTemplate.TemplateBase.Name)]
 
-	result = database.DB.Model(&dashboardTemplate).Updates(models.DashboardTemplate{
+	err := saveDashboardTemplate(&dashboardTemplate, ifMatch, models.DashboardTemplate{
 		TemplateConfig: baseTemplate.TemplateConfig,
 	})
 
-	return dashboardTemplate, result.Error
+	return dashboardTemplate, err
 
 }
 
+// ResetDashboardTemplateToOrgDefault resets the dashboard to the org default, or to the base template
+// when the organization did not publish one.
+func ResetDashboardTemplateToOrgDefault(accountId uint, orgId string, dashboardId uint, ifMatch string) (models.DashboardTemplate, error) {
+	var dashboardTemplate models.DashboardTemplate
+
+	result := database.DB.First(&dashboardTemplate, dashboardId)
+	if result.RowsAffected == 0 || result.Error != nil {
+		return dashboardTemplate, gorm.ErrRecordNotFound
+	}
+
+	if dashboardTemplate.UserIdentityID != accountId {
+		return dashboardTemplate, util.ErrNotAuthorized
+	}
+
+	templateConfig, err := dashboardTemplateConfig(orgId, models.AvailableTemplates(dashboardTemplate.TemplateBase.Name))
+	if err != nil {
+		return dashboardTemplate, err
+	}
+
+	err = saveDashboardTemplate(&dashboardTemplate, ifMatch, models.DashboardTemplate{
+		TemplateConfig: templateConfig,
+	})
+
+	return dashboardTemplate, err
+}
+
 // TODO: replace these once we have actual base templates
 func getLandingPageBaseLayout(x int) []models.GridItem {
 	if x == 0 {
@@ -560,5 +661,5 @@ func EncodeDashboardTemplate(accountId uint, templateId uint) (string, error) {
 func DecodeDashboardTemplate(encoded string) (models.DashboardTemplate, error) {
 	dashboardTemplate, err := models.DecodeDashboardBase64(encoded)
 
-	return dashboardTemplate, err
+	return dashboardTemplate, util.BadRequest(err)
 }
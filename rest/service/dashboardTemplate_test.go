@@ -172,6 +172,7 @@ func setUp() {
 	dbName = fmt.Sprintf("%d-services.db", time)
 	config.Get().DbName = dbName
 	LoadBaseLayout()
+	util.InitUserIdentitiesCache(util.IdentityCacheOptions{})
 
 	database.Init()
 	err := database.DB.AutoMigrate(&models.DashboardTemplate{}, &models.UserIdentity{})
@@ -205,7 +206,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 
 	t.Run("Should return dashboard templates  with landingPage base template", func(t *testing.T) {
 		userId := uint(1)
-		userDashboardTemplates, err := GetUserDashboardTemplate(userId, models.LandingPage)
+		userDashboardTemplates, err := GetUserDashboardTemplate(userId, "", models.LandingPage)
 		assert.Nil(t, err)
 		assert.NotNil(t, userDashboardTemplates)
 		assert.Equal(t, 2, len(userDashboardTemplates))
@@ -214,7 +215,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 
 	t.Run("Should create new dashboard template with landingPage base template if user does not have personalized landingPage template", func(t *testing.T) {
 		userId := uint(2)
-		userDashboardTemplates, err := GetUserDashboardTemplate(userId, models.LandingPage)
+		userDashboardTemplates, err := GetUserDashboardTemplate(userId, "", models.LandingPage)
 		assert.Nil(t, err)
 		assert.NotNil(t, userDashboardTemplates)
 		assert.Equal(t, 1, len(userDashboardTemplates))
@@ -223,7 +224,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 
 	t.Run("GetDashboardTemplate should return all templates if dashboard is empty", func(t *testing.T) {
 		userId := uint(1)
-		userDashboardTemplates, err := GetDashboardTemplate(userId, models.AvailableTemplates(""))
+		userDashboardTemplates, err := GetDashboardTemplate(userId, "", models.AvailableTemplates(""))
 		assert.Nil(t, err)
 		assert.NotNil(t, userDashboardTemplates)
 		assert.Equal(t, 4, len(userDashboardTemplates))
@@ -233,7 +234,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 
 	t.Run("GetDashboardTemplate should return only landingPage dashboard templates if dashboard is not landingPage", func(t *testing.T) {
 		userId := uint(1)
-		userDashboardTemplates, err := GetDashboardTemplate(userId, models.LandingPage)
+		userDashboardTemplates, err := GetDashboardTemplate(userId, "", models.LandingPage)
 		assert.Nil(t, err)
 		assert.NotNil(t, userDashboardTemplates)
 		assert.Equal(t, 2, len(userDashboardTemplates))
@@ -255,7 +256,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 				Xl: getMockItems(),
 			},
 		}
-		_, err := UpdateDashboardTemplate(templateId, userId, template)
+		_, err := UpdateDashboardTemplate(templateId, userId, template, "")
 		assert.NotNil(t, err)
 		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
 	})
@@ -275,7 +276,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 				Xl: getMockItems(),
 			},
 		}
-		_, err := UpdateDashboardTemplate(templateId, userId, template)
+		_, err := UpdateDashboardTemplate(templateId, userId, template, "")
 		assert.NotNil(t, err)
 		assert.True(t, errors.Is(err, util.ErrNotAuthorized))
 	})
@@ -295,7 +296,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 				Xl: getMockItems(),
 			},
 		}
-		updatedTemplate, err := UpdateDashboardTemplate(templateId, userId, template)
+		updatedTemplate, err := UpdateDashboardTemplate(templateId, userId, template, "")
 		assert.Nil(t, err)
 		assert.NotNil(t, updatedTemplate)
 		assert.Equal(t, template.TemplateConfig, updatedTemplate.TemplateConfig)
@@ -305,6 +306,30 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 		assert.Equal(t, true, updatedTemplate.Default)
 	})
 
+	t.Run("UpdateDashboardTemplate should reject updates based on a stale ETag", func(t *testing.T) {
+		userId := uint(1)
+		templateId := uint(1)
+		var stored models.DashboardTemplate
+		assert.Nil(t, database.DB.First(&stored, templateId).Error)
+		etag := DashboardTemplateETag(stored)
+
+		template := models.DashboardTemplate{
+			TemplateConfig: models.TemplateConfig{
+				Sm: getMockItems(),
+			},
+		}
+		updatedTemplate, err := UpdateDashboardTemplate(templateId, userId, template, etag)
+		assert.Nil(t, err)
+		assert.Equal(t, stored.Version+1, updatedTemplate.Version)
+		assert.NotEqual(t, etag, DashboardTemplateETag(updatedTemplate))
+
+		_, err = UpdateDashboardTemplate(templateId, userId, template, etag)
+		assert.ErrorIs(t, err, util.ErrPreconditionFailed)
+
+		_, err = UpdateDashboardTemplate(templateId, userId, template, DashboardTemplateETag(updatedTemplate))
+		assert.Nil(t, err)
+	})
+
 	t.Run("UpdateDashboardTemplate should return an error if template is not valid", func(t *testing.T) {
 		userId := uint(1)
 		templateId := uint(1)
@@ -332,7 +357,7 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 				Xl: getMockItems(),
 			},
 		}
-		_, err := UpdateDashboardTemplate(templateId, userId, template)
+		_, err := UpdateDashboardTemplate(templateId, userId, template, "")
 		assert.NotNil(t, err)
 		assert.Equal(t, `invalid grid item, height "h", width "w", maxHeight "maxH", mixHeight "minH" must be greater than 0`, err.Error())
 	})
@@ -445,14 +470,14 @@ func TestGetAllUserDashboardTemplates(t *testing.T) {
 
 	t.Run("ForkBaseTemplate should return not found error if template does not exist", func(t *testing.T) {
 		userId := uint(1)
-		_, err := ForkBaseTemplate(userId, "fakeTemplate")
+		_, err := ForkBaseTemplate(userId, "", "fakeTemplate")
 		assert.NotNil(t, err)
 		assert.Equal(t, "invalid dashboard template. Expected one of landingPage, got fakeTemplate", err.Error())
 	})
 
 	t.Run("ForkBaseTemplate should create a new template with the base template", func(t *testing.T) {
 		userId := uint(1)
-		template, err := ForkBaseTemplate(userId, "landingPage")
+		template, err := ForkBaseTemplate(userId, "", "landingPage")
 		assert.Nil(t, err)
 		assert.NotNil(t, template)
 		assert.Equal(t, models.LandingPage.String(), template.TemplateBase.Name)
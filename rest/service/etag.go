@@ -0,0 +1,42 @@
+package service
+
+import (
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+)
+
+// DashboardTemplateETag is the entity tag of a stored dashboard template, derived from its version.
+func DashboardTemplateETag(template models.DashboardTemplate) string {
+	return util.ETag("dashboard-template", template.ID, template.Version)
+}
+
+// DashboardTemplatesETag is the entity tag of a page of dashboard templates.
+func DashboardTemplatesETag(templates []models.DashboardTemplate, total int) string {
+	parts := []interface{}{"dashboard-templates", total}
+	for _, template := range templates {
+		parts = append(parts, template.ID, template.Version)
+	}
+	return util.ETag(parts...)
+}
+
+// FavoritePagesETag is the entity tag of the favorite pages of a user at a favorites version. A new
+// navigation changes it too, as the favorites are resolved against it.
+func FavoritePagesETag(favoritesVersion int) string {
+	return util.ETag("favorite-pages", navigationVersion(), favoritesVersion)
+}
+
+// FavoritePageETag is the entity tag of a single favorite page, derived from its version.
+func FavoritePageETag(page models.FavoritePage) string {
+	return util.ETag("favorite-page", page.ID, page.Version, navigationVersion())
+}
+
+// SelfReportETag is the entity tag of a self report, derived from its version.
+func SelfReportETag(selfReport models.SelfReport) string {
+	return util.ETag("self-report", selfReport.ID, selfReport.Version)
+}
+
+// RecentlyUsedWorkspacesETag is the entity tag of the recently used workspaces of a user at a workspaces
+// version.
+func RecentlyUsedWorkspacesETag(workspacesVersion int) string {
+	return util.ETag("recently-used-workspaces", workspacesVersion)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
+	"github.com/RedHatInsights/chrome-service-backend/rest/connectionhub"
+	"github.com/google/uuid"
+)
+
+// EventPublisher sends a websocket event to the connection hubs of every replica.
+type EventPublisher func(event cloudevents.KafkaEnvelope) error
+
+var eventPublisher EventPublisher
+
+// SetEventPublisher sets the publisher of the events generated by the service. Events are dropped
+// while it is not set, for example when websockets are disabled.
+func SetEventPublisher(publisher EventPublisher) {
+	eventPublisher = publisher
+}
+
+// publishEvent wraps the message in a cloud event and publishes it. The message goes through the same
+// path as the events of other services, it is never broadcast.
+func publishEvent(source cloudevents.URI, eventType string, message connectionhub.WsMessage) error {
+	if eventPublisher == nil {
+		return nil
+	}
+	event := cloudevents.KafkaEnvelope{
+		Envelope: cloudevents.WrapPayload(message, source, uuid.NewString(), eventType),
+	}
+	return eventPublisher(event)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"gorm.io/gorm"
+)
+
+const maxFavoritesNameLength = 100
+
+func getUserFavoriteFolder(tx *gorm.DB, userID uint, folderID uint) (models.FavoriteFolder, error) {
+	var folder models.FavoriteFolder
+	result := tx.Find(&folder, folderID)
+	if result.RowsAffected == 0 || result.Error != nil {
+		return folder, gorm.ErrRecordNotFound
+	}
+
+	if folder.UserIdentityID != userID {
+		return folder, util.ErrNotAuthorized
+	}
+
+	return folder, nil
+}
+
+func validateFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return name, fmt.Errorf("%w: folder name is required", util.ErrBadRequest)
+	}
+	if len(name) > maxFavoritesNameLength {
+		return name, fmt.Errorf("%w: folder name can have at most %d characters", util.ErrBadRequest, maxFavoritesNameLength)
+	}
+	return name, nil
+}
+
+// GetUserFavoriteFolders returns the user folders in their manual order, each with its active favorites.
+func GetUserFavoriteFolders(userID uint) ([]models.FavoriteFolder, error) {
+	var folders []models.FavoriteFolder
+	err := database.DB.
+		Where("user_identity_id = ?", userID).
+		Preload("FavoritePages", func(db *gorm.DB) *gorm.DB {
+			return db.Where("favorite = ?", true).Order(favoritesOrder)
+		}).
+		Order(favoritesOrder).
+		Find(&folders).
+		Error
+	if err != nil {
+		return folders, err
+	}
+
+	for i := range folders {
+		folders[i].FavoritePages = ResolveFavoritePages(folders[i].FavoritePages)
+	}
+	return folders, nil
+}
+
+func CreateFavoriteFolder(userID uint, request models.FavoriteFolderRequest) (models.FavoriteFolder, error) {
+	folder := models.FavoriteFolder{UserIdentityID: userID}
+	name, err := validateFolderName(request.Name)
+	if err != nil {
+		return folder, err
+	}
+	folder.Name = name
+
+	err = database.DB.Model(&models.FavoriteFolder{}).
+		Where("user_identity_id = ?", userID).
+		Select("COALESCE(MAX(position), -1) + 1").
+		Scan(&folder.Position).
+		Error
+	if err != nil {
+		return folder, err
+	}
+
+	err = database.DB.Create(&folder).Error
+	return folder, err
+}
+
+func RenameFavoriteFolder(userID uint, folderID uint, request models.FavoriteFolderRequest) (models.FavoriteFolder, error) {
+	folder, err := getUserFavoriteFolder(database.DB, userID, folderID)
+	if err != nil {
+		return folder, err
+	}
+
+	name, err := validateFolderName(request.Name)
+	if err != nil {
+		return folder, err
+	}
+
+	folder.Name = name
+	err = database.DB.Model(&folder).Update("name", name).Error
+	return folder, err
+}
+
+// DeleteFavoriteFolder removes a folder. Its favorites are kept and appended to the top level.
+func DeleteFavoriteFolder(userID uint, folderID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		folder, err := getUserFavoriteFolder(tx, userID, folderID)
+		if err != nil {
+			return err
+		}
+		if _, err := bumpFavoritesVersion(tx, userID, ""); err != nil {
+			return err
+		}
+
+		var ids []uint
+		if err := favoritesInFolder(tx, userID, &folder.ID).Order(favoritesOrder).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		position, err := nextFavoritePosition(tx, userID, nil)
+		if err != nil {
+			return err
+		}
+		for i, id := range ids {
+			err := tx.Model(&models.FavoritePage{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"folder_id": nil,
+				"position":  position + i,
+				"version":   gorm.Expr("version + 1"),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&folder).Error
+	})
+}
+
+// ReorderFavoriteFolders sets the order of the user folders.
+func ReorderFavoriteFolders(userID uint, request models.FavoritesReorderRequest) ([]models.FavoriteFolder, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current []uint
+		err := tx.Model(&models.FavoriteFolder{}).Where("user_identity_id = ?", userID).Order(favoritesOrder).Pluck("id", &current).Error
+		if err != nil {
+			return err
+		}
+
+		ordered, err := reorderIDs(current, request.IDs)
+		if err != nil {
+			return err
+		}
+		return updatePositions(tx, &models.FavoriteFolder{}, ordered)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetUserFavoriteFolders(userID)
+}
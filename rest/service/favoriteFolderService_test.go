@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func favoritePathnames(pages []models.FavoritePage) []string {
+	names := []string{}
+	for _, page := range pages {
+		names = append(names, page.Pathname)
+	}
+	return names
+}
+
+func saveTestFavorites(t *testing.T, user *models.UserIdentity, pathnames ...string) []models.FavoritePage {
+	t.Helper()
+	for _, pathname := range pathnames {
+		_, _, err := SaveUserFavoritePage(user.ID, user.AccountId, createTestFavoritePage(t, pathname, true, user.ID), "")
+		require.NoError(t, err)
+	}
+
+	pages, err := GetUserActiveFavoritePages(user.ID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.FavoriteFolder{})
+	})
+	return pages
+}
+
+func TestReorderIDs(t *testing.T) {
+	ordered, err := reorderIDs([]uint{1, 2, 3, 4}, []uint{3, 1})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{3, 1, 2, 4}, ordered)
+
+	_, err = reorderIDs([]uint{1, 2}, []uint{5})
+	assert.True(t, errors.Is(err, util.ErrBadRequest))
+
+	_, err = reorderIDs([]uint{1, 2}, []uint{1, 1})
+	assert.True(t, errors.Is(err, util.ErrBadRequest))
+}
+
+func TestFavoritePagesOrdering(t *testing.T) {
+	user := setupTestUser(t)
+	pages := saveTestFavorites(t, user, testPathname1, testPathname2, testPathname3)
+
+	t.Run("Should append new favorites", func(t *testing.T) {
+		assert.Equal(t, []string{testPathname1, testPathname2, testPathname3}, favoritePathnames(pages))
+		assert.Equal(t, 2, pages[2].Position)
+	})
+
+	t.Run("Should reorder favorites", func(t *testing.T) {
+		reordered, _, err := ReorderFavoritePages(user.ID, models.FavoritesReorderRequest{IDs: []uint{pages[2].ID, pages[0].ID}}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{testPathname3, testPathname1, testPathname2}, favoritePathnames(reordered))
+	})
+
+	t.Run("Should set a title override", func(t *testing.T) {
+		title := "My dashboard"
+		page, err := UpdateFavoritePage(user.ID, pages[0].ID, models.FavoritePageUpdateRequest{Title: &title}, "")
+		require.NoError(t, err)
+		assert.Equal(t, title, page.Title)
+
+		_, err = UpdateFavoritePage(user.ID+1000, pages[0].ID, models.FavoritePageUpdateRequest{Title: &title}, "")
+		assert.True(t, errors.Is(err, util.ErrNotAuthorized))
+	})
+}
+
+func TestFavoriteFolders(t *testing.T) {
+	user := setupTestUser(t)
+	pages := saveTestFavorites(t, user, testPathname1, testPathname2, testPathname3)
+
+	_, err := CreateFavoriteFolder(user.ID, models.FavoriteFolderRequest{Name: "  "})
+	assert.True(t, errors.Is(err, util.ErrBadRequest))
+
+	folder, err := CreateFavoriteFolder(user.ID, models.FavoriteFolderRequest{Name: "Insights"})
+	require.NoError(t, err)
+	other, err := CreateFavoriteFolder(user.ID, models.FavoriteFolderRequest{Name: "Other"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.Position)
+
+	t.Run("Should move favorites into folder", func(t *testing.T) {
+		moved, _, err := MoveFavoritePages(user.ID, models.FavoritesMoveRequest{FolderID: &folder.ID, IDs: []uint{pages[1].ID, pages[0].ID}}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{testPathname2, testPathname1}, favoritePathnames(moved))
+
+		folders, err := GetUserFavoriteFolders(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{testPathname2, testPathname1}, favoritePathnames(folders[0].FavoritePages))
+	})
+
+	t.Run("Should reject moving into another user folder", func(t *testing.T) {
+		foreign := models.FavoriteFolder{Name: "foreign", UserIdentityID: user.ID + 1000}
+		require.NoError(t, database.DB.Create(&foreign).Error)
+		defer database.DB.Unscoped().Delete(&foreign)
+
+		_, _, err := MoveFavoritePages(user.ID, models.FavoritesMoveRequest{FolderID: &foreign.ID, IDs: []uint{pages[2].ID}}, "")
+		assert.True(t, errors.Is(err, util.ErrNotAuthorized))
+	})
+
+	t.Run("Should reorder folders", func(t *testing.T) {
+		folders, err := ReorderFavoriteFolders(user.ID, models.FavoritesReorderRequest{IDs: []uint{other.ID}})
+		require.NoError(t, err)
+		assert.Equal(t, "Other", folders[0].Name)
+	})
+
+	t.Run("Should keep favorites of deleted folder", func(t *testing.T) {
+		require.NoError(t, DeleteFavoriteFolder(user.ID, folder.ID))
+
+		active, err := GetUserActiveFavoritePages(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{testPathname3, testPathname2, testPathname1}, favoritePathnames(active))
+		for _, page := range active {
+			assert.Nil(t, page.FolderID)
+		}
+	})
+}
+
+func TestFavoritePagesIfMatch(t *testing.T) {
+	user := setupTestUser(t)
+	pages := saveTestFavorites(t, user, testPathname1)
+	_, _, version, err := ListUserFavoritePages(user.ID, false, util.ListQuery{Limit: 10})
+	require.NoError(t, err)
+	listETag := FavoritePagesETag(version)
+	pageETag := FavoritePageETag(pages[0])
+
+	t.Run("Should reject list writes based on a stale list", func(t *testing.T) {
+		saved, savedVersion, err := SaveUserFavoritePage(user.ID, user.AccountId, createTestFavoritePage(t, testPathname2, true, user.ID), listETag)
+		require.NoError(t, err)
+		assert.Len(t, saved, 2)
+		assert.Equal(t, version+1, savedVersion)
+
+		_, _, err = ReorderFavoritePages(user.ID, models.FavoritesReorderRequest{IDs: []uint{pages[0].ID}}, listETag)
+		assert.True(t, errors.Is(err, util.ErrPreconditionFailed))
+	})
+
+	t.Run("Should check a favorite against its own entity tag", func(t *testing.T) {
+		title := "Renamed"
+		_, err := UpdateFavoritePage(user.ID, pages[0].ID, models.FavoritePageUpdateRequest{Title: &title}, listETag)
+		assert.True(t, errors.Is(err, util.ErrPreconditionFailed))
+
+		// saving another favorite changed the list, not this favorite
+		page, err := UpdateFavoritePage(user.ID, pages[0].ID, models.FavoritePageUpdateRequest{Title: &title}, pageETag)
+		require.NoError(t, err)
+		assert.Equal(t, title, page.Title)
+		assert.NotEqual(t, pageETag, FavoritePageETag(page))
+
+		_, err = UpdateFavoritePage(user.ID, pages[0].ID, models.FavoritePageUpdateRequest{Title: &title}, pageETag)
+		assert.True(t, errors.Is(err, util.ErrPreconditionFailed))
+	})
+}
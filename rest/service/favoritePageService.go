@@ -6,14 +6,18 @@ import (
 	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+// favoritesOrder keeps the manual order of favorites and folders stable for rows sharing a position.
+const favoritesOrder = "position ASC, id ASC"
+
 func GetUserActiveFavoritePages(userID uint) ([]models.FavoritePage, error) {
 	var activeFavoritePages []models.FavoritePage
 
-	err := database.DB.Where("user_identity_id = ?", userID).Where("favorite", true).Find(&activeFavoritePages).Error
+	err := database.DB.Where("user_identity_id = ?", userID).Where("favorite", true).Order(favoritesOrder).Find(&activeFavoritePages).Error
 
 	return activeFavoritePages, err
 }
@@ -21,7 +25,7 @@ func GetUserActiveFavoritePages(userID uint) ([]models.FavoritePage, error) {
 func GetAllUserFavoritePages(userID uint) ([]models.FavoritePage, error) {
 	var favoritePages []models.FavoritePage
 
-	err := database.DB.Where("user_identity_id = ?", userID).Find(&favoritePages).Error
+	err := database.DB.Where("user_identity_id = ?", userID).Order(favoritesOrder).Find(&favoritePages).Error
 	return favoritePages, err
 }
 
@@ -31,12 +35,85 @@ func GetUserArchivedFavoritePages(userID uint) ([]models.FavoritePage, error) {
 	err := database.DB.
 		Where("user_identity_id = ?", userID).
 		Where("favorite = ?", false).
+		Order(favoritesOrder).
 		Find(&archivedFavorites).
 		Error
 
 	return archivedFavorites, err
 }
 
+// FavoritePagesListOptions are the pagination, sort and filter params of the favorite pages list.
+var FavoritePagesListOptions = util.ListQueryOptions{
+	MaxLimit: 1000,
+	SortFields: map[string]string{
+		"position": "position",
+		"pathname": "pathname",
+		"created":  "created_at",
+		"updated":  "updated_at",
+	},
+	DefaultSort: "position",
+	TieBreaker:  "id",
+	Filters: map[string]util.ListFilter{
+		"pathname":          util.StringFilter("pathname"),
+		util.FAVORITE_PARAM: util.BoolFilter("favorite"),
+	},
+}
+
+// ListUserFavoritePages returns a page of the user's favorite pages, the number of matching pages and the
+// favorites version of the user. archivedOnly limits the list to the pages that are no longer favorite.
+func ListUserFavoritePages(userID uint, archivedOnly bool, query util.ListQuery) ([]models.FavoritePage, int, int, error) {
+	var pages []models.FavoritePage
+	var total, version int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// the version is read before the pages, a concurrent write leaves a stale tag but never a stale list
+		var err error
+		if version, err = favoritesVersion(tx, userID); err != nil {
+			return err
+		}
+		db := tx.Model(&models.FavoritePage{}).Where("user_identity_id = ?", userID)
+		if archivedOnly {
+			db = db.Where("favorite = ?", false)
+		}
+		pages, total, err = util.FindPage[models.FavoritePage](db, query)
+		return err
+	})
+	return pages, total, version, err
+}
+
+func favoritesVersion(tx *gorm.DB, userID uint) (int, error) {
+	var version int
+	err := tx.Model(&models.UserIdentity{}).Where("id = ?", userID).Select("favorites_version").Scan(&version).Error
+	return version, err
+}
+
+// bumpFavoritesVersion records a write to the favorite pages of the user and returns the new favorites
+// version. With an If-Match header the write only applies while the version is the one the client read.
+// It runs first in the transaction of the write, so concurrent writes of the same user wait on each other.
+func bumpFavoritesVersion(tx *gorm.DB, userID uint, ifMatch string) (int, error) {
+	query := tx.Model(&models.UserIdentity{}).Where("id = ?", userID)
+	if ifMatch != "" {
+		version, err := favoritesVersion(tx, userID)
+		if err != nil {
+			return 0, err
+		}
+		if err := util.CheckIfMatch(ifMatch, FavoritePagesETag(version)); err != nil {
+			return 0, err
+		}
+		query = query.Where("favorites_version = ?", version)
+	}
+	result := query.UpdateColumn("favorites_version", gorm.Expr("favorites_version + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if ifMatch != "" {
+			return 0, fmt.Errorf("%w: the favorite pages were modified, fetch them again and retry", util.ErrPreconditionFailed)
+		}
+		return 0, gorm.ErrRecordNotFound
+	}
+	return favoritesVersion(tx, userID)
+}
+
 func CheckIfExistsInDB(allFavoritePages []models.FavoritePage, newFavoritePage models.FavoritePage) (bool, uint) {
 	pageExists := false
 	var globalId uint
@@ -54,8 +131,12 @@ func CheckIfExistsInDB(allFavoritePages []models.FavoritePage, newFavoritePage m
 }
 
 func DeleteOrUpdateFavoritePage(favoritePage models.FavoritePage) error {
+	return deleteOrUpdateFavoritePage(database.DB, favoritePage)
+}
+
+func deleteOrUpdateFavoritePage(tx *gorm.DB, favoritePage models.FavoritePage) error {
 	if !favoritePage.Favorite {
-		result := database.DB.Unscoped().Delete(&favoritePage)
+		result := tx.Unscoped().Delete(&favoritePage)
 		if result.Error != nil {
 			return result.Error
 		}
@@ -63,7 +144,10 @@ func DeleteOrUpdateFavoritePage(favoritePage models.FavoritePage) error {
 			return gorm.ErrRecordNotFound
 		}
 	} else {
-		result := database.DB.Model(&models.FavoritePage{}).Where("pathname = ?", favoritePage.Pathname).Update("favorite", favoritePage.Favorite)
+		result := tx.Model(&models.FavoritePage{}).Where("pathname = ?", favoritePage.Pathname).Updates(map[string]interface{}{
+			"favorite": favoritePage.Favorite,
+			"version":  gorm.Expr("version + 1"),
+		})
 		if result.Error != nil {
 			return result.Error
 		}
@@ -83,24 +167,248 @@ func debugFavoritesEntry(accountId string, payload models.FavoritePage) {
 	}
 }
 
-func SaveUserFavoritePage(userID uint, accountId string, newFavoritePage models.FavoritePage) error {
-	var userFavoritePages, err = GetAllUserFavoritePages(userID)
+// SaveUserFavoritePage adds, updates or removes the favorite page of its pathname and returns the active
+// favorite pages and the favorites version they were read at.
+func SaveUserFavoritePage(userID uint, accountId string, newFavoritePage models.FavoritePage, ifMatch string) ([]models.FavoritePage, int, error) {
+	var activeFavoritePages []models.FavoritePage
+	var version int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if version, err = bumpFavoritesVersion(tx, userID, ifMatch); err != nil {
+			return err
+		}
+
+		var userFavoritePages []models.FavoritePage
+		if err := tx.Where("user_identity_id = ?", userID).Order(favoritesOrder).Find(&userFavoritePages).Error; err != nil {
+			return err
+		}
+
+		alreadyInDB, newFavoriteGlobalId := CheckIfExistsInDB(userFavoritePages, newFavoritePage)
+		if !alreadyInDB && newFavoritePage.Favorite {
+			// new favorites must exist in the navigation, moved routes are stored under their new pathname
+			newFavoritePage.Pathname, err = validateFavoritePathname(newFavoritePage.Pathname)
+			if err != nil {
+				return err
+			}
+			alreadyInDB, newFavoriteGlobalId = CheckIfExistsInDB(userFavoritePages, newFavoritePage)
+		}
+
+		if alreadyInDB {
+			newFavoritePage.ID = newFavoriteGlobalId
+			err = deleteOrUpdateFavoritePage(tx, newFavoritePage)
+			logrus.Debugf("Deleted %+v\n", newFavoritePage)
+			debugFavoritesEntry(accountId, newFavoritePage)
+		} else {
+			debugFavoritesEntry(accountId, newFavoritePage)
+			if newFavoritePage.FolderID != nil {
+				if _, err = getUserFavoriteFolder(tx, userID, *newFavoritePage.FolderID); err != nil {
+					return err
+				}
+			}
+			newFavoritePage.Position, err = nextFavoritePosition(tx, userID, newFavoritePage.FolderID)
+			if err != nil {
+				return err
+			}
+			err = tx.Create(&newFavoritePage).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_identity_id = ?", userID).Where("favorite", true).Order(favoritesOrder).Find(&activeFavoritePages).Error
+	})
+
+	return activeFavoritePages, version, err
+}
+
+func favoritesInFolder(tx *gorm.DB, userID uint, folderID *uint) *gorm.DB {
+	query := tx.Model(&models.FavoritePage{}).Where("user_identity_id = ?", userID)
+	if folderID == nil {
+		return query.Where("folder_id IS NULL")
+	}
+	return query.Where("folder_id = ?", *folderID)
+}
+
+// nextFavoritePosition returns the position that appends a favorite to the end of a folder, or of the top level.
+func nextFavoritePosition(tx *gorm.DB, userID uint, folderID *uint) (int, error) {
+	var position int
+	err := favoritesInFolder(tx, userID, folderID).Select("COALESCE(MAX(position), -1) + 1").Scan(&position).Error
+	return position, err
+}
 
-	if err != nil {
-		panic(err)
+// reorderIDs puts the requested IDs first, followed by the remaining current IDs in their current order.
+// Requested IDs must all be part of the current IDs.
+func reorderIDs(current []uint, requested []uint) ([]uint, error) {
+	known := make(map[uint]bool, len(current))
+	for _, id := range current {
+		known[id] = true
 	}
 
-	alreadyInDB, newFavoriteGlobalId := CheckIfExistsInDB(userFavoritePages, newFavoritePage)
+	ordered := make([]uint, 0, len(current))
+	listed := make(map[uint]bool, len(requested))
+	for _, id := range requested {
+		if !known[id] {
+			return nil, fmt.Errorf("%w: item %d does not exist in the reordered list", util.ErrBadRequest, id)
+		}
+		if listed[id] {
+			return nil, fmt.Errorf("%w: item %d is listed more than once", util.ErrBadRequest, id)
+		}
+		listed[id] = true
+		ordered = append(ordered, id)
+	}
+	for _, id := range current {
+		if !listed[id] {
+			ordered = append(ordered, id)
+		}
+	}
+	return ordered, nil
+}
 
-	if alreadyInDB {
-		newFavoritePage.ID = newFavoriteGlobalId
-		err = DeleteOrUpdateFavoritePage(newFavoritePage)
-		logrus.Debugf("Deleted %+v\n", newFavoritePage)
-		debugFavoritesEntry(accountId, newFavoritePage)
-	} else {
-		debugFavoritesEntry(accountId, newFavoritePage)
-		err = database.DB.Create(&newFavoritePage).Error
+func updatePositions(tx *gorm.DB, model interface{}, ids []uint) error {
+	for position, id := range ids {
+		if err := tx.Model(model).Where("id = ?", id).Update("position", position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bumpFavoritePageVersions records a write to each of the favorite pages.
+func bumpFavoritePageVersions(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&models.FavoritePage{}).Where("id IN ?", ids).UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
+// ReorderFavoritePages sets the order of favorites within a folder, or within the top level when no folder is given.
+// It returns the reordered favorites and the favorites version they were read at.
+func ReorderFavoritePages(userID uint, request models.FavoritesReorderRequest, ifMatch string) ([]models.FavoritePage, int, error) {
+	var pages []models.FavoritePage
+	var version int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if version, err = bumpFavoritesVersion(tx, userID, ifMatch); err != nil {
+			return err
+		}
+		if request.FolderID != nil {
+			if _, err := getUserFavoriteFolder(tx, userID, *request.FolderID); err != nil {
+				return err
+			}
+		}
+
+		var current []uint
+		if err := favoritesInFolder(tx, userID, request.FolderID).Order(favoritesOrder).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+		ordered, err := reorderIDs(current, request.IDs)
+		if err != nil {
+			return err
+		}
+		if err := updatePositions(tx, &models.FavoritePage{}, ordered); err != nil {
+			return err
+		}
+		if err := bumpFavoritePageVersions(tx, ordered); err != nil {
+			return err
+		}
+
+		return favoritesInFolder(tx, userID, request.FolderID).Order(favoritesOrder).Find(&pages).Error
+	})
+
+	return pages, version, err
+}
+
+// MoveFavoritePages moves favorites to the end of a folder, or of the top level when no folder is given.
+// It returns the moved favorites and the favorites version they were read at.
+func MoveFavoritePages(userID uint, request models.FavoritesMoveRequest, ifMatch string) ([]models.FavoritePage, int, error) {
+	if len(request.IDs) == 0 {
+		return nil, 0, fmt.Errorf("%w: no favorites to move", util.ErrBadRequest)
+	}
+
+	var pages []models.FavoritePage
+	var version int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if version, err = bumpFavoritesVersion(tx, userID, ifMatch); err != nil {
+			return err
+		}
+		if request.FolderID != nil {
+			if _, err := getUserFavoriteFolder(tx, userID, *request.FolderID); err != nil {
+				return err
+			}
+		}
+
+		var owned []uint
+		if err := tx.Model(&models.FavoritePage{}).Where("user_identity_id = ? AND id IN ?", userID, request.IDs).Pluck("id", &owned).Error; err != nil {
+			return err
+		}
+		if _, err := reorderIDs(owned, request.IDs); err != nil {
+			return err
+		}
+
+		position, err := nextFavoritePosition(tx, userID, request.FolderID)
+		if err != nil {
+			return err
+		}
+		for i, id := range request.IDs {
+			err := tx.Model(&models.FavoritePage{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"folder_id": request.FolderID,
+				"position":  position + i,
+				"version":   gorm.Expr("version + 1"),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("id IN ?", request.IDs).Order(favoritesOrder).Find(&pages).Error
+	})
+
+	return pages, version, err
+}
+
+// UpdateFavoritePage changes the title override of a favorite. An empty title restores the navigation title.
+// With an If-Match header the update only applies while the favorite is the version the client read.
+func UpdateFavoritePage(userID uint, favoriteID uint, request models.FavoritePageUpdateRequest, ifMatch string) (models.FavoritePage, error) {
+	var favoritePage models.FavoritePage
+	result := database.DB.Find(&favoritePage, favoriteID)
+	if result.RowsAffected == 0 || result.Error != nil {
+		return favoritePage, gorm.ErrRecordNotFound
+	}
+
+	if favoritePage.UserIdentityID != userID {
+		return favoritePage, util.ErrNotAuthorized
+	}
+	if err := util.CheckIfMatch(ifMatch, FavoritePageETag(favoritePage)); err != nil {
+		return favoritePage, err
+	}
+
+	if request.Title == nil {
+		return favoritePage, nil
+	}
+	if len(*request.Title) > maxFavoritesNameLength {
+		return favoritePage, fmt.Errorf("%w: favorite title can have at most %d characters", util.ErrBadRequest, maxFavoritesNameLength)
 	}
 
-	return err
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := bumpFavoritesVersion(tx, userID, ""); err != nil {
+			return err
+		}
+		query := tx.Model(&favoritePage)
+		if ifMatch != "" {
+			query = query.Where("version = ?", favoritePage.Version)
+		}
+		result := query.Updates(map[string]interface{}{
+			"title":   *request.Title,
+			"version": gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if ifMatch != "" && result.RowsAffected == 0 {
+			return fmt.Errorf("%w: the favorite page was modified, fetch it again and retry", util.ErrPreconditionFailed)
+		}
+		return tx.First(&favoritePage, favoritePage.ID).Error
+	})
+	return favoritePage, err
 }
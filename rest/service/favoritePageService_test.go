@@ -464,7 +464,7 @@ func TestSaveUserFavoritePage(t *testing.T) {
 			}
 
 			// Execute
-			err := SaveUserFavoritePage(user.ID, testAccountID, tt.newPage)
+			_, _, err := SaveUserFavoritePage(user.ID, testAccountID, tt.newPage, "")
 
 			// Assertions
 			if tt.expectError {
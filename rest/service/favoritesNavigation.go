@@ -0,0 +1,347 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// NavigationEntry is a page known to the generated navigation.
+type NavigationEntry struct {
+	Href   string
+	Title  string
+	Bundle string
+	Icon   string
+}
+
+// NavigationIndex resolves favorite pathnames against the hrefs of the generated navigation and
+// the routes of the generated federated modules. Like the chrome router, a pathname matches a route
+// when the route is one of its path prefixes. Bundle roots only match exactly, as every bundle has
+// a landing route that would otherwise match any page of the bundle.
+type NavigationIndex struct {
+	entries map[string]NavigationEntry
+	routes  map[string]bool
+	// version changes with the generated files, the resolved favorites change with it
+	version string
+}
+
+type navigationItem struct {
+	Href       string           `json:"href"`
+	Title      string           `json:"title"`
+	Icon       string           `json:"icon"`
+	IsExternal bool             `json:"isExternal"`
+	Routes     []navigationItem `json:"routes"`
+	NavItems   []navigationItem `json:"navItems"`
+}
+
+type navigationBundle struct {
+	ID       string           `json:"id"`
+	NavItems []navigationItem `json:"navItems"`
+}
+
+type fedModule struct {
+	Modules []struct {
+		Routes []struct {
+			Pathname string `json:"pathname"`
+		} `json:"routes"`
+	} `json:"modules"`
+}
+
+func normalizePathname(pathname string) string {
+	if i := strings.IndexAny(pathname, "?#"); i >= 0 {
+		pathname = pathname[:i]
+	}
+	pathname = strings.TrimSpace(pathname)
+	if pathname != "/" {
+		pathname = strings.TrimRight(pathname, "/")
+	}
+	if !strings.HasPrefix(pathname, "/") {
+		pathname = "/" + pathname
+	}
+	return pathname
+}
+
+// pathPrefixes returns the pathname followed by all its parent paths, longest first.
+// The root path is not included as it would match any pathname.
+func pathPrefixes(pathname string) []string {
+	prefixes := []string{}
+	for pathname != "" && pathname != "/" {
+		prefixes = append(prefixes, pathname)
+		pathname = pathname[:strings.LastIndex(pathname, "/")]
+	}
+	return prefixes
+}
+
+func (ni *NavigationIndex) addItems(bundle string, parentIcon string, items []navigationItem) {
+	for _, item := range items {
+		icon := item.Icon
+		if icon == "" {
+			icon = parentIcon
+		}
+		if item.Href != "" && !item.IsExternal && !strings.HasPrefix(item.Href, "http") {
+			href := normalizePathname(item.Href)
+			if _, ok := ni.entries[href]; !ok {
+				ni.entries[href] = NavigationEntry{Href: href, Title: item.Title, Bundle: bundle, Icon: icon}
+			}
+		}
+		ni.addItems(bundle, icon, item.Routes)
+		ni.addItems(bundle, icon, item.NavItems)
+	}
+}
+
+// NewNavigationIndex builds the index from the content of bundles-generated.json and fed-modules-generated.json.
+func NewNavigationIndex(bundlesData []byte, fedModulesData []byte) (*NavigationIndex, error) {
+	ni := &NavigationIndex{
+		entries: make(map[string]NavigationEntry),
+		routes:  make(map[string]bool),
+		version: util.ETag(string(bundlesData), string(fedModulesData)),
+	}
+
+	bundles := []navigationBundle{}
+	if err := json.Unmarshal(bundlesData, &bundles); err != nil {
+		return nil, fmt.Errorf("unable to parse generated bundles: %w", err)
+	}
+	for _, bundle := range bundles {
+		ni.addItems(bundle.ID, "", bundle.NavItems)
+	}
+
+	fedModules := map[string]json.RawMessage{}
+	if err := json.Unmarshal(fedModulesData, &fedModules); err != nil {
+		return nil, fmt.Errorf("unable to parse generated fed modules: %w", err)
+	}
+	for name, rawModule := range fedModules {
+		var module fedModule
+		if err := json.Unmarshal(rawModule, &module); err != nil {
+			// non module entries such as $schema
+			logrus.Debugf("Skipping fed module entry %s: %v", name, err)
+			continue
+		}
+		for _, m := range module.Modules {
+			for _, route := range m.Routes {
+				if route.Pathname != "" {
+					ni.routes[normalizePathname(route.Pathname)] = true
+				}
+			}
+		}
+	}
+
+	return ni, nil
+}
+
+// Lookup returns the navigation entry closest to the pathname and whether the pathname is routable.
+func (ni *NavigationIndex) Lookup(pathname string) (NavigationEntry, bool) {
+	pathname = normalizePathname(pathname)
+	if pathname == "/" {
+		return NavigationEntry{Href: pathname}, true
+	}
+
+	entry := NavigationEntry{}
+	found := false
+	for _, prefix := range pathPrefixes(pathname) {
+		if prefix != pathname && strings.Count(prefix, "/") < 2 {
+			continue
+		}
+		if navEntry, ok := ni.entries[prefix]; ok && entry.Href == "" {
+			entry = navEntry
+			found = true
+		}
+		if ni.routes[prefix] {
+			found = true
+		}
+	}
+
+	if entry.Bundle == "" {
+		entry.Bundle = strings.Split(strings.TrimPrefix(pathname, "/"), "/")[0]
+	}
+	return entry, found
+}
+
+// Redirect returns the new location of a moved pathname. Redirects of a parent path apply to its sub pages.
+func Redirect(redirects map[string]string, pathname string) (string, bool) {
+	pathname = normalizePathname(pathname)
+	for _, prefix := range pathPrefixes(pathname) {
+		if target, ok := redirects[prefix]; ok {
+			return normalizePathname(target + strings.TrimPrefix(pathname, prefix)), true
+		}
+	}
+	return pathname, false
+}
+
+var (
+	navigationIndex *NavigationIndex
+	// navigationIndexLoaded is set once the index was loaded or set, a missing index is not loaded again
+	navigationIndexLoaded bool
+	navigationIndexLock   sync.Mutex
+)
+
+// SetNavigationIndex replaces the navigation index used to validate favorites.
+// A nil index disables validation.
+func SetNavigationIndex(index *NavigationIndex) {
+	navigationIndexLock.Lock()
+	defer navigationIndexLock.Unlock()
+	navigationIndex = index
+	navigationIndexLoaded = true
+}
+
+// getNavigationIndex lazily loads the index from the generated files. Favorites are not validated
+// when the files are not available, the files are only read once.
+func getNavigationIndex() *NavigationIndex {
+	navigationIndexLock.Lock()
+	defer navigationIndexLock.Unlock()
+	if !navigationIndexLoaded {
+		navigationIndex = loadNavigationIndex()
+		navigationIndexLoaded = true
+	}
+	return navigationIndex
+}
+
+func loadNavigationIndex() *NavigationIndex {
+	bundlesData, err := os.ReadFile(util.BundlesGeneratedPath)
+	if err != nil {
+		logrus.Warnf("Unable to read %s, favorites will not be validated: %v", util.BundlesGeneratedPath, err)
+		return nil
+	}
+	fedModulesData, err := os.ReadFile(util.FedModulesGeneratedPath)
+	if err != nil {
+		logrus.Warnf("Unable to read %s, favorites will not be validated: %v", util.FedModulesGeneratedPath, err)
+		return nil
+	}
+
+	index, err := NewNavigationIndex(bundlesData, fedModulesData)
+	if err != nil {
+		logrus.Errorf("Unable to build navigation index, favorites will not be validated: %v", err)
+		return nil
+	}
+	return index
+}
+
+// navigationVersion identifies the navigation favorites are resolved against, it is empty when favorites
+// are not resolved.
+func navigationVersion() string {
+	if index := getNavigationIndex(); index != nil {
+		return index.version
+	}
+	return ""
+}
+
+// validateFavoritePathname returns the pathname to store for a new favorite. Moved routes are redirected
+// and routes missing from the navigation are rejected.
+func validateFavoritePathname(pathname string) (string, error) {
+	index := getNavigationIndex()
+	if index == nil {
+		return pathname, nil
+	}
+
+	if _, ok := index.Lookup(pathname); ok {
+		return pathname, nil
+	}
+	if target, ok := Redirect(config.Get().FavoritesConfig.Redirects, pathname); ok {
+		if _, ok := index.Lookup(target); ok {
+			return target, nil
+		}
+	}
+	return pathname, fmt.Errorf("%w: favorite page %s does not exist in the navigation", util.ErrBadRequest, pathname)
+}
+
+func resolveFavoritePage(index *NavigationIndex, page *models.FavoritePage) {
+	entry, ok := index.Lookup(page.Pathname)
+	page.ResolvedTitle = entry.Title
+	page.Bundle = entry.Bundle
+	page.Icon = entry.Icon
+	page.Stale = !ok
+}
+
+// ResolveFavoritePages adds the navigation title, bundle and icon to favorites and flags favorites whose
+// route no longer exists. It never writes, favorites of moved routes are redirected by RedirectFavoritePages.
+func ResolveFavoritePages(pages []models.FavoritePage) []models.FavoritePage {
+	index := getNavigationIndex()
+	if index == nil {
+		return pages
+	}
+
+	for i := range pages {
+		resolveFavoritePage(index, &pages[i])
+	}
+	return pages
+}
+
+// redirectFavoritePage moves a stale favorite to its new route. When the user already has a favorite of
+// the new route, the stale duplicate is removed.
+func redirectFavoritePage(index *NavigationIndex, redirects map[string]string, page models.FavoritePage) (bool, error) {
+	target, ok := Redirect(redirects, page.Pathname)
+	if !ok {
+		return false, nil
+	}
+	if _, ok := index.Lookup(target); !ok {
+		return false, nil
+	}
+
+	return true, database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := bumpFavoritesVersion(tx, page.UserIdentityID, ""); err != nil {
+			return err
+		}
+		var duplicates int64
+		err := tx.Model(&models.FavoritePage{}).
+			Where("user_identity_id = ? AND pathname = ?", page.UserIdentityID, target).
+			Count(&duplicates).Error
+		if err != nil {
+			return err
+		}
+		if duplicates > 0 {
+			return tx.Unscoped().Delete(&page).Error
+		}
+		return tx.Model(&page).Updates(map[string]interface{}{
+			"pathname": target,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+	})
+}
+
+// likePrefixPattern matches the values starting with prefix, the LIKE wildcards in prefix are escaped.
+func likePrefixPattern(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
+
+// RedirectFavoritePages moves the stored favorites of every user whose route moved to the new route of
+// the configured redirects and returns how many were moved. Favorites whose new route is missing from
+// the navigation are kept as they are. Moved favorites no longer match the redirects, running it again
+// only moves the favorites saved since.
+func RedirectFavoritePages() (int, error) {
+	index := getNavigationIndex()
+	redirects := config.Get().FavoritesConfig.Redirects
+	if index == nil || len(redirects) == 0 {
+		return 0, nil
+	}
+
+	redirected := 0
+	for source := range redirects {
+		source = normalizePathname(source)
+		var pages []models.FavoritePage
+		err := database.DB.Where(`pathname = ? OR pathname LIKE ? ESCAPE '\'`, source, likePrefixPattern(source+"/")).Find(&pages).Error
+		if err != nil {
+			return redirected, err
+		}
+		for _, page := range pages {
+			if _, ok := index.Lookup(page.Pathname); ok {
+				continue
+			}
+			moved, err := redirectFavoritePage(index, redirects, page)
+			if err != nil {
+				return redirected, err
+			}
+			if moved {
+				redirected++
+			}
+		}
+	}
+	return redirected, nil
+}
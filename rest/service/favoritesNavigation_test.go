@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBundlesGenerated = `[
+	{
+		"id": "insights",
+		"title": "Red Hat Enterprise Linux",
+		"navItems": [
+			{"id": "overview", "title": "Dashboard", "href": "/insights/dashboard"},
+			{
+				"title": "Inventory",
+				"expandable": true,
+				"icon": "InsightsIcon",
+				"routes": [
+					{"id": "systems", "title": "Systems", "href": "/insights/inventory/"},
+					{"id": "docs", "title": "Docs", "href": "https://docs.redhat.com", "isExternal": true}
+				]
+			}
+		]
+	}
+]`
+
+const testFedModulesGenerated = `{
+	"$schema": "../../../modulesSchema.json",
+	"landing": {"modules": [{"id": "landing", "routes": [{"pathname": "/"}, {"pathname": "/insights"}]}]},
+	"advisor": {"modules": [{"id": "advisor", "routes": [{"pathname": "/insights/advisor"}]}]}
+}`
+
+func setupNavigationIndex(t *testing.T, redirects map[string]string) *NavigationIndex {
+	t.Helper()
+	index, err := NewNavigationIndex([]byte(testBundlesGenerated), []byte(testFedModulesGenerated))
+	require.NoError(t, err)
+	SetNavigationIndex(index)
+
+	cfg := config.Get()
+	original := cfg.FavoritesConfig
+	cfg.FavoritesConfig = config.FavoritesConfig{Redirects: redirects}
+	t.Cleanup(func() {
+		SetNavigationIndex(nil)
+		cfg.FavoritesConfig = original
+	})
+	return index
+}
+
+func TestNavigationIndexLookup(t *testing.T) {
+	index := setupNavigationIndex(t, nil)
+
+	tests := []struct {
+		pathname string
+		found    bool
+		title    string
+		icon     string
+	}{
+		{pathname: "/insights/dashboard", found: true, title: "Dashboard"},
+		{pathname: "/insights/inventory/host-1?tab=general", found: true, title: "Systems", icon: "InsightsIcon"},
+		{pathname: "/insights/advisor/recommendations", found: true},
+		{pathname: "/insights", found: true},
+		{pathname: "/insights/removed-app", found: false},
+		{pathname: "https://docs.redhat.com", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pathname, func(t *testing.T) {
+			entry, found := index.Lookup(tt.pathname)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.title, entry.Title)
+			assert.Equal(t, tt.icon, entry.Icon)
+		})
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	redirects := map[string]string{"/insights/old-app": "/insights/advisor"}
+
+	target, ok := Redirect(redirects, "/insights/old-app/recommendations/")
+	assert.True(t, ok)
+	assert.Equal(t, "/insights/advisor/recommendations", target)
+
+	_, ok = Redirect(redirects, "/insights/old-application")
+	assert.False(t, ok)
+}
+
+func TestValidateFavoritePathname(t *testing.T) {
+	setupNavigationIndex(t, map[string]string{"/insights/old-dashboard": "/insights/dashboard"})
+
+	pathname, err := validateFavoritePathname("/insights/dashboard")
+	require.NoError(t, err)
+	assert.Equal(t, "/insights/dashboard", pathname)
+
+	pathname, err = validateFavoritePathname("/insights/old-dashboard")
+	require.NoError(t, err)
+	assert.Equal(t, "/insights/dashboard", pathname)
+
+	_, err = validateFavoritePathname("/insights/removed-app")
+	assert.True(t, errors.Is(err, util.ErrBadRequest))
+}
+
+func TestResolveFavoritePages(t *testing.T) {
+	user := setupTestUser(t)
+	seedFavoritePages(t, user.ID, []models.FavoritePage{
+		createTestFavoritePage(t, "/insights/inventory", true, user.ID),
+		createTestFavoritePage(t, "/insights/removed-app", true, user.ID),
+		createTestFavoritePage(t, "/insights/old-dashboard", true, user.ID),
+	})
+	setupNavigationIndex(t, map[string]string{
+		"/insights/old-dashboard": "/insights/dashboard",
+	})
+
+	pages, err := GetUserActiveFavoritePages(user.ID)
+	require.NoError(t, err)
+	resolved := ResolveFavoritePages(pages)
+
+	require.Len(t, resolved, 3)
+	assert.Equal(t, "Systems", resolved[0].ResolvedTitle)
+	assert.Equal(t, "insights", resolved[0].Bundle)
+	assert.Equal(t, "InsightsIcon", resolved[0].Icon)
+	assert.False(t, resolved[0].Stale)
+	assert.True(t, resolved[1].Stale)
+	assert.True(t, resolved[2].Stale, "reads do not redirect favorites")
+
+	stored, err := GetAllUserFavoritePages(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/insights/old-dashboard", stored[2].Pathname)
+}
+
+func TestRedirectFavoritePages(t *testing.T) {
+	user := setupTestUser(t)
+	seedFavoritePages(t, user.ID, []models.FavoritePage{
+		createTestFavoritePage(t, "/insights/inventory", true, user.ID),
+		createTestFavoritePage(t, "/insights/old-dashboard", true, user.ID),
+		createTestFavoritePage(t, "/insights/old-inventory", true, user.ID),
+		createTestFavoritePage(t, "/insights/old-app", true, user.ID),
+	})
+	setupNavigationIndex(t, map[string]string{
+		"/insights/old-dashboard": "/insights/dashboard",
+		"/insights/old-inventory": "/insights/inventory",
+		"/insights/old-app":       "/insights/removed-app",
+	})
+
+	redirected, err := RedirectFavoritePages()
+	require.NoError(t, err)
+	assert.Equal(t, 2, redirected)
+
+	stored, err := GetAllUserFavoritePages(user.ID)
+	require.NoError(t, err)
+	pathnames := []string{}
+	for _, page := range stored {
+		pathnames = append(pathnames, page.Pathname)
+	}
+	// the redirect to an existing favorite removes the duplicate, a missing new route keeps the favorite
+	assert.ElementsMatch(t, []string{"/insights/inventory", "/insights/dashboard", "/insights/old-app"}, pathnames)
+
+	redirected, err = RedirectFavoritePages()
+	require.NoError(t, err)
+	assert.Equal(t, 0, redirected, "redirected favorites are not moved again")
+}
+
+func TestLikePrefixPattern(t *testing.T) {
+	assert.Equal(t, "/insights/old-app/%", likePrefixPattern("/insights/old-app/"))
+	assert.Equal(t, `/insights/old\_app\%/%`, likePrefixPattern("/insights/old_app%/"))
+}
+
+func TestSaveUserFavoritePageValidation(t *testing.T) {
+	user := setupTestUser(t)
+	setupNavigationIndex(t, nil)
+
+	_, _, err := SaveUserFavoritePage(user.ID, user.AccountId, createTestFavoritePage(t, "/insights/removed-app", true, user.ID), "")
+	assert.True(t, errors.Is(err, util.ErrBadRequest))
+
+	_, _, err = SaveUserFavoritePage(user.ID, user.AccountId, createTestFavoritePage(t, "/insights/dashboard", true, user.ID), "")
+	require.NoError(t, err)
+}
+
+func TestFavoritePagesETagNavigation(t *testing.T) {
+	setupNavigationIndex(t, nil)
+	etag := FavoritePagesETag(1)
+
+	// the same favorites resolve differently against another navigation
+	index, err := NewNavigationIndex([]byte(`[]`), []byte(testFedModulesGenerated))
+	require.NoError(t, err)
+	SetNavigationIndex(index)
+	assert.NotEqual(t, etag, FavoritePagesETag(1))
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"gorm.io/gorm"
+)
+
+type FavoritesImportMode string
+
+const (
+	// FavoritesImportMerge adds the imported favorites after the existing ones
+	FavoritesImportMerge FavoritesImportMode = "merge"
+	// FavoritesImportReplace removes all existing favorites and folders before the import
+	FavoritesImportReplace FavoritesImportMode = "replace"
+)
+
+func (im FavoritesImportMode) IsValid() error {
+	switch im {
+	case FavoritesImportMerge, FavoritesImportReplace:
+		return nil
+	}
+
+	return fmt.Errorf("%w: invalid favorites import mode. Expected one of %s, %s, got %s", util.ErrBadRequest, FavoritesImportMerge, FavoritesImportReplace, im)
+}
+
+type FavoritesImportResult struct {
+	Imported int `json:"imported"`
+	// Skipped favorites already exist for the user
+	Skipped int `json:"skipped"`
+	// Invalid favorites no longer exist in the navigation of this environment
+	Invalid []string `json:"invalid"`
+}
+
+func favoritesExportSecret() []byte {
+	return []byte(config.Get().FavoritesConfig.ExportSecret)
+}
+
+// ExportFavorites returns the signed, base64 encoded document of the user active favorites and folders.
+func ExportFavorites(userID uint) (string, error) {
+	folders, err := GetUserFavoriteFolders(userID)
+	if err != nil {
+		return "", err
+	}
+	pages, err := GetUserActiveFavoritePages(userID)
+	if err != nil {
+		return "", err
+	}
+
+	document := models.FavoritesDocument{
+		Version:    models.FavoritesDocumentVersion,
+		ExportedAt: time.Now().UTC(),
+		Folders:    []models.FavoritesDocumentFolder{},
+		Pages:      []models.FavoritesDocumentPage{},
+	}
+	folderNames := make(map[uint]string, len(folders))
+	for _, folder := range folders {
+		folderNames[folder.ID] = folder.Name
+		document.Folders = append(document.Folders, models.FavoritesDocumentFolder{Name: folder.Name, Position: folder.Position})
+	}
+	for _, page := range pages {
+		documentPage := models.FavoritesDocumentPage{Pathname: page.Pathname, Title: page.Title, Position: page.Position}
+		if page.FolderID != nil {
+			documentPage.Folder = folderNames[*page.FolderID]
+		}
+		document.Pages = append(document.Pages, documentPage)
+	}
+
+	return document.EncodeBase64(favoritesExportSecret())
+}
+
+// DecodeFavorites verifies the signature of an exported favorites document and decodes it.
+func DecodeFavorites(encoded string) (models.FavoritesDocument, error) {
+	document, err := models.DecodeFavoritesBase64(encoded, favoritesExportSecret())
+	if err != nil {
+		return document, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+	return document, nil
+}
+
+// importFavoriteFolders returns the folder IDs of the document folders by name. Folders with an existing name are reused.
+func importFavoriteFolders(tx *gorm.DB, userID uint, document models.FavoritesDocument) (map[string]uint, error) {
+	var existing []models.FavoriteFolder
+	if err := tx.Where("user_identity_id = ?", userID).Order(favoritesOrder).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	folderIDs := make(map[string]uint, len(existing)+len(document.Folders))
+	for _, folder := range existing {
+		folderIDs[folder.Name] = folder.ID
+	}
+
+	position := len(existing)
+	for _, documentFolder := range sortedDocumentFolders(document.Folders) {
+		if _, ok := folderIDs[documentFolder.Name]; ok {
+			continue
+		}
+		folder := models.FavoriteFolder{Name: documentFolder.Name, Position: position, UserIdentityID: userID}
+		if err := tx.Create(&folder).Error; err != nil {
+			return nil, err
+		}
+		folderIDs[folder.Name] = folder.ID
+		position++
+	}
+	return folderIDs, nil
+}
+
+func sortedDocumentFolders(folders []models.FavoritesDocumentFolder) []models.FavoritesDocumentFolder {
+	sorted := append([]models.FavoritesDocumentFolder{}, folders...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+	return sorted
+}
+
+func sortedDocumentPages(pages []models.FavoritesDocumentPage) []models.FavoritesDocumentPage {
+	sorted := append([]models.FavoritesDocumentPage{}, pages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+	return sorted
+}
+
+// ImportFavorites stores the favorites of a decoded document. Favorites are deduplicated by pathname and
+// favorites missing from the navigation of this environment are reported as invalid.
+func ImportFavorites(userID uint, document models.FavoritesDocument, mode FavoritesImportMode) (FavoritesImportResult, error) {
+	result := FavoritesImportResult{Invalid: []string{}}
+	if err := mode.IsValid(); err != nil {
+		return result, err
+	}
+	if err := document.IsValid(); err != nil {
+		return result, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := bumpFavoritesVersion(tx, userID, ""); err != nil {
+			return err
+		}
+		if mode == FavoritesImportReplace {
+			if err := tx.Unscoped().Where("user_identity_id = ?", userID).Delete(&models.FavoritePage{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_identity_id = ?", userID).Delete(&models.FavoriteFolder{}).Error; err != nil {
+				return err
+			}
+		}
+
+		folderIDs, err := importFavoriteFolders(tx, userID, document)
+		if err != nil {
+			return err
+		}
+
+		var userFavoritePages []models.FavoritePage
+		if err := tx.Where("user_identity_id = ?", userID).Find(&userFavoritePages).Error; err != nil {
+			return err
+		}
+
+		for _, documentPage := range sortedDocumentPages(document.Pages) {
+			pathname, err := validateFavoritePathname(documentPage.Pathname)
+			if errors.Is(err, util.ErrBadRequest) {
+				result.Invalid = append(result.Invalid, documentPage.Pathname)
+				continue
+			}
+
+			page := models.FavoritePage{Pathname: pathname, Title: documentPage.Title, Favorite: true, UserIdentityID: userID}
+			if exists, _ := CheckIfExistsInDB(userFavoritePages, page); exists {
+				result.Skipped++
+				continue
+			}
+			if documentPage.Folder != "" {
+				folderID := folderIDs[documentPage.Folder]
+				page.FolderID = &folderID
+			}
+
+			page.Position, err = nextFavoritePosition(tx, userID, page.FolderID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&page).Error; err != nil {
+				return err
+			}
+			userFavoritePages = append(userFavoritePages, page)
+			result.Imported++
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFavoritesExportSecret(t *testing.T, secret string) {
+	t.Helper()
+	cfg := config.Get()
+	original := cfg.FavoritesConfig.ExportSecret
+	cfg.FavoritesConfig.ExportSecret = secret
+	t.Cleanup(func() {
+		cfg.FavoritesConfig.ExportSecret = original
+	})
+}
+
+func TestFavoritesImportMode(t *testing.T) {
+	assert.NoError(t, FavoritesImportMerge.IsValid())
+	assert.NoError(t, FavoritesImportReplace.IsValid())
+	assert.True(t, errors.Is(FavoritesImportMode("append").IsValid(), util.ErrBadRequest))
+}
+
+func TestExportImportFavorites(t *testing.T) {
+	setupFavoritesExportSecret(t, "favorites-secret")
+	source := setupTestUser(t)
+	saveTestFavorites(t, source, testPathname1, testPathname2)
+	folder, err := CreateFavoriteFolder(source.ID, models.FavoriteFolderRequest{Name: "Insights"})
+	require.NoError(t, err)
+	pages, err := GetUserActiveFavoritePages(source.ID)
+	require.NoError(t, err)
+	_, _, err = MoveFavoritePages(source.ID, models.FavoritesMoveRequest{FolderID: &folder.ID, IDs: []uint{pages[1].ID}}, "")
+	require.NoError(t, err)
+
+	encoded, err := ExportFavorites(source.ID)
+	require.NoError(t, err)
+	document, err := DecodeFavorites(encoded)
+	require.NoError(t, err)
+	assert.Len(t, document.Pages, 2)
+
+	target := &models.UserIdentity{AccountId: "favorites-import-target"}
+	require.NoError(t, database.DB.Create(target).Error)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("user_identity_id = ?", target.ID).Delete(&models.FavoritePage{})
+		database.DB.Unscoped().Delete(target)
+	})
+	saveTestFavorites(t, target, testPathname1, testPathname3)
+
+	t.Run("Should merge and dedupe favorites", func(t *testing.T) {
+		result, err := ImportFavorites(target.ID, document, FavoritesImportMerge)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Imported)
+		assert.Equal(t, 1, result.Skipped)
+
+		active, err := GetUserActiveFavoritePages(target.ID)
+		require.NoError(t, err)
+		assert.Len(t, active, 3)
+
+		folders, err := GetUserFavoriteFolders(target.ID)
+		require.NoError(t, err)
+		require.Len(t, folders, 1)
+		assert.Equal(t, []string{testPathname2}, favoritePathnames(folders[0].FavoritePages))
+	})
+
+	t.Run("Should replace favorites", func(t *testing.T) {
+		result, err := ImportFavorites(target.ID, document, FavoritesImportReplace)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Imported)
+
+		active, err := GetUserActiveFavoritePages(target.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{testPathname1, testPathname2}, favoritePathnames(active))
+	})
+
+	t.Run("Should report favorites missing from the navigation", func(t *testing.T) {
+		setupNavigationIndex(t, nil)
+		result, err := ImportFavorites(target.ID, models.FavoritesDocument{
+			Version: models.FavoritesDocumentVersion,
+			Pages:   []models.FavoritesDocumentPage{{Pathname: "/insights/removed-app"}},
+		}, FavoritesImportMerge)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/insights/removed-app"}, result.Invalid)
+	})
+
+	t.Run("Should reject documents signed in another environment", func(t *testing.T) {
+		setupFavoritesExportSecret(t, "other-secret")
+		_, err := DecodeFavorites(encoded)
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+}
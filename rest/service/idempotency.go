@@ -0,0 +1,127 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key header.
+const MaxIdempotencyKeyLength = 255
+
+// idempotencyReplayedHeaders are the response headers stored with the response and replayed.
+var idempotencyReplayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyRequestHash identifies a request by its method, URL and body.
+func IdempotencyRequestHash(method string, uri string, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", method, uri)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func idempotencyRecordQuery(tx *gorm.DB, userId uint, key string) *gorm.DB {
+	return tx.Where("user_identity_id = ? AND key = ?", userId, key)
+}
+
+// BeginIdempotentRequest reserves the key of the user for a request. It returns the stored record when
+// the key was already used for the same request, the request then has to be answered with it. Keys
+// reused for a different request are rejected as unprocessable, and keys of requests still being
+// handled as conflicts. Keys of requests that did not complete within lockTimeout are reserved again.
+func BeginIdempotentRequest(userId uint, key string, requestHash string, ttl time.Duration, lockTimeout time.Duration, now time.Time) (*models.IdempotencyRecord, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: the Idempotency-Key header must have between 1 and %d characters", util.ErrBadRequest, MaxIdempotencyKeyLength)
+	}
+
+	var stored *models.IdempotencyRecord
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		reserved := models.IdempotencyRecord{UserIdentityID: userId, Key: key, RequestHash: requestHash, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reserved)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		var record models.IdempotencyRecord
+		if err := idempotencyRecordQuery(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userId, key).First(&record).Error; err != nil {
+			return err
+		}
+		expired := !record.ExpiresAt.After(now)
+		abandoned := !record.Completed && record.CreatedAt.Add(lockTimeout).Before(now)
+		if expired || abandoned {
+			// the key is free again, reserve it for this request
+			return tx.Model(&record).Select("RequestHash", "Completed", "Status", "Header", "Body", "CreatedAt", "ExpiresAt").Updates(&models.IdempotencyRecord{
+				RequestHash: requestHash,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			}).Error
+		}
+		if record.RequestHash != requestHash {
+			return fmt.Errorf("%w: the Idempotency-Key was already used for a different request", util.ErrUnprocessable)
+		}
+		if !record.Completed {
+			return fmt.Errorf("%w: a request with the same Idempotency-Key is still being processed", util.ErrConflict)
+		}
+		stored = &record
+		return nil
+	})
+	return stored, err
+}
+
+// CompleteIdempotentRequest stores the response of a reserved request so repeats are answered with it.
+func CompleteIdempotentRequest(userId uint, key string, status int, header http.Header, body []byte) error {
+	storedHeader := map[string]string{}
+	for _, name := range idempotencyReplayedHeaders {
+		if value := header.Get(name); value != "" {
+			storedHeader[name] = value
+		}
+	}
+	return idempotencyRecordQuery(database.DB.Model(&models.IdempotencyRecord{}), userId, key).Updates(map[string]interface{}{
+		"completed": true,
+		"status":    status,
+		"header":    datatypes.NewJSONType(storedHeader),
+		"body":      body,
+	}).Error
+}
+
+// ReleaseIdempotentRequest frees the key of a request that failed, so it can be retried.
+func ReleaseIdempotentRequest(userId uint, key string) error {
+	return idempotencyRecordQuery(database.DB, userId, key).Where("completed = ?", false).Delete(&models.IdempotencyRecord{}).Error
+}
+
+// RemoveExpiredIdempotencyRecords deletes the records that are no longer replayed.
+func RemoveExpiredIdempotencyRecords(now time.Time) (int64, error) {
+	result := database.DB.Where("expires_at <= ?", now).Delete(&models.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// RunIdempotencyJanitor removes the expired records on every interval, it does not return.
+func RunIdempotencyJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if removed, err := RemoveExpiredIdempotencyRecords(now); err != nil {
+			logrus.Errorf("Unable to remove expired idempotency records: %v", err)
+		} else if removed > 0 {
+			logrus.Debugf("Removed %d expired idempotency records", removed)
+		}
+	}
+}
+
+// IsIdempotencyReplayable reports whether a response is stored for repeats. Server errors are not,
+// the key is released so the client can retry.
+func IsIdempotencyReplayable(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusTooManyRequests
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupIdempotencyUser(t *testing.T) *models.UserIdentity {
+	t.Helper()
+	user := setupTestUser(t)
+	t.Cleanup(func() {
+		database.DB.Where("user_identity_id = ?", user.ID).Delete(&models.IdempotencyRecord{})
+	})
+	return user
+}
+
+func TestIdempotencyRequestHash(t *testing.T) {
+	hash := IdempotencyRequestHash(http.MethodPost, "/favorite-pages", []byte(`{"pathname":"/foo"}`))
+	assert.Equal(t, hash, IdempotencyRequestHash(http.MethodPost, "/favorite-pages", []byte(`{"pathname":"/foo"}`)))
+	assert.NotEqual(t, hash, IdempotencyRequestHash(http.MethodPost, "/favorite-pages", []byte(`{"pathname":"/bar"}`)))
+	assert.NotEqual(t, hash, IdempotencyRequestHash(http.MethodPost, "/favorite-pages/import", []byte(`{"pathname":"/foo"}`)))
+}
+
+func TestBeginIdempotentRequest(t *testing.T) {
+	ttl := time.Hour
+	lockTimeout := 5 * time.Minute
+	now := time.Now()
+	hash := IdempotencyRequestHash(http.MethodPost, "/favorite-pages", []byte(`{"pathname":"/foo"}`))
+
+	t.Run("should replay completed requests", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+
+		stored, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		header.Set("X-Request-Id", "not stored")
+		require.NoError(t, CompleteIdempotentRequest(user.ID, "key", http.StatusOK, header, []byte(`{"data":[]}`)))
+
+		stored, err = BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now.Add(time.Minute))
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, http.StatusOK, stored.Status)
+		assert.Equal(t, map[string]string{"Content-Type": "application/json"}, stored.Header.Data())
+		assert.Equal(t, `{"data":[]}`, string(stored.Body))
+	})
+
+	t.Run("should reject keys reused for a different request", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+
+		_, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		require.NoError(t, err)
+		require.NoError(t, CompleteIdempotentRequest(user.ID, "key", http.StatusOK, http.Header{}, nil))
+
+		_, err = BeginIdempotentRequest(user.ID, "key", "other", ttl, lockTimeout, now)
+		assert.ErrorIs(t, err, util.ErrUnprocessable)
+	})
+
+	t.Run("should reject concurrent requests", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+
+		_, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		require.NoError(t, err)
+		_, err = BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		assert.ErrorIs(t, err, util.ErrConflict)
+		_, err = BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now.Add(lockTimeout-time.Second))
+		assert.ErrorIs(t, err, util.ErrConflict)
+	})
+
+	t.Run("should reserve keys of abandoned requests", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+
+		_, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		require.NoError(t, err)
+		stored, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now.Add(2*lockTimeout))
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("should reserve expired keys for a different request", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+
+		_, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		require.NoError(t, err)
+		require.NoError(t, CompleteIdempotentRequest(user.ID, "key", http.StatusCreated, http.Header{}, nil))
+
+		stored, err := BeginIdempotentRequest(user.ID, "key", "other", ttl, lockTimeout, now.Add(ttl))
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+
+		var record models.IdempotencyRecord
+		require.NoError(t, database.DB.Where("user_identity_id = ? AND key = ?", user.ID, "key").First(&record).Error)
+		assert.Equal(t, "other", record.RequestHash)
+		assert.False(t, record.Completed)
+	})
+
+	t.Run("should keep keys of different users apart", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+		other := setupIdempotencyUser(t)
+
+		_, err := BeginIdempotentRequest(user.ID, "key", hash, ttl, lockTimeout, now)
+		require.NoError(t, err)
+		stored, err := BeginIdempotentRequest(other.ID, "key", "other", ttl, lockTimeout, now)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("should validate the key", func(t *testing.T) {
+		user := setupIdempotencyUser(t)
+
+		_, err := BeginIdempotentRequest(user.ID, "", hash, ttl, lockTimeout, now)
+		assert.ErrorIs(t, err, util.ErrBadRequest)
+		_, err = BeginIdempotentRequest(user.ID, strings.Repeat("k", MaxIdempotencyKeyLength+1), hash, ttl, lockTimeout, now)
+		assert.ErrorIs(t, err, util.ErrBadRequest)
+	})
+}
+
+func TestReleaseIdempotentRequest(t *testing.T) {
+	user := setupIdempotencyUser(t)
+	now := time.Now()
+
+	_, err := BeginIdempotentRequest(user.ID, "key", "hash", time.Hour, time.Minute, now)
+	require.NoError(t, err)
+	require.NoError(t, ReleaseIdempotentRequest(user.ID, "key"))
+
+	stored, err := BeginIdempotentRequest(user.ID, "key", "other", time.Hour, time.Minute, now)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestRemoveExpiredIdempotencyRecords(t *testing.T) {
+	user := setupIdempotencyUser(t)
+	now := time.Now()
+
+	_, err := BeginIdempotentRequest(user.ID, "expired", "hash", time.Minute, time.Minute, now.Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = BeginIdempotentRequest(user.ID, "active", "hash", time.Hour, time.Minute, now)
+	require.NoError(t, err)
+
+	removed, err := RemoveExpiredIdempotencyRecords(now)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, removed, int64(1))
+
+	var keys []string
+	require.NoError(t, database.DB.Model(&models.IdempotencyRecord{}).Where("user_identity_id = ?", user.ID).Pluck("key", &keys).Error)
+	assert.Equal(t, []string{"active"}, keys)
+}
@@ -6,8 +6,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"hash"
-	"reflect"
 	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/config"
@@ -17,6 +15,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type IntercomApp string
@@ -25,32 +24,6 @@ type IntercomPayload struct {
 	Dev  string `json:"dev,omitempty"`
 }
 
-const (
-	OpenShift           IntercomApp = "openshift"
-	Acs                 IntercomApp = "acs"
-	Ansible             IntercomApp = "ansible"
-	AnsibleDashboard    IntercomApp = "ansibleDashboard"
-	AutomationHub       IntercomApp = "automationHub"
-	AutomationAnalytics IntercomApp = "automationAnalytics"
-	DBAAS               IntercomApp = "dbaas"
-	ActivationKeys      IntercomApp = "activationKeys"
-	Advisor             IntercomApp = "advisor"
-	Compliance          IntercomApp = "compliance"
-	Connector           IntercomApp = "connector"
-	ContentSources      IntercomApp = "contentSources"
-	Dashboard           IntercomApp = "dashboard"
-	ImageBuilder        IntercomApp = "imageBuilder"
-	Inventory           IntercomApp = "inventory"
-	Malware             IntercomApp = "malware"
-	Patch               IntercomApp = "patch"
-	Policies            IntercomApp = "policies"
-	Registration        IntercomApp = "registration"
-	Remediations        IntercomApp = "remediations"
-	Ros                 IntercomApp = "ros"
-	Tasks               IntercomApp = "tasks"
-	Vulnerability       IntercomApp = "vulnerability"
-)
-
 func debugFavoritesIdentity(userId string) {
 	c := config.Get()
 	for _, i := range c.DebugConfig.DebugFavoriteIds {
@@ -60,13 +33,13 @@ func debugFavoritesIdentity(userId string) {
 	}
 }
 
+// IsValidApp checks the app against the intercom app registry loaded from the configuration.
 func (ib IntercomApp) IsValidApp() error {
-	switch ib {
-	case OpenShift, Ansible, Acs, AnsibleDashboard, AutomationHub, AutomationAnalytics, DBAAS, ActivationKeys, Advisor, Compliance, Connector, ContentSources, Dashboard, ImageBuilder, Inventory, Malware, Patch, Policies, Registration, Remediations, Ros, Tasks, Vulnerability:
+	if _, ok := config.Get().IntercomConfig.Lookup(string(ib)); ok {
 		return nil
 	}
 
-	return fmt.Errorf("invalid intercom bundle string. Expected one like %s, got %s", OpenShift, ib)
+	return fmt.Errorf("invalid intercom bundle string. Expected one like openshift, got %s", ib)
 }
 
 func parseUserBundles(user models.UserIdentity) (map[string]bool, error) {
@@ -108,28 +81,74 @@ func AddVisitedBundle(user models.UserIdentity, bundle string) (models.UserIdent
 	if err != nil {
 		return models.UserIdentity{}, err
 	}
-	// update the bundle reference for the function scope
+	visits, err := parseBundleVisits(user)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+	recordBundleVisit(visits, bundle, time.Now())
+	bundleVisits := datatypes.NewJSONType(visits)
+	err = database.DB.Model(&models.UserIdentity{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"visited_bundles": datatypes.JSON(b),
+		"bundle_visits":   bundleVisits,
+	}).Error
+	if err != nil {
+		return user, err
+	}
+
+	// the changes are only visible to the caller and the cache once they are stored
 	user.VisitedBundles = b
-	err = database.DB.Model(&user).Update("visited_bundles", bundles).Error
-	return user, err
+	user.BundleVisits = bundleVisits
+	refreshCachedIdentity(&user)
+	return user, nil
+}
+
+// GetUserIdentityResponse returns the user data complete with its related tables, as returned by the
+// user endpoint.
+func GetUserIdentityResponse(user models.UserIdentity, now time.Time) (models.UserIdentityResponse, error) {
+	updatedUser, err := GetUserIdentityData(user)
+	if err != nil {
+		return models.UserIdentityResponse{}, err
+	}
+
+	return models.UserIdentityResponse{
+		AccountId:        updatedUser.AccountId,
+		FirstLogin:       updatedUser.FirstLogin,
+		DayOne:           updatedUser.DayOne,
+		LastLogin:        updatedUser.LastLogin,
+		LoginSummary:     GetLoginSummary(updatedUser, now),
+		LastVisitedPages: updatedUser.LastVisitedPages.Data(),
+		FavoritePages:    updatedUser.FavoritePages,
+		SelfReport:       updatedUser.SelfReport,
+		VisitedBundles:   updatedUser.VisitedBundles,
+		UIPreview:        updatedUser.UIPreview,
+		UIPreviewSeen:    updatedUser.UIPreviewSeen,
+		PreviewFeatures:  EnrolledPreviewFeatures(updatedUser),
+		ActiveWorkspace:  updatedUser.ActiveWorkspace,
+	}, nil
 }
 
 func GetVisitedBundles(user models.UserIdentity) (map[string]bool, error) {
 	return parseUserBundles(user)
 }
 
-// Create the user object and add the row if not already in DB
-func CreateIdentity(userId string, skipCache bool) (models.UserIdentity, error) {
+// Create the user object and add the row if not already in DB. New identities are seeded with the
+// starter favorites of their organization.
+func CreateIdentity(userId string, orgId string, skipCache bool) (models.UserIdentity, error) {
+	now := time.Now()
 	identity := models.UserIdentity{
 		AccountId:        userId,
 		FirstLogin:       true,
 		DayOne:           true,
-		LastLogin:        time.Now(),
+		LastLogin:        now,
+		SessionCount:     1,
+		LoginHistory:     datatypes.NewJSONType([]time.Time{now}),
 		LastVisitedPages: datatypes.NewJSONType([]models.VisitedPage{}),
 		FavoritePages:    []models.FavoritePage{},
 		SelfReport:       models.SelfReport{},
 		VisitedBundles:   nil,
-		ActiveWorkspace:  "default",
+		BundleVisits:     datatypes.NewJSONType(map[string]models.BundleVisit{}),
+		PreviewFeatures:  datatypes.NewJSONType(map[string]models.PreviewFeatureState{}),
+		ActiveWorkspace:  defaultActiveWorkspace,
 	}
 	err := json.Unmarshal([]byte(`{}`), &identity.VisitedBundles)
 	if err != nil {
@@ -137,17 +156,28 @@ func CreateIdentity(userId string, skipCache bool) (models.UserIdentity, error)
 	}
 
 	/**
-	* Because we pass the object from the middleware to the rest of the application,
-	* we don't have to worry about invalidation the cache, as the object is passed by reference
-	* saves a lot DB queries.
+	* Every service function that mutates the identity row has to call refreshCachedIdentity,
+	* which also invalidates the entry on the other replicas when the shared cache is used.
 	 */
 	cachedIdentity, ok := util.UsersCache.Get(userId)
 	if !skipCache && ok {
 		return cachedIdentity, nil
 	}
 
-	res := database.DB.Where("account_id = ?", userId).FirstOrCreate(&identity)
+	var stored models.UserIdentity
+	res := database.DB.Where("account_id = ?", userId).Limit(1).Find(&stored)
 	err = res.Error
+	if err == nil && res.RowsAffected > 0 {
+		identity = stored
+	} else if err == nil {
+		starterFavorites, seedErr := starterFavoritePages(orgId)
+		if seedErr != nil {
+			// a new user without starter favorites is better than a failed request
+			logrus.Errorf("Unable to load starter favorites of organization %s: %v", orgId, seedErr)
+		}
+		identity.FavoritePages = starterFavorites
+		err = database.DB.Create(&identity).Error
+	}
 
 	// set the cache after successful DB operation
 	if err == nil {
@@ -157,42 +187,35 @@ func CreateIdentity(userId string, skipCache bool) (models.UserIdentity, error)
 	return identity, err
 }
 
-func encodeKey(namespace string, userId string) (string, error) {
-	var intercomHash hash.Hash
-	var err error
-	c := config.Get()
-	v := reflect.ValueOf(c.IntercomConfig)
-	key := reflect.Indirect(v).FieldByName(string(namespace))
-	if key.IsValid() {
-		intercomHash = hmac.New(sha256.New, []byte(key.String()))
-		_, err = intercomHash.Write([]byte(userId))
-		if err != nil {
-			return "", err
-		}
-		return hex.EncodeToString(intercomHash.Sum(nil)), nil
+func encodeKey(secret string, userId string) (string, error) {
+	// missing secrets are reported on startup, do not encode with an empty key
+	if secret == "" {
+		return "", nil
 	}
 
-	// is not a valid key, do not encode
-	return "", nil
+	intercomHash := hmac.New(sha256.New, []byte(secret))
+	_, err := intercomHash.Write([]byte(userId))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(intercomHash.Sum(nil)), nil
 }
 
 func GetUserIntercomHash(userId string, namespace IntercomApp) (IntercomPayload, error) {
-	err := namespace.IsValidApp()
 	response := IntercomPayload{}
-	if err != nil {
+	secrets, ok := config.Get().IntercomConfig.Lookup(string(namespace))
+	if !ok {
 		logrus.Infof("Unable to verify intercom namespace %s", namespace)
 		return response, nil
 	}
-	devNamespace := fmt.Sprintf("%s_dev", namespace)
 
-	prodKey, err := encodeKey(string(namespace), userId)
+	prodKey, err := encodeKey(secrets.Prod, userId)
 	if err != nil {
 		return response, err
 	}
 	response.Prod = prodKey
 
-	devKey, err := encodeKey(devNamespace, userId)
-
+	devKey, err := encodeKey(secrets.Dev, userId)
 	if err != nil {
 		return response, err
 	}
@@ -200,13 +223,73 @@ func GetUserIntercomHash(userId string, namespace IntercomApp) (IntercomPayload,
 	return response, nil
 }
 
+// GetUserIntercomHashes returns the hashes for multiple apps at once. Unknown apps are left out of the response.
+func GetUserIntercomHashes(userId string, namespaces []IntercomApp) (map[string]IntercomPayload, error) {
+	response := make(map[string]IntercomPayload)
+	for _, namespace := range namespaces {
+		if err := namespace.IsValidApp(); err != nil {
+			logrus.Infof("Unable to verify intercom namespace %s", namespace)
+			continue
+		}
+		payload, err := GetUserIntercomHash(userId, namespace)
+		if err != nil {
+			return response, err
+		}
+		response[string(namespace)] = payload
+	}
+	return response, nil
+}
+
+// ReportIntercomConfiguration logs every problem found in the intercom app registry.
+func ReportIntercomConfiguration() {
+	intercomConfig := config.Get().IntercomConfig
+	for _, problem := range intercomConfig.Problems {
+		logrus.Errorf("intercom configuration (%s): %s", intercomConfig.Source, problem)
+	}
+	logrus.Infof("Loaded %d intercom apps from %s", len(intercomConfig.Apps), intercomConfig.Source)
+}
+
+// FindUserIdentity returns the stored identity of the account without creating it. The cache is
+// skipped so support engineers always look at the stored state.
+func FindUserIdentity(accountId string) (models.UserIdentity, error) {
+	var identity models.UserIdentity
+	result := database.DB.Where("account_id = ?", accountId).Limit(1).Find(&identity)
+	if result.Error != nil {
+		return identity, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return identity, gorm.ErrRecordNotFound
+	}
+	return identity, nil
+}
+
+// EvictCachedIdentity drops the cached identity of the account on every replica, the next request
+// of the user reads it from the database.
+func EvictCachedIdentity(accountId string) {
+	util.UsersCache.Delete(accountId)
+}
+
+// refreshCachedIdentity stores the mutated identity in the cache and invalidates stale copies
+// held by other replicas.
+func refreshCachedIdentity(identity *models.UserIdentity) {
+	util.UsersCache.Refresh(identity.AccountId, *identity)
+}
+
 func UpdateUserPreview(identity *models.UserIdentity, preview bool) error {
 	identity.UIPreview = preview
-	return database.DB.Model(identity).Update("ui_preview", preview).Error
+	err := database.DB.Model(identity).Update("ui_preview", preview).Error
+	if err == nil {
+		refreshCachedIdentity(identity)
+	}
+	return err
 }
 
 func MarkPreviewSeen(identity *models.UserIdentity) error {
-	return database.DB.Model(identity).Updates(models.UserIdentity{UIPreviewSeen: true}).Error
+	err := database.DB.Model(identity).Updates(models.UserIdentity{UIPreviewSeen: true}).Error
+	if err == nil {
+		refreshCachedIdentity(identity)
+	}
+	return err
 }
 
 func UpdateActiveWorkspace(identity *models.UserIdentity, workspace string) error {
@@ -215,7 +298,7 @@ func UpdateActiveWorkspace(identity *models.UserIdentity, workspace string) erro
 
 	// set the cache after successful DB operation
 	if err == nil {
-		util.UsersCache.Set(identity.AccountId, *identity)
+		refreshCachedIdentity(identity)
 	}
 
 	return err
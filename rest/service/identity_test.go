@@ -0,0 +1,101 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectedIntercomHash(secret string, userId string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(userId))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func setupIntercomRegistry(t *testing.T, registry string, env map[string]string) config.IntercomConfig {
+	t.Helper()
+	apps, err := config.ParseIntercomApps([]byte(registry))
+	require.NoError(t, err)
+
+	cfg := config.Get()
+	original := cfg.IntercomConfig
+	t.Cleanup(func() {
+		cfg.IntercomConfig = original
+	})
+
+	cfg.IntercomConfig = config.NewIntercomConfig(apps, "test", func(name string) string {
+		return env[name]
+	})
+	return cfg.IntercomConfig
+}
+
+func TestIntercomRegistry(t *testing.T) {
+	registry := `
+- name: ansible
+  prodEnv: INTERCOM_ANSIBLE
+  devEnv: INTERCOM_ANSIBLE_DEV
+  aliases: [automationHub]
+- name: openshift
+  prodEnv: INTERCOM_OPENSHIFT
+  devEnv: INTERCOM_OPENSHIFT_DEV
+`
+	env := map[string]string{
+		"INTERCOM_ANSIBLE":     "ansible-prod",
+		"INTERCOM_ANSIBLE_DEV": "ansible-dev",
+		"INTERCOM_OPENSHIFT":   "openshift-prod",
+	}
+
+	t.Run("Should report missing secrets", func(t *testing.T) {
+		ic := setupIntercomRegistry(t, registry, env)
+		assert.Equal(t, []string{"intercom app openshift dev secret variable INTERCOM_OPENSHIFT_DEV is missing or empty"}, ic.Problems)
+	})
+
+	t.Run("Should report duplicate names", func(t *testing.T) {
+		ic := setupIntercomRegistry(t, registry+`
+- name: automationHub
+  prodEnv: INTERCOM_ANSIBLE
+  devEnv: INTERCOM_ANSIBLE_DEV
+`, env)
+		assert.Contains(t, ic.Problems, "intercom app name automationHub is registered more than once")
+	})
+
+	t.Run("Should accept JSON registry", func(t *testing.T) {
+		apps, err := config.ParseIntercomApps([]byte(`[{"name":"acs","prodEnv":"INTERCOM_ACS","devEnv":"INTERCOM_ACS_DEV"}]`))
+		require.NoError(t, err)
+		assert.Equal(t, "INTERCOM_ACS", apps[0].ProdEnv)
+	})
+
+	t.Run("Should hash app and alias with the same secrets", func(t *testing.T) {
+		setupIntercomRegistry(t, registry, env)
+		payload, err := GetUserIntercomHash("user-1", "automationHub")
+		require.NoError(t, err)
+		assert.Equal(t, expectedIntercomHash("ansible-prod", "user-1"), payload.Prod)
+		assert.Equal(t, expectedIntercomHash("ansible-dev", "user-1"), payload.Dev)
+
+		direct, err := GetUserIntercomHash("user-1", "ansible")
+		require.NoError(t, err)
+		assert.Equal(t, payload, direct)
+	})
+
+	t.Run("Should not hash with a missing secret", func(t *testing.T) {
+		setupIntercomRegistry(t, registry, env)
+		payload, err := GetUserIntercomHash("user-1", "openshift")
+		require.NoError(t, err)
+		assert.Equal(t, expectedIntercomHash("openshift-prod", "user-1"), payload.Prod)
+		assert.Empty(t, payload.Dev)
+	})
+
+	t.Run("Should return hashes for multiple apps and skip unknown apps", func(t *testing.T) {
+		setupIntercomRegistry(t, registry, env)
+		payload, err := GetUserIntercomHashes("user-1", []IntercomApp{"ansible", "openshift", "unknown"})
+		require.NoError(t, err)
+		assert.Len(t, payload, 2)
+		assert.Contains(t, payload, "ansible")
+		assert.Contains(t, payload, "openshift")
+	})
+}
@@ -1,27 +1,144 @@
 package service
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/sirupsen/logrus"
 	"gorm.io/datatypes"
-	"math"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// HandlePostLastVisitedPages inserts the most recent pages from chrome. Once they are added,
-// all older entries are removed from the table.
-func HandlePostLastVisitedPages(recentPages []models.VisitedPage, user *models.UserIdentity) error {
-	newPages := []models.VisitedPage{}
-	// Go's standard library doesn't include a min for integers, only floats
-	payloadLen := math.Min(float64(len(recentPages)), 10)
-	// just make sure we only get the added records
-	for i := 0; i < int(payloadLen); i++ {
-		newPages = append(newPages, recentPages[i])
+func visitedPageKey(page models.VisitedPage) string {
+	return page.Bundle + "|" + page.Pathname
+}
+
+// visitedPageAfter reports whether page a was visited after page b. Pages without a timestamp
+// were stored before timestamps were tracked and are considered the oldest.
+func visitedPageAfter(a models.VisitedPage, b models.VisitedPage) bool {
+	if a.Timestamp == nil {
+		return false
 	}
-	visitedPages := datatypes.NewJSONType[[]models.VisitedPage](newPages)
+	if b.Timestamp == nil {
+		return true
+	}
+	return a.Timestamp.After(*b.Timestamp)
+}
 
-	logrus.Debugf("Pages to be inserted: %+v\n", recentPages)
-	err := database.DB.Model(&user).Updates(models.UserIdentity{LastVisitedPages: visitedPages}).Error
+// stampVisitedPages fills in missing timestamps of incoming pages. Lists are ordered from the most recent
+// page, so each following page is stamped a millisecond earlier to keep the client order.
+func stampVisitedPages(pages []models.VisitedPage, now time.Time) []models.VisitedPage {
+	stamped := make([]models.VisitedPage, 0, len(pages))
+	for i, page := range pages {
+		if page.Timestamp == nil || page.Timestamp.After(now) {
+			timestamp := now.Add(-time.Duration(i) * time.Millisecond)
+			page.Timestamp = &timestamp
+		}
+		stamped = append(stamped, page)
+	}
+	return stamped
+}
+
+// mergeVisitedPages merges incoming pages into the stored history. Pages are deduplicated by pathname and bundle,
+// keeping the most recent visit, and the history is trimmed to the per bundle and per user limits.
+func mergeVisitedPages(stored []models.VisitedPage, incoming []models.VisitedPage, maxPages int, maxPagesPerBundle int) []models.VisitedPage {
+	latest := make(map[string]models.VisitedPage)
+	order := []string{}
+	for _, page := range append(append([]models.VisitedPage{}, incoming...), stored...) {
+		key := visitedPageKey(page)
+		current, ok := latest[key]
+		if !ok {
+			order = append(order, key)
+			latest[key] = page
+			continue
+		}
+		if visitedPageAfter(page, current) {
+			latest[key] = page
+		}
+	}
+
+	merged := make([]models.VisitedPage, 0, len(order))
+	for _, key := range order {
+		merged = append(merged, latest[key])
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return visitedPageAfter(merged[i], merged[j])
+	})
+
+	bundleCount := make(map[string]int)
+	pages := []models.VisitedPage{}
+	for _, page := range merged {
+		if len(pages) >= maxPages {
+			break
+		}
+		if bundleCount[page.Bundle] >= maxPagesPerBundle {
+			continue
+		}
+		bundleCount[page.Bundle]++
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// HandlePostLastVisitedPages merges the most recent pages from chrome into the stored history. The stored
+// row is locked while merging so concurrent tabs do not overwrite each other.
+func HandlePostLastVisitedPages(recentPages []models.VisitedPage, user *models.UserIdentity) error {
+	cfg := config.Get().LastVisitedConfig
+	incoming := stampVisitedPages(recentPages, time.Now().UTC())
+	logrus.Debugf("Pages to be merged: %+v\n", incoming)
+
+	var visitedPages datatypes.JSONType[[]models.VisitedPage]
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var stored models.UserIdentity
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "last_visited_pages").First(&stored, user.ID).Error
+		if err != nil {
+			return err
+		}
+
+		pages := mergeVisitedPages(stored.LastVisitedPages.Data(), incoming, cfg.MaxPages, cfg.MaxPagesPerBundle)
+		visitedPages = datatypes.NewJSONType(pages)
+		return tx.Model(&stored).Update("last_visited_pages", visitedPages).Error
+	})
+	if err == nil {
+		user.LastVisitedPages = visitedPages
+		refreshCachedIdentity(user)
+	}
 
 	return err
 }
+
+// GetLastVisitedPages returns the stored history, optionally filtered by bundle.
+func GetLastVisitedPages(user models.UserIdentity, bundle string) []models.VisitedPage {
+	pages := user.LastVisitedPages.Data()
+	if bundle == "" {
+		return pages
+	}
+
+	filtered := []models.VisitedPage{}
+	for _, page := range pages {
+		if page.Bundle == bundle {
+			filtered = append(filtered, page)
+		}
+	}
+	return filtered
+}
+
+// ValidateLastVisitedRequest combines the single visit event and the list of pages of a request.
+func ValidateLastVisitedRequest(request models.LastVisitedRequest) ([]models.VisitedPage, error) {
+	pages := request.Pages
+	if request.Page != nil {
+		pages = append([]models.VisitedPage{*request.Page}, pages...)
+	}
+	for _, page := range pages {
+		if page.Pathname == "" {
+			return nil, fmt.Errorf("%w: last visited page pathname is required", util.ErrBadRequest)
+		}
+	}
+	return pages, nil
+}
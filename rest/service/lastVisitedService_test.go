@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func visitedPageAt(bundle string, pathname string, timestamp time.Time) models.VisitedPage {
+	return models.VisitedPage{Bundle: bundle, Pathname: pathname, Title: pathname, Timestamp: &timestamp}
+}
+
+func pathnames(pages []models.VisitedPage) []string {
+	names := []string{}
+	for _, page := range pages {
+		names = append(names, page.Pathname)
+	}
+	return names
+}
+
+func TestMergeVisitedPages(t *testing.T) {
+	now := time.Now()
+	stored := []models.VisitedPage{
+		visitedPageAt("insights", "/insights/advisor", now.Add(-time.Minute)),
+		visitedPageAt("openshift", "/openshift/overview", now.Add(-time.Hour)),
+		{Bundle: "insights", Pathname: "/insights/legacy"},
+	}
+
+	t.Run("Should dedupe by pathname and bundle keeping the latest visit", func(t *testing.T) {
+		incoming := []models.VisitedPage{visitedPageAt("insights", "/insights/advisor", now)}
+		pages := mergeVisitedPages(stored, incoming, 10, 10)
+		assert.Equal(t, []string{"/insights/advisor", "/openshift/overview", "/insights/legacy"}, pathnames(pages))
+		assert.True(t, pages[0].Timestamp.Equal(now))
+	})
+
+	t.Run("Should keep stale events behind newer stored visits", func(t *testing.T) {
+		incoming := []models.VisitedPage{visitedPageAt("openshift", "/openshift/overview", now.Add(-time.Hour*2))}
+		pages := mergeVisitedPages(stored, incoming, 10, 10)
+		assert.True(t, pages[1].Timestamp.Equal(now.Add(-time.Hour)))
+	})
+
+	t.Run("Should keep the same pathname in different bundles", func(t *testing.T) {
+		incoming := []models.VisitedPage{visitedPageAt("openshift", "/insights/advisor", now)}
+		pages := mergeVisitedPages(stored, incoming, 10, 10)
+		assert.Len(t, pages, 4)
+	})
+
+	t.Run("Should enforce per user and per bundle caps", func(t *testing.T) {
+		incoming := []models.VisitedPage{visitedPageAt("insights", "/insights/inventory", now)}
+		pages := mergeVisitedPages(stored, incoming, 10, 2)
+		assert.Equal(t, []string{"/insights/inventory", "/insights/advisor", "/openshift/overview"}, pathnames(pages))
+
+		pages = mergeVisitedPages(stored, incoming, 2, 10)
+		assert.Equal(t, []string{"/insights/inventory", "/insights/advisor"}, pathnames(pages))
+	})
+}
+
+func TestStampVisitedPages(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	pages := stampVisitedPages([]models.VisitedPage{
+		{Bundle: "insights", Pathname: "/first"},
+		{Bundle: "insights", Pathname: "/second", Timestamp: &future},
+	}, now)
+
+	assert.True(t, pages[0].Timestamp.Equal(now))
+	assert.True(t, pages[1].Timestamp.Before(now))
+}
+
+func TestValidateLastVisitedRequest(t *testing.T) {
+	t.Run("Should combine single visit event with pages", func(t *testing.T) {
+		pages, err := ValidateLastVisitedRequest(models.LastVisitedRequest{
+			Page:  &models.VisitedPage{Bundle: "insights", Pathname: "/event"},
+			Pages: []models.VisitedPage{{Bundle: "insights", Pathname: "/list"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/event", "/list"}, pathnames(pages))
+	})
+
+	t.Run("Should reject pages without pathname", func(t *testing.T) {
+		_, err := ValidateLastVisitedRequest(models.LastVisitedRequest{Page: &models.VisitedPage{Bundle: "insights"}})
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+}
+
+func TestGetLastVisitedPagesByBundle(t *testing.T) {
+	user := models.UserIdentity{LastVisitedPages: datatypes.NewJSONType([]models.VisitedPage{
+		{Bundle: "insights", Pathname: "/insights/advisor"},
+		{Bundle: "openshift", Pathname: "/openshift/overview"},
+	})}
+
+	assert.Len(t, GetLastVisitedPages(user, ""), 2)
+	assert.Equal(t, []string{"/openshift/overview"}, pathnames(GetLastVisitedPages(user, "openshift")))
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
+	"github.com/RedHatInsights/chrome-service-backend/rest/connectionhub"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DrawerEventType      = "com.redhat.console.notifications.drawer"
+	drawerStateEventType = "com.redhat.console.notifications.drawer.state"
+	drawerEventSource    = "https://console.redhat.com/api/chrome-service/v1/notifications/drawer"
+	// maxDrawerStateUpdate limits the number of items changed by a single bulk request
+	maxDrawerStateUpdate = 500
+)
+
+type DrawerFilter string
+
+const (
+	DrawerFilterAll       DrawerFilter = ""
+	DrawerFilterUnread    DrawerFilter = "unread"
+	DrawerFilterRead      DrawerFilter = "read"
+	DrawerFilterDismissed DrawerFilter = "dismissed"
+)
+
+func (df DrawerFilter) IsValid() error {
+	switch df {
+	case DrawerFilterAll, DrawerFilterUnread, DrawerFilterRead, DrawerFilterDismissed:
+		return nil
+	}
+
+	return fmt.Errorf("%w: invalid drawer filter. Expected one of %s, %s, %s, got %s", util.ErrBadRequest, DrawerFilterUnread, DrawerFilterRead, DrawerFilterDismissed, df)
+}
+
+// DrawerAudience identifies the recipient of drawer events.
+type DrawerAudience struct {
+	UserID    uint
+	AccountID string
+	Username  string
+	OrgID     string
+}
+
+// StoreDrawerEvent persists a drawer event consumed from kafka. Redelivered events are ignored.
+// Broadcast events are never delivered by the connection hub and are not stored either.
+func StoreDrawerEvent(envelope cloudevents.KafkaEnvelope) error {
+	if envelope.Type != DrawerEventType || envelope.Data.Broadcast {
+		return nil
+	}
+
+	recipients := []models.DrawerEventRecipient{}
+	addRecipients := func(kind models.DrawerRecipientKind, values []string) {
+		for _, value := range values {
+			if value != "" {
+				recipients = append(recipients, models.DrawerEventRecipient{Kind: kind, Value: value})
+			}
+		}
+	}
+	addRecipients(models.DrawerRecipientUser, envelope.Data.Users)
+	addRecipients(models.DrawerRecipientUsername, envelope.Data.Usernames)
+	addRecipients(models.DrawerRecipientOrganization, envelope.Data.Organizations)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(envelope.Data.Payload)
+	if err != nil {
+		return err
+	}
+	eventTime := envelope.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	event := models.DrawerEvent{
+		EventID:    envelope.Id,
+		Source:     string(envelope.Source),
+		Time:       eventTime,
+		Payload:    datatypes.JSON(payload),
+		Recipients: recipients,
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Recipients").Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for i := range recipients {
+			recipients[i].DrawerEventID = event.ID
+		}
+		return tx.Create(&recipients).Error
+	})
+}
+
+// drawerRecipientEvents selects the IDs of the events delivered to the audience.
+func drawerRecipientEvents(tx *gorm.DB, audience DrawerAudience) *gorm.DB {
+	return tx.Model(&models.DrawerEventRecipient{}).
+		Select("drawer_event_id").
+		Where("(kind = ? AND value = ?) OR (kind = ? AND value = ?) OR (kind = ? AND value = ?)",
+			models.DrawerRecipientUser, audience.AccountID,
+			models.DrawerRecipientUsername, audience.Username,
+			models.DrawerRecipientOrganization, audience.OrgID)
+}
+
+func drawerItemsQuery(tx *gorm.DB, audience DrawerAudience, filter DrawerFilter) *gorm.DB {
+	query := tx.Table("drawer_events").
+		Joins("LEFT JOIN drawer_item_states ON drawer_item_states.drawer_event_id = drawer_events.id AND drawer_item_states.user_identity_id = ? AND drawer_item_states.deleted_at IS NULL", audience.UserID).
+		Where("drawer_events.deleted_at IS NULL").
+		Where("drawer_events.id IN (?)", drawerRecipientEvents(tx, audience))
+
+	dismissed := "COALESCE(drawer_item_states.dismissed, ?)"
+	read := "COALESCE(drawer_item_states.read, ?)"
+	switch filter {
+	case DrawerFilterDismissed:
+		return query.Where(dismissed+" = ?", false, true)
+	case DrawerFilterUnread:
+		query = query.Where(read+" = ?", false, false)
+	case DrawerFilterRead:
+		query = query.Where(read+" = ?", false, true)
+	}
+	return query.Where(dismissed+" = ?", false, false)
+}
+
+// ListDrawerItems returns a page of the audience drawer items, newest first, and the number of items matching the filter.
+func ListDrawerItems(audience DrawerAudience, filter DrawerFilter, limit int, offset int) ([]models.DrawerItem, int64, error) {
+	items := []models.DrawerItem{}
+	if err := filter.IsValid(); err != nil {
+		return items, 0, err
+	}
+
+	var total int64
+	if err := drawerItemsQuery(database.DB, audience, filter).Count(&total).Error; err != nil {
+		return items, 0, err
+	}
+
+	err := drawerItemsQuery(database.DB, audience, filter).
+		Select("drawer_events.id, drawer_events.event_id, drawer_events.source, drawer_events.time, drawer_events.payload, " +
+			"COALESCE(drawer_item_states.read, false) AS read, COALESCE(drawer_item_states.dismissed, false) AS dismissed").
+		Order("drawer_events.time DESC, drawer_events.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&items).Error
+	return items, total, err
+}
+
+// resolveDrawerItemIDs checks the requested items were delivered to the audience. All selects
+// every item that is not dismissed yet.
+func resolveDrawerItemIDs(tx *gorm.DB, audience DrawerAudience, request models.DrawerStateRequest) ([]uint, error) {
+	ids := []uint{}
+	if request.All {
+		err := drawerItemsQuery(tx, audience, DrawerFilterAll).Limit(maxDrawerStateUpdate).Pluck("drawer_events.id", &ids).Error
+		return ids, err
+	}
+	if len(request.IDs) == 0 {
+		return ids, fmt.Errorf("%w: no drawer items selected", util.ErrBadRequest)
+	}
+	if len(request.IDs) > maxDrawerStateUpdate {
+		return ids, fmt.Errorf("%w: at most %d drawer items can be updated at once", util.ErrBadRequest, maxDrawerStateUpdate)
+	}
+
+	err := tx.Model(&models.DrawerEvent{}).
+		Where("id IN ?", request.IDs).
+		Where("id IN (?)", drawerRecipientEvents(tx, audience)).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return ids, err
+	}
+	if len(ids) != len(uniqueIDs(request.IDs)) {
+		return ids, fmt.Errorf("drawer items %v: %w", request.IDs, gorm.ErrRecordNotFound)
+	}
+	return ids, nil
+}
+
+func uniqueIDs(ids []uint) map[uint]bool {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
+
+// updateDrawerItemsState upserts the column of the selected items and notifies the user connections.
+func updateDrawerItemsState(audience DrawerAudience, request models.DrawerStateRequest, column string, value bool) ([]uint, error) {
+	var ids []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ids, err = resolveDrawerItemIDs(tx, audience, request)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		states := make([]models.DrawerItemState, 0, len(ids))
+		for _, id := range ids {
+			state := models.DrawerItemState{UserIdentityID: audience.UserID, DrawerEventID: id}
+			if column == "read" {
+				state.Read = value
+			} else {
+				state.Dismissed = value
+			}
+			states = append(states, state)
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_identity_id"}, {Name: "drawer_event_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{column, "updated_at"}),
+		}).Create(&states).Error
+	})
+	if err == nil && len(ids) > 0 {
+		notifyDrawerStateChange(audience, map[string]interface{}{"ids": ids, column: value})
+	}
+	return ids, err
+}
+
+func MarkDrawerItemsRead(audience DrawerAudience, request models.DrawerStateRequest, read bool) ([]uint, error) {
+	return updateDrawerItemsState(audience, request, "read", read)
+}
+
+func DismissDrawerItems(audience DrawerAudience, request models.DrawerStateRequest) ([]uint, error) {
+	return updateDrawerItemsState(audience, request, "dismissed", true)
+}
+
+// notifyDrawerStateChange sends the state change to every open connection of the user so other
+// tabs can update their drawer without reloading it. The tabs may be connected to any replica.
+func notifyDrawerStateChange(audience DrawerAudience, payload map[string]interface{}) {
+	if audience.Username == "" {
+		return
+	}
+	message := connectionhub.WsMessage{
+		Usernames: []string{audience.Username},
+		Payload:   payload,
+	}
+	if err := publishEvent(drawerEventSource, drawerStateEventType, message); err != nil {
+		logrus.Errorf("Unable to notify drawer state change: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/cloudevents"
+	"github.com/RedHatInsights/chrome-service-backend/rest/connectionhub"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func drawerEnvelope(id string, eventTime time.Time, destinations connectionhub.WsMessage) cloudevents.KafkaEnvelope {
+	destinations.Payload = map[string]interface{}{"title": id}
+	envelope := cloudevents.KafkaEnvelope{}
+	envelope.Id = id
+	envelope.Type = DrawerEventType
+	envelope.Source = "https://notifications.example.com"
+	envelope.Time = eventTime
+	envelope.Data = destinations
+	return envelope
+}
+
+func TestNotificationDrawer(t *testing.T) {
+	user := setupTestUser(t)
+	audience := DrawerAudience{UserID: user.ID, AccountID: user.AccountId, Username: "drawer-user", OrgID: "drawer-org"}
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("1 = 1").Delete(&models.DrawerItemState{})
+		database.DB.Unscoped().Where("1 = 1").Delete(&models.DrawerEventRecipient{})
+		database.DB.Unscoped().Where("1 = 1").Delete(&models.DrawerEvent{})
+	})
+
+	now := time.Now()
+	for i, destinations := range []connectionhub.WsMessage{
+		{Users: []string{user.AccountId}},
+		{Usernames: []string{"drawer-user"}},
+		{Organizations: []string{"drawer-org"}},
+		{Organizations: []string{"other-org"}},
+		{Broadcast: true, Organizations: []string{"drawer-org"}},
+	} {
+		envelope := drawerEnvelope(fmt.Sprintf("event-%d", i), now.Add(time.Duration(i)*time.Minute), destinations)
+		require.NoError(t, StoreDrawerEvent(envelope))
+	}
+	require.NoError(t, StoreDrawerEvent(drawerEnvelope("event-0", now, connectionhub.WsMessage{Users: []string{user.AccountId}})), "redelivered events are ignored")
+
+	eventIDs := func(items []models.DrawerItem) []string {
+		ids := []string{}
+		for _, item := range items {
+			ids = append(ids, item.EventID)
+		}
+		return ids
+	}
+
+	t.Run("Should list events delivered to the user newest first", func(t *testing.T) {
+		items, total, err := ListDrawerItems(audience, DrawerFilterAll, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, []string{"event-2", "event-1"}, eventIDs(items))
+		assert.False(t, items[0].Read)
+
+		items, _, err = ListDrawerItems(audience, DrawerFilterAll, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"event-0"}, eventIDs(items))
+	})
+
+	t.Run("Should reject invalid filters", func(t *testing.T) {
+		_, _, err := ListDrawerItems(audience, DrawerFilter("archived"), 10, 0)
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+
+	items, _, err := ListDrawerItems(audience, DrawerFilterAll, 10, 0)
+	require.NoError(t, err)
+
+	t.Run("Should mark items read in bulk", func(t *testing.T) {
+		ids, err := MarkDrawerItemsRead(audience, models.DrawerStateRequest{IDs: []uint{items[0].ID, items[1].ID}}, true)
+		require.NoError(t, err)
+		assert.Len(t, ids, 2)
+
+		unread, total, err := ListDrawerItems(audience, DrawerFilterUnread, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []string{"event-0"}, eventIDs(unread))
+
+		_, err = MarkDrawerItemsRead(audience, models.DrawerStateRequest{IDs: []uint{items[0].ID}}, false)
+		require.NoError(t, err)
+		_, total, err = ListDrawerItems(audience, DrawerFilterRead, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("Should publish state changes to the tabs of the user on every replica", func(t *testing.T) {
+		events := []cloudevents.KafkaEnvelope{}
+		SetEventPublisher(func(event cloudevents.KafkaEnvelope) error {
+			events = append(events, event)
+			return nil
+		})
+		t.Cleanup(func() { SetEventPublisher(nil) })
+
+		ids, err := MarkDrawerItemsRead(audience, models.DrawerStateRequest{IDs: []uint{items[2].ID}}, false)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, drawerStateEventType, events[0].Type)
+		assert.Equal(t, []string{"drawer-user"}, events[0].Data.Usernames)
+		assert.Equal(t, ids, events[0].Data.Payload["ids"])
+		assert.Equal(t, false, events[0].Data.Payload["read"])
+	})
+
+	t.Run("Should mark all items read", func(t *testing.T) {
+		ids, err := MarkDrawerItemsRead(audience, models.DrawerStateRequest{All: true}, true)
+		require.NoError(t, err)
+		assert.Len(t, ids, 3)
+		_, total, err := ListDrawerItems(audience, DrawerFilterUnread, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("Should dismiss items", func(t *testing.T) {
+		_, err := DismissDrawerItems(audience, models.DrawerStateRequest{IDs: []uint{items[2].ID}})
+		require.NoError(t, err)
+
+		visible, _, err := ListDrawerItems(audience, DrawerFilterAll, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"event-2", "event-1"}, eventIDs(visible))
+
+		dismissed, _, err := ListDrawerItems(audience, DrawerFilterDismissed, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, dismissed, 1)
+		assert.True(t, dismissed[0].Read, "dismissing keeps the read state")
+	})
+
+	t.Run("Should reject items of other users", func(t *testing.T) {
+		var other models.DrawerEvent
+		require.NoError(t, database.DB.Where("event_id = ?", "event-3").First(&other).Error)
+		_, err := MarkDrawerItemsRead(audience, models.DrawerStateRequest{IDs: []uint{other.ID}}, true)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+}
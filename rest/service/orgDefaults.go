@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func validateDashboardName(dashboard models.AvailableTemplates) error {
+	if err := dashboard.IsValid(); err != nil {
+		return fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+	return nil
+}
+
+func findOrgDashboardTemplate(orgID string, dashboard models.AvailableTemplates) (models.OrgDashboardTemplate, bool, error) {
+	var template models.OrgDashboardTemplate
+	if orgID == "" {
+		return template, false, nil
+	}
+	result := database.DB.Where("org_id = ? AND name = ?", orgID, dashboard.String()).Limit(1).Find(&template)
+	return template, result.RowsAffected > 0, result.Error
+}
+
+// dashboardTemplateConfig returns the layout new dashboards are forked from, the org default when the
+// organization published one and the base template otherwise.
+func dashboardTemplateConfig(orgID string, dashboard models.AvailableTemplates) (models.TemplateConfig, error) {
+	orgTemplate, found, err := findOrgDashboardTemplate(orgID, dashboard)
+	if err != nil {
+		return models.TemplateConfig{}, err
+	}
+	if found {
+		return orgTemplate.TemplateConfig, nil
+	}
+	return BaseTemplates[dashboard].TemplateConfig, nil
+}
+
+func GetOrgDashboardTemplate(orgID string, dashboard models.AvailableTemplates) (models.OrgDashboardTemplate, error) {
+	if err := validateDashboardName(dashboard); err != nil {
+		return models.OrgDashboardTemplate{}, err
+	}
+	template, found, err := findOrgDashboardTemplate(orgID, dashboard)
+	if err == nil && !found {
+		err = gorm.ErrRecordNotFound
+	}
+	return template, err
+}
+
+// SetOrgDashboardTemplate publishes the org default of a dashboard. Layout variants left empty are
+// taken from the base template.
+func SetOrgDashboardTemplate(orgID string, dashboard models.AvailableTemplates, templateConfig models.TemplateConfig, updatedBy string) (models.OrgDashboardTemplate, error) {
+	if err := validateDashboardName(dashboard); err != nil {
+		return models.OrgDashboardTemplate{}, err
+	}
+	if orgID == "" {
+		return models.OrgDashboardTemplate{}, fmt.Errorf("%w: missing organization", util.ErrBadRequest)
+	}
+	if err := templateConfig.IsValid(); err != nil {
+		return models.OrgDashboardTemplate{}, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+
+	base := BaseTemplates[dashboard].TemplateConfig
+	if len(templateConfig.Sm.Data()) == 0 {
+		templateConfig.Sm = base.Sm
+	}
+	if len(templateConfig.Md.Data()) == 0 {
+		templateConfig.Md = base.Md
+	}
+	if len(templateConfig.Lg.Data()) == 0 {
+		templateConfig.Lg = base.Lg
+	}
+	if len(templateConfig.Xl.Data()) == 0 {
+		templateConfig.Xl = base.Xl
+	}
+
+	template := models.OrgDashboardTemplate{
+		OrgID:          orgID,
+		Name:           dashboard.String(),
+		TemplateConfig: templateConfig,
+		UpdatedBy:      updatedBy,
+	}
+	// a published default is replaced
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sm", "md", "lg", "xl", "updated_by", "updated_at"}),
+	}).Create(&template).Error
+	if err != nil {
+		return template, err
+	}
+	return GetOrgDashboardTemplate(orgID, dashboard)
+}
+
+// DeleteOrgDashboardTemplate removes the org default, new dashboards are forked from the base template again.
+func DeleteOrgDashboardTemplate(orgID string, dashboard models.AvailableTemplates) error {
+	if err := validateDashboardName(dashboard); err != nil {
+		return err
+	}
+	result := database.DB.Unscoped().Where("org_id = ? AND name = ?", orgID, dashboard.String()).Delete(&models.OrgDashboardTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func GetOrgStarterFavorites(orgID string) ([]models.OrgStarterFavorite, error) {
+	favorites := []models.OrgStarterFavorite{}
+	err := database.DB.Where("org_id = ?", orgID).Order("position, id").Find(&favorites).Error
+	return favorites, err
+}
+
+// SetOrgStarterFavorites replaces the starter favorites of the organization. Users that already exist keep
+// their favorites, only new identities are seeded.
+func SetOrgStarterFavorites(orgID string, request models.OrgStarterFavoritesRequest, updatedBy string) ([]models.OrgStarterFavorite, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("%w: missing organization", util.ErrBadRequest)
+	}
+	if err := request.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+
+	favorites := make([]models.OrgStarterFavorite, 0, len(request.Pages))
+	for i, page := range request.Pages {
+		pathname, err := validateFavoritePathname(page.Pathname)
+		if err != nil {
+			return nil, err
+		}
+		favorites = append(favorites, models.OrgStarterFavorite{
+			OrgID:     orgID,
+			Pathname:  pathname,
+			Title:     page.Title,
+			Position:  i,
+			UpdatedBy: updatedBy,
+		})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("org_id = ?", orgID).Delete(&models.OrgStarterFavorite{}).Error; err != nil {
+			return err
+		}
+		if len(favorites) == 0 {
+			return nil
+		}
+		return tx.Create(&favorites).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetOrgStarterFavorites(orgID)
+}
+
+// starterFavoritePages converts the org starter favorites to the favorite pages of a new identity.
+func starterFavoritePages(orgID string) ([]models.FavoritePage, error) {
+	pages := []models.FavoritePage{}
+	if orgID == "" {
+		return pages, nil
+	}
+	favorites, err := GetOrgStarterFavorites(orgID)
+	if err != nil {
+		return pages, err
+	}
+	for _, favorite := range favorites {
+		pages = append(pages, models.FavoritePage{
+			Pathname: favorite.Pathname,
+			Title:    favorite.Title,
+			Favorite: true,
+			Position: favorite.Position,
+		})
+	}
+	return pages, nil
+}
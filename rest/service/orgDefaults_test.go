@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func TestOrgDefaults(t *testing.T) {
+	user := setupTestUser(t)
+	orgID := "org-defaults-org"
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("org_id = ?", orgID).Delete(&models.OrgDashboardTemplate{})
+		database.DB.Unscoped().Where("org_id = ?", orgID).Delete(&models.OrgStarterFavorite{})
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.DashboardTemplate{})
+	})
+
+	orgLayout := []models.GridItem{{
+		BaseWidgetDimensions: models.BaseWidgetDimensions{Width: 1, Height: 2, MaxHeight: 4, MinHeight: 1},
+		ID:                   "rhel#org",
+	}}
+
+	t.Run("falls back to the base template", func(t *testing.T) {
+		_, err := GetOrgDashboardTemplate(orgID, models.LandingPage)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		template, err := ForkBaseTemplate(user.ID, orgID, models.LandingPage)
+		require.NoError(t, err)
+		assert.Equal(t, BaseTemplates[models.LandingPage].TemplateConfig.Sm.Data(), template.TemplateConfig.Sm.Data())
+	})
+
+	t.Run("rejects invalid org defaults", func(t *testing.T) {
+		_, err := SetOrgDashboardTemplate(orgID, "fakeTemplate", models.TemplateConfig{}, "admin")
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+
+		invalid := models.TemplateConfig{Sm: datatypes.NewJSONType([]models.GridItem{{ID: "rhel#org"}})}
+		_, err = SetOrgDashboardTemplate(orgID, models.LandingPage, invalid, "admin")
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+
+	t.Run("forks and resets to the org default", func(t *testing.T) {
+		orgTemplate, err := SetOrgDashboardTemplate(orgID, models.LandingPage, models.TemplateConfig{Sm: datatypes.NewJSONType(orgLayout)}, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, "admin", orgTemplate.UpdatedBy)
+		assert.Equal(t, orgLayout, orgTemplate.TemplateConfig.Sm.Data())
+		assert.Equal(t, BaseTemplates[models.LandingPage].TemplateConfig.Xl.Data(), orgTemplate.TemplateConfig.Xl.Data(), "empty variants are taken from the base template")
+
+		// publishing again replaces the layouts of the org default
+		republished, err := SetOrgDashboardTemplate(orgID, models.LandingPage, models.TemplateConfig{Md: datatypes.NewJSONType(orgLayout)}, "other-admin")
+		require.NoError(t, err)
+		assert.Equal(t, orgTemplate.ID, republished.ID)
+		assert.Equal(t, "other-admin", republished.UpdatedBy)
+		assert.Equal(t, BaseTemplates[models.LandingPage].TemplateConfig.Sm.Data(), republished.TemplateConfig.Sm.Data())
+		assert.Equal(t, orgLayout, republished.TemplateConfig.Md.Data())
+		orgTemplate, err = SetOrgDashboardTemplate(orgID, models.LandingPage, models.TemplateConfig{Sm: datatypes.NewJSONType(orgLayout)}, "admin")
+		require.NoError(t, err)
+
+		forked, err := ForkBaseTemplate(user.ID, orgID, models.LandingPage)
+		require.NoError(t, err)
+		assert.Equal(t, orgLayout, forked.TemplateConfig.Sm.Data())
+
+		reset, err := ResetDashboardTemplate(user.ID, forked.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, BaseTemplates[models.LandingPage].TemplateConfig.Sm.Data(), reset.TemplateConfig.Sm.Data())
+
+		reset, err = ResetDashboardTemplateToOrgDefault(user.ID, orgID, forked.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, orgLayout, reset.TemplateConfig.Sm.Data())
+
+		_, err = ResetDashboardTemplateToOrgDefault(user.ID+1000, orgID, forked.ID, "")
+		assert.ErrorIs(t, err, util.ErrNotAuthorized)
+
+		require.NoError(t, DeleteOrgDashboardTemplate(orgID, models.LandingPage))
+		assert.ErrorIs(t, DeleteOrgDashboardTemplate(orgID, models.LandingPage), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("seeds new identities with the starter favorites", func(t *testing.T) {
+		_, err := SetOrgStarterFavorites(orgID, models.OrgStarterFavoritesRequest{Pages: []models.OrgStarterFavoritePage{
+			{Pathname: "/insights/dashboard"},
+			{Pathname: "/insights/dashboard"},
+		}}, "admin")
+		assert.True(t, errors.Is(err, util.ErrBadRequest), "duplicate pages are rejected")
+
+		favorites, err := SetOrgStarterFavorites(orgID, models.OrgStarterFavoritesRequest{Pages: []models.OrgStarterFavoritePage{
+			{Pathname: "/openshift/overview", Title: "Clusters"},
+			{Pathname: "/insights/dashboard"},
+		}}, "admin")
+		require.NoError(t, err)
+		require.Len(t, favorites, 2)
+		assert.Equal(t, "/openshift/overview", favorites[0].Pathname)
+
+		identity, err := CreateIdentity("org-defaults-new-user", orgID, true)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			database.DB.Unscoped().Where("user_identity_id = ?", identity.ID).Delete(&models.FavoritePage{})
+			database.DB.Unscoped().Delete(&identity)
+			util.UsersCache.Delete(identity.AccountId)
+		})
+		pages, err := GetUserActiveFavoritePages(identity.ID)
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		assert.ElementsMatch(t, []string{"/openshift/overview", "/insights/dashboard"}, []string{pages[0].Pathname, pages[1].Pathname})
+
+		_, err = SetOrgStarterFavorites(orgID, models.OrgStarterFavoritesRequest{Pages: []models.OrgStarterFavoritePage{{Pathname: "/ansible"}}}, "admin")
+		require.NoError(t, err)
+		existing, err := CreateIdentity("org-defaults-new-user", orgID, true)
+		require.NoError(t, err)
+		assert.Equal(t, identity.ID, existing.ID)
+		pages, err = GetUserActiveFavoritePages(identity.ID)
+		require.NoError(t, err)
+		assert.Len(t, pages, 2, "existing users keep their favorites")
+	})
+}
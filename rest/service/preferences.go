@@ -0,0 +1,244 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	jsonschema "github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type preferencesNamespace struct {
+	schema       *jsonschema.Schema
+	defaultValue json.RawMessage
+}
+
+// PreferencesRegistry holds the compiled schemas of the registered preferences namespaces.
+type PreferencesRegistry struct {
+	namespaces map[string]preferencesNamespace
+}
+
+// NewPreferencesRegistry compiles the namespace schemas. The "default" keyword of a schema is the
+// document returned before the user stores one and has to be valid against the schema itself.
+func NewPreferencesRegistry(schemas map[string][]byte) (*PreferencesRegistry, error) {
+	registry := &PreferencesRegistry{namespaces: make(map[string]preferencesNamespace, len(schemas))}
+	compiler := jsonschema.NewCompiler()
+	for namespace, data := range schemas {
+		if !config.IsValidPreferencesNamespace(namespace) {
+			return nil, fmt.Errorf("invalid preferences namespace name %s", namespace)
+		}
+		schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferences schema %s: %w", namespace, err)
+		}
+		url := namespace + ".json"
+		if err := compiler.AddResource(url, schemaDoc); err != nil {
+			return nil, fmt.Errorf("invalid preferences schema %s: %w", namespace, err)
+		}
+		schema, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferences schema %s: %w", namespace, err)
+		}
+
+		defaultValue := json.RawMessage(`{}`)
+		if schema.Default != nil {
+			defaultValue, err = json.Marshal(*schema.Default)
+			if err != nil {
+				return nil, fmt.Errorf("invalid preferences schema %s default: %w", namespace, err)
+			}
+		}
+		if err := validatePreferencesDocument(schema, defaultValue); err != nil {
+			return nil, fmt.Errorf("invalid preferences schema %s default: %w", namespace, err)
+		}
+		registry.namespaces[namespace] = preferencesNamespace{schema: schema, defaultValue: defaultValue}
+	}
+	return registry, nil
+}
+
+// Namespaces returns the sorted names of the registered namespaces.
+func (pr *PreferencesRegistry) Namespaces() []string {
+	names := make([]string, 0, len(pr.namespaces))
+	for name := range pr.namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (pr *PreferencesRegistry) lookup(namespace string) (preferencesNamespace, error) {
+	ns, ok := pr.namespaces[namespace]
+	if !ok {
+		return ns, fmt.Errorf("preferences namespace %s: %w", namespace, gorm.ErrRecordNotFound)
+	}
+	return ns, nil
+}
+
+var (
+	preferencesRegistry     *PreferencesRegistry
+	preferencesRegistryLock sync.Mutex
+)
+
+// SetPreferencesRegistry replaces the registry built from the configuration.
+func SetPreferencesRegistry(registry *PreferencesRegistry) {
+	preferencesRegistryLock.Lock()
+	defer preferencesRegistryLock.Unlock()
+	preferencesRegistry = registry
+}
+
+// getPreferencesRegistry lazily compiles the configured schemas. Broken schemas are logged and
+// result in an empty registry rather than a failing service.
+func getPreferencesRegistry() *PreferencesRegistry {
+	preferencesRegistryLock.Lock()
+	defer preferencesRegistryLock.Unlock()
+	if preferencesRegistry != nil {
+		return preferencesRegistry
+	}
+
+	registry, err := NewPreferencesRegistry(config.Get().PreferencesConfig.Schemas)
+	if err != nil {
+		logrus.Errorf("Unable to load preferences schemas: %v", err)
+		registry = &PreferencesRegistry{namespaces: map[string]preferencesNamespace{}}
+	}
+	preferencesRegistry = registry
+	return preferencesRegistry
+}
+
+func validatePreferencesDocument(schema *jsonschema.Schema, value json.RawMessage) error {
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(value))
+	if err != nil {
+		return fmt.Errorf("%w: invalid preferences document: %s", util.ErrBadRequest, err.Error())
+	}
+	if err := schema.Validate(document); err != nil {
+		return fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+	return nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to the target document.
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+func applyPreferencesPatch(current json.RawMessage, patch json.RawMessage) (json.RawMessage, error) {
+	var target, patchDoc interface{}
+	if err := json.Unmarshal(current, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("%w: invalid preferences patch: %s", util.ErrBadRequest, err.Error())
+	}
+	return json.Marshal(mergePatch(target, patchDoc))
+}
+
+func findUserPreference(tx *gorm.DB, userID uint, namespace string) (models.UserPreference, bool, error) {
+	var preference models.UserPreference
+	result := tx.Where("user_identity_id = ? AND namespace = ?", userID, namespace).Limit(1).Find(&preference)
+	return preference, result.RowsAffected > 0, result.Error
+}
+
+// GetUserPreferences returns the stored namespace document or the namespace default with version 0.
+func GetUserPreferences(userID uint, namespace string) (models.UserPreference, error) {
+	ns, err := getPreferencesRegistry().lookup(namespace)
+	if err != nil {
+		return models.UserPreference{}, err
+	}
+	preference, found, err := findUserPreference(database.DB, userID, namespace)
+	if err != nil || found {
+		return preference, err
+	}
+	return models.UserPreference{
+		UserIdentityID: userID,
+		Namespace:      namespace,
+		Value:          datatypes.JSON(ns.defaultValue),
+	}, nil
+}
+
+// UpdateUserPreferences replaces the namespace document, or merge patches it when patch is set.
+// The resulting document is validated against the namespace schema and size limit. Concurrent
+// writes based on the same version are rejected with util.ErrConflict.
+func UpdateUserPreferences(userID uint, namespace string, request models.UserPreferenceRequest, patch bool) (models.UserPreference, error) {
+	ns, err := getPreferencesRegistry().lookup(namespace)
+	if err != nil {
+		return models.UserPreference{}, err
+	}
+	if len(request.Value) == 0 {
+		return models.UserPreference{}, fmt.Errorf("%w: missing preferences value", util.ErrBadRequest)
+	}
+
+	var preference models.UserPreference
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		stored, found, err := findUserPreference(tx, userID, namespace)
+		if err != nil {
+			return err
+		}
+		if request.Version != nil && *request.Version != stored.Version {
+			return fmt.Errorf("%w: preferences version %d does not match the stored version %d", util.ErrConflict, *request.Version, stored.Version)
+		}
+
+		value := json.RawMessage(request.Value)
+		if patch {
+			current := json.RawMessage(stored.Value)
+			if !found {
+				current = ns.defaultValue
+			}
+			value, err = applyPreferencesPatch(current, value)
+			if err != nil {
+				return err
+			}
+		}
+		if maxSize := config.Get().PreferencesConfig.MaxSize; len(value) > maxSize {
+			return fmt.Errorf("%w: preferences document exceeds %d bytes", util.ErrTooLarge, maxSize)
+		}
+		if err := validatePreferencesDocument(ns.schema, value); err != nil {
+			return err
+		}
+
+		if !found {
+			preference = models.UserPreference{UserIdentityID: userID, Namespace: namespace, Value: datatypes.JSON(value), Version: 1}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&preference)
+			if result.Error == nil && result.RowsAffected == 0 {
+				return fmt.Errorf("%w: preferences were created by another request", util.ErrConflict)
+			}
+			return result.Error
+		}
+
+		result := tx.Model(&stored).Where("version = ?", stored.Version).Updates(map[string]interface{}{
+			"value":   datatypes.JSON(value),
+			"version": stored.Version + 1,
+		})
+		if result.Error == nil && result.RowsAffected == 0 {
+			return fmt.Errorf("%w: preferences were updated by another request", util.ErrConflict)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		preference, _, err = findUserPreference(tx, userID, namespace)
+		return err
+	})
+	return preference, err
+}
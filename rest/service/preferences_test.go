@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const testPreferencesSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"theme": {"type": "string", "enum": ["light", "dark"]},
+		"pinned": {"type": "array", "items": {"type": "string"}}
+	},
+	"default": {"theme": "light"}
+}`
+
+func setupPreferencesRegistry(t *testing.T, user *models.UserIdentity) {
+	t.Helper()
+	registry, err := NewPreferencesRegistry(map[string][]byte{"test-app": []byte(testPreferencesSchema)})
+	require.NoError(t, err)
+	SetPreferencesRegistry(registry)
+	t.Cleanup(func() {
+		SetPreferencesRegistry(nil)
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.UserPreference{})
+	})
+}
+
+func preferencesRequest(value string, version *int) models.UserPreferenceRequest {
+	return models.UserPreferenceRequest{Value: datatypes.JSON(value), Version: version}
+}
+
+func TestNewPreferencesRegistry(t *testing.T) {
+	registry, err := NewPreferencesRegistry(config.Get().PreferencesConfig.Schemas)
+	require.NoError(t, err, "embedded schemas have to compile")
+	assert.Contains(t, registry.Namespaces(), "chrome")
+
+	_, err = NewPreferencesRegistry(map[string][]byte{"broken": []byte(`{"type": "object", "default": []}`)})
+	assert.Error(t, err, "default has to match the schema")
+
+	_, err = NewPreferencesRegistry(map[string][]byte{"Invalid Name": []byte(`{}`)})
+	assert.Error(t, err)
+}
+
+func TestMergePatch(t *testing.T) {
+	patched, err := applyPreferencesPatch([]byte(`{"a": "b", "c": {"d": "e", "f": "g"}}`), []byte(`{"a": "z", "c": {"f": null}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a": "z", "c": {"d": "e"}}`, string(patched))
+}
+
+func TestUserPreferences(t *testing.T) {
+	user := setupTestUser(t)
+	setupPreferencesRegistry(t, user)
+
+	t.Run("Should return defaults", func(t *testing.T) {
+		preference, err := GetUserPreferences(user.ID, "test-app")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"theme": "light"}`, string(preference.Value))
+		assert.Equal(t, 0, preference.Version)
+	})
+
+	t.Run("Should reject unknown namespace", func(t *testing.T) {
+		_, err := GetUserPreferences(user.ID, "unknown")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("Should reject invalid documents", func(t *testing.T) {
+		_, err := UpdateUserPreferences(user.ID, "test-app", preferencesRequest(`{"theme": "blue"}`, nil), false)
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+
+	t.Run("Should patch over defaults and increment version", func(t *testing.T) {
+		preference, err := UpdateUserPreferences(user.ID, "test-app", preferencesRequest(`{"pinned": ["insights"]}`, nil), true)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"theme": "light", "pinned": ["insights"]}`, string(preference.Value))
+		assert.Equal(t, 1, preference.Version)
+
+		preference, err = UpdateUserPreferences(user.ID, "test-app", preferencesRequest(`{"theme": "dark"}`, &preference.Version), false)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"theme": "dark"}`, string(preference.Value))
+		assert.Equal(t, 2, preference.Version)
+	})
+
+	t.Run("Should reject stale versions", func(t *testing.T) {
+		stale := 1
+		_, err := UpdateUserPreferences(user.ID, "test-app", preferencesRequest(`{"theme": "light"}`, &stale), false)
+		assert.True(t, errors.Is(err, util.ErrConflict))
+
+		preference, err := GetUserPreferences(user.ID, "test-app")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"theme": "dark"}`, string(preference.Value))
+	})
+
+	t.Run("Should enforce size limit", func(t *testing.T) {
+		cfg := config.Get()
+		original := cfg.PreferencesConfig.MaxSize
+		cfg.PreferencesConfig.MaxSize = 16
+		t.Cleanup(func() {
+			cfg.PreferencesConfig.MaxSize = original
+		})
+		_, err := UpdateUserPreferences(user.ID, "test-app", preferencesRequest(`{"pinned": ["insights", "openshift"]}`, nil), false)
+		assert.True(t, errors.Is(err, util.ErrTooLarge))
+	})
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/featureflags"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// PreviewFeaturesContextProperty is the Unleash context property listing the enrolled previews.
+const PreviewFeaturesContextProperty = "previewFeatures"
+
+// PreviewFeatureStatus combines a catalog entry with the enrollment of the user.
+type PreviewFeatureStatus struct {
+	config.PreviewFeature
+	models.PreviewFeatureState
+	Seen bool `json:"seen"`
+	// Enabled is true when the user is enrolled and the linked feature flag is on for the user
+	Enabled bool `json:"enabled"`
+}
+
+// userPreviewFeatures returns a copy of the preview enrollments that is safe to change, the stored map
+// is shared with the cached identity.
+func userPreviewFeatures(user models.UserIdentity) map[string]models.PreviewFeatureState {
+	features := maps.Clone(user.PreviewFeatures.Data())
+	if features == nil {
+		features = map[string]models.PreviewFeatureState{}
+	}
+	return features
+}
+
+// getActivePreviewFeature returns the catalog entry of a preview currently offered to users.
+func getActivePreviewFeature(name string, now time.Time) (config.PreviewFeature, error) {
+	feature, ok := config.Get().PreviewFeaturesConfig.Lookup(name)
+	if !ok {
+		return feature, fmt.Errorf("preview feature %s: %w", name, gorm.ErrRecordNotFound)
+	}
+	if !feature.IsActive(now) {
+		return feature, fmt.Errorf("%w: preview feature %s is not available", util.ErrBadRequest, name)
+	}
+	return feature, nil
+}
+
+// EnrolledPreviewFeatures returns the sorted names of the active previews the user is enrolled into.
+func EnrolledPreviewFeatures(user models.UserIdentity) []string {
+	now := time.Now()
+	enrolled := []string{}
+	for name, state := range userPreviewFeatures(user) {
+		if _, err := getActivePreviewFeature(name, now); err == nil && state.Enrolled {
+			enrolled = append(enrolled, name)
+		}
+	}
+	sort.Strings(enrolled)
+	return enrolled
+}
+
+// PreviewFeaturesUnleashProperties returns the Unleash context properties of the user previews.
+func PreviewFeaturesUnleashProperties(user models.UserIdentity) map[string]string {
+	return map[string]string{
+		PreviewFeaturesContextProperty: strings.Join(EnrolledPreviewFeatures(user), ","),
+	}
+}
+
+// IsFeatureFlagEnabledForUser evaluates a flag with the user preview enrollments in the Unleash context.
+func IsFeatureFlagEnabledForUser(user models.UserIdentity, flag string) bool {
+	return featureflags.IsEnabledForUser(flag, user.AccountId, PreviewFeaturesUnleashProperties(user))
+}
+
+// GetPreviewFeatures lists the previews currently offered with the enrollment of the user.
+func GetPreviewFeatures(user models.UserIdentity) []PreviewFeatureStatus {
+	now := time.Now()
+	states := userPreviewFeatures(user)
+	properties := PreviewFeaturesUnleashProperties(user)
+	statuses := []PreviewFeatureStatus{}
+	for _, feature := range config.Get().PreviewFeaturesConfig.Features {
+		if !feature.IsActive(now) {
+			continue
+		}
+		state := states[feature.Name]
+		status := PreviewFeatureStatus{
+			PreviewFeature:      feature,
+			PreviewFeatureState: state,
+			Seen:                state.SeenAt != nil,
+		}
+		if state.Enrolled {
+			status.Enabled = feature.FeatureFlag == "" || featureflags.IsEnabledForUser(feature.FeatureFlag, user.AccountId, properties)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// savePreviewFeatures stores the enrollments, the identity is only changed once they are stored.
+func savePreviewFeatures(identity *models.UserIdentity, features map[string]models.PreviewFeatureState) error {
+	previewFeatures := datatypes.NewJSONType(features)
+	err := database.DB.Model(&models.UserIdentity{}).Where("id = ?", identity.ID).Update("preview_features", previewFeatures).Error
+	if err != nil {
+		return err
+	}
+	identity.PreviewFeatures = previewFeatures
+	refreshCachedIdentity(identity)
+	return nil
+}
+
+// UpdatePreviewFeatureEnrollment enrolls the user into an active preview or opts them out of it.
+func UpdatePreviewFeatureEnrollment(identity *models.UserIdentity, name string, enrolled bool) error {
+	now := time.Now()
+	if _, err := getActivePreviewFeature(name, now); err != nil {
+		return err
+	}
+	features := userPreviewFeatures(*identity)
+	state := features[name]
+	if state.Enrolled != enrolled {
+		state.Enrolled = enrolled
+		state.EnrolledAt = nil
+		if enrolled {
+			state.EnrolledAt = &now
+		}
+	}
+	features[name] = state
+	return savePreviewFeatures(identity, features)
+}
+
+// MarkPreviewFeatureSeen records the first time the user was shown an active preview.
+func MarkPreviewFeatureSeen(identity *models.UserIdentity, name string) error {
+	now := time.Now()
+	if _, err := getActivePreviewFeature(name, now); err != nil {
+		return err
+	}
+	features := userPreviewFeatures(*identity)
+	state := features[name]
+	if state.SeenAt != nil {
+		return nil
+	}
+	state.SeenAt = &now
+	features[name] = state
+	return savePreviewFeatures(identity, features)
+}
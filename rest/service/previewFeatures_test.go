@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupPreviewFeatures(t *testing.T) {
+	t.Helper()
+	past := time.Now().Add(-48 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	cfg := config.Get()
+	original := cfg.PreviewFeaturesConfig
+	cfg.PreviewFeaturesConfig = config.PreviewFeaturesConfig{Features: []config.PreviewFeature{
+		{Name: "new-navigation", Title: "New navigation"},
+		{Name: "dark-mode", Title: "Dark mode", FeatureFlag: "platform.chrome.dark-mode"},
+		{Name: "ended", Title: "Ended preview", StartDate: &past, EndDate: &yesterday},
+	}}
+	t.Cleanup(func() {
+		cfg.PreviewFeaturesConfig = original
+	})
+}
+
+func TestParsePreviewFeatures(t *testing.T) {
+	features, err := config.ParsePreviewFeatures([]byte(`
+- name: new-navigation
+  title: New navigation
+  startDate: 2026-01-01T00:00:00Z
+  endDate: 2026-06-30T00:00:00Z
+`))
+	require.NoError(t, err)
+	require.Len(t, features, 1)
+	assert.True(t, features[0].IsActive(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, features[0].IsActive(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)))
+
+	_, err = config.ParsePreviewFeatures([]byte(`[{"name": "a"}, {"name": "a"}]`))
+	assert.Error(t, err)
+
+	_, err = config.ParsePreviewFeatures([]byte(`[{"name": "a", "startDate": "2026-02-01T00:00:00Z", "endDate": "2026-01-01T00:00:00Z"}]`))
+	assert.Error(t, err)
+}
+
+func TestPreviewFeatureEnrollment(t *testing.T) {
+	setupPreviewFeatures(t)
+	user := setupTestUser(t)
+
+	t.Run("Should list active previews only", func(t *testing.T) {
+		features := GetPreviewFeatures(*user)
+		require.Len(t, features, 2)
+		assert.False(t, features[0].Enrolled)
+		assert.False(t, features[0].Enabled)
+	})
+
+	t.Run("Should reject unknown and ended previews", func(t *testing.T) {
+		err := UpdatePreviewFeatureEnrollment(user, "unknown", true)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		err = UpdatePreviewFeatureEnrollment(user, "ended", true)
+		assert.True(t, errors.Is(err, util.ErrBadRequest))
+	})
+
+	t.Run("Should enroll per feature", func(t *testing.T) {
+		require.NoError(t, UpdatePreviewFeatureEnrollment(user, "new-navigation", true))
+		require.NoError(t, UpdatePreviewFeatureEnrollment(user, "dark-mode", true))
+		require.NoError(t, MarkPreviewFeatureSeen(user, "dark-mode"))
+
+		var stored models.UserIdentity
+		require.NoError(t, database.DB.First(&stored, user.ID).Error)
+		assert.Equal(t, []string{"dark-mode", "new-navigation"}, EnrolledPreviewFeatures(stored))
+		assert.Equal(t, map[string]string{PreviewFeaturesContextProperty: "dark-mode,new-navigation"}, PreviewFeaturesUnleashProperties(stored))
+
+		features := GetPreviewFeatures(stored)
+		assert.True(t, features[0].Enabled, "previews without a feature flag are enabled on enrollment")
+		assert.False(t, features[0].Seen)
+		assert.False(t, features[1].Enabled, "feature flag is off without an Unleash connection")
+		assert.True(t, features[1].Seen)
+	})
+
+	t.Run("Should opt out", func(t *testing.T) {
+		require.NoError(t, UpdatePreviewFeatureEnrollment(user, "new-navigation", false))
+		assert.Equal(t, []string{"dark-mode"}, EnrolledPreviewFeatures(*user))
+		assert.Nil(t, userPreviewFeatures(*user)["new-navigation"].EnrolledAt)
+	})
+
+	t.Run("Should not change the enrollments of copies of the identity", func(t *testing.T) {
+		cached := *user
+		require.NoError(t, UpdatePreviewFeatureEnrollment(user, "new-navigation", true))
+		assert.Equal(t, []string{"dark-mode"}, EnrolledPreviewFeatures(cached))
+		assert.Equal(t, []string{"dark-mode", "new-navigation"}, EnrolledPreviewFeatures(*user))
+	})
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func validateQuickstartID(quickstartID string) error {
+	if err := models.IsValidQuickstartID(quickstartID); err != nil {
+		return fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+	return nil
+}
+
+func findQuickstartProgress(tx *gorm.DB, userID uint, quickstartID string) (models.QuickstartProgress, bool, error) {
+	var progress models.QuickstartProgress
+	result := tx.Where("user_identity_id = ? AND quickstart_id = ?", userID, quickstartID).Limit(1).Find(&progress)
+	return progress, result.RowsAffected > 0, result.Error
+}
+
+func allQuickstartTasksSucceeded(statuses []models.QuickstartTaskStatus) bool {
+	if len(statuses) == 0 {
+		return false
+	}
+	for _, status := range statuses {
+		if status != models.QuickstartTaskSuccess {
+			return false
+		}
+	}
+	return true
+}
+
+// ListQuickstartProgress returns the quickstart progress of a user, optionally limited to a single bundle.
+func ListQuickstartProgress(userID uint, bundle string) ([]models.QuickstartProgress, error) {
+	progress := []models.QuickstartProgress{}
+	query := database.DB.Where("user_identity_id = ?", userID)
+	if bundle != "" {
+		query = query.Where("bundle = ?", bundle)
+	}
+	err := query.Order("updated_at DESC").Find(&progress).Error
+	return progress, err
+}
+
+func GetQuickstartProgress(userID uint, quickstartID string) (models.QuickstartProgress, error) {
+	if err := validateQuickstartID(quickstartID); err != nil {
+		return models.QuickstartProgress{}, err
+	}
+	progress, found, err := findQuickstartProgress(database.DB, userID, quickstartID)
+	if err == nil && !found {
+		err = gorm.ErrRecordNotFound
+	}
+	return progress, err
+}
+
+// SaveQuickstartProgress stores the progress of a quickstart. The start time is set by the first save and
+// the completion time once the quickstart is marked as completed or all of its tasks succeeded.
+func SaveQuickstartProgress(userID uint, quickstartID string, request models.QuickstartProgressRequest) (models.QuickstartProgress, error) {
+	if err := validateQuickstartID(quickstartID); err != nil {
+		return models.QuickstartProgress{}, err
+	}
+	if err := request.IsValid(); err != nil {
+		return models.QuickstartProgress{}, fmt.Errorf("%w: %s", util.ErrBadRequest, err.Error())
+	}
+	if request.TaskStatuses == nil {
+		request.TaskStatuses = []models.QuickstartTaskStatus{}
+	}
+
+	var progress models.QuickstartProgress
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		stored, found, err := findQuickstartProgress(tx, userID, quickstartID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		completedAt := stored.CompletedAt
+		if completedAt == nil && (request.Completed || allQuickstartTasksSucceeded(request.TaskStatuses)) {
+			completedAt = &now
+		}
+
+		if !found {
+			progress = models.QuickstartProgress{
+				UserIdentityID: userID,
+				QuickstartID:   quickstartID,
+				Bundle:         request.Bundle,
+				TaskIndex:      request.TaskIndex,
+				TaskStatuses:   datatypes.NewJSONType(request.TaskStatuses),
+				StartedAt:      now,
+				CompletedAt:    completedAt,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&progress)
+			if result.Error == nil && result.RowsAffected == 0 {
+				return fmt.Errorf("%w: quickstart progress was created by another request", util.ErrConflict)
+			}
+			return result.Error
+		}
+
+		updates := map[string]interface{}{
+			"task_index":    request.TaskIndex,
+			"task_statuses": datatypes.NewJSONType(request.TaskStatuses),
+			"completed_at":  completedAt,
+		}
+		// keep the stored bundle when the client does not know it
+		if request.Bundle != "" {
+			updates["bundle"] = request.Bundle
+		}
+		if err := tx.Model(&models.QuickstartProgress{}).Where("id = ?", stored.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		progress, _, err = findQuickstartProgress(tx, userID, quickstartID)
+		return err
+	})
+	return progress, err
+}
+
+// ResetQuickstartProgress removes the quickstart progress so the next save starts the quickstart over.
+func ResetQuickstartProgress(userID uint, quickstartID string) error {
+	if err := validateQuickstartID(quickstartID); err != nil {
+		return err
+	}
+	result := database.DB.Unscoped().Where("user_identity_id = ? AND quickstart_id = ?", userID, quickstartID).Delete(&models.QuickstartProgress{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetQuickstartBundleSummary counts the started, in progress and completed quickstarts of a user per bundle.
+func GetQuickstartBundleSummary(userID uint) ([]models.QuickstartBundleSummary, error) {
+	progress, err := ListQuickstartProgress(userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := map[string]*models.QuickstartBundleSummary{}
+	for _, p := range progress {
+		summary, ok := bundles[p.Bundle]
+		if !ok {
+			summary = &models.QuickstartBundleSummary{Bundle: p.Bundle}
+			bundles[p.Bundle] = summary
+		}
+		summary.Started++
+		if p.CompletedAt != nil {
+			summary.Completed++
+		} else {
+			summary.InProgress++
+		}
+	}
+
+	summaries := []models.QuickstartBundleSummary{}
+	for _, summary := range bundles {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Bundle < summaries[j].Bundle
+	})
+	return summaries, nil
+}
+
+// GetQuickstartStats aggregates the number of users who started and completed each quickstart.
+func GetQuickstartStats() ([]models.QuickstartStats, error) {
+	stats := []models.QuickstartStats{}
+	err := database.DB.Model(&models.QuickstartProgress{}).
+		Select("quickstart_id, MAX(bundle) AS bundle, COUNT(*) AS started, COUNT(completed_at) AS completed").
+		Group("quickstart_id").
+		Order("quickstart_id").
+		Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestQuickstartProgress(t *testing.T) {
+	user := setupTestUser(t)
+	other := models.UserIdentity{AccountId: "quickstart-other-user"}
+	require.NoError(t, database.DB.Create(&other).Error)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("1 = 1").Delete(&models.QuickstartProgress{})
+		database.DB.Unscoped().Delete(&other)
+	})
+
+	t.Run("rejects invalid progress", func(t *testing.T) {
+		for _, request := range []models.QuickstartProgressRequest{
+			{TaskIndex: 0, TaskStatuses: []models.QuickstartTaskStatus{"Done"}},
+			{TaskIndex: 2, TaskStatuses: []models.QuickstartTaskStatus{models.QuickstartTaskInitial}},
+			{TaskIndex: -2},
+		} {
+			_, err := SaveQuickstartProgress(user.ID, "insights-tour", request)
+			assert.True(t, errors.Is(err, util.ErrBadRequest), "expected bad request for %+v, got %v", request, err)
+		}
+		_, err := GetQuickstartProgress(user.ID, "insights-tour")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("tracks start and completion", func(t *testing.T) {
+		started, err := SaveQuickstartProgress(user.ID, "insights-tour", models.QuickstartProgressRequest{
+			Bundle:       "insights",
+			TaskIndex:    0,
+			TaskStatuses: []models.QuickstartTaskStatus{models.QuickstartTaskVisited, models.QuickstartTaskInitial},
+		})
+		require.NoError(t, err)
+		assert.False(t, started.StartedAt.IsZero())
+		assert.Nil(t, started.CompletedAt)
+
+		completed, err := SaveQuickstartProgress(user.ID, "insights-tour", models.QuickstartProgressRequest{
+			TaskIndex:    1,
+			TaskStatuses: []models.QuickstartTaskStatus{models.QuickstartTaskSuccess, models.QuickstartTaskSuccess},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, started.ID, completed.ID)
+		assert.Equal(t, "insights", completed.Bundle, "the stored bundle is kept")
+		assert.Equal(t, 1, completed.TaskIndex)
+		assert.True(t, completed.StartedAt.Equal(started.StartedAt))
+		require.NotNil(t, completed.CompletedAt)
+
+		_, err = SaveQuickstartProgress(user.ID, "openshift-tour", models.QuickstartProgressRequest{Bundle: "openshift", TaskIndex: -1})
+		require.NoError(t, err)
+		_, err = SaveQuickstartProgress(user.ID, "rhel-tour", models.QuickstartProgressRequest{Bundle: "insights", TaskIndex: -1, Completed: true})
+		require.NoError(t, err)
+		_, err = SaveQuickstartProgress(other.ID, "insights-tour", models.QuickstartProgressRequest{Bundle: "insights", TaskIndex: -1})
+		require.NoError(t, err)
+
+		progress, err := ListQuickstartProgress(user.ID, "insights")
+		require.NoError(t, err)
+		assert.Len(t, progress, 2)
+
+		summary, err := GetQuickstartBundleSummary(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.QuickstartBundleSummary{
+			{Bundle: "insights", Started: 2, Completed: 2},
+			{Bundle: "openshift", Started: 1, InProgress: 1},
+		}, summary)
+
+		stats, err := GetQuickstartStats()
+		require.NoError(t, err)
+		assert.Equal(t, []models.QuickstartStats{
+			{QuickstartID: "insights-tour", Bundle: "insights", Started: 2, Completed: 1},
+			{QuickstartID: "openshift-tour", Bundle: "openshift", Started: 1},
+			{QuickstartID: "rhel-tour", Bundle: "insights", Started: 1, Completed: 1},
+		}, stats)
+	})
+
+	t.Run("reset starts over", func(t *testing.T) {
+		require.NoError(t, ResetQuickstartProgress(user.ID, "insights-tour"))
+		assert.ErrorIs(t, ResetQuickstartProgress(user.ID, "insights-tour"), gorm.ErrRecordNotFound)
+
+		restarted, err := SaveQuickstartProgress(user.ID, "insights-tour", models.QuickstartProgressRequest{Bundle: "insights", TaskIndex: -1})
+		require.NoError(t, err)
+		assert.Nil(t, restarted.CompletedAt)
+	})
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"gorm.io/gorm"
 )
 
 func GetSelfReport(accountID uint) (models.SelfReport, error) {
@@ -30,3 +33,53 @@ func HandleNewSelfReport(accountID uint, newSelfReport *models.SelfReport) error
 		Update("updated_at", time.Now()).
 		Error
 }
+
+// SelfReportUpdate lists the self report fields to change, nil fields keep their value.
+type SelfReportUpdate struct {
+	ProductsOfInterest *[]string
+	JobRole            *string
+}
+
+// UpdateSelfReport applies update to the self report of the user, creating the report when the user does
+// not have one yet. With an If-Match header the update only applies while the report is the version the
+// client read. It returns the report before and after the update.
+func UpdateSelfReport(userID uint, update SelfReportUpdate, ifMatch string) (models.SelfReport, models.SelfReport, error) {
+	previous, err := GetSelfReport(userID)
+	if err != nil {
+		return previous, previous, err
+	}
+	if err := util.CheckIfMatch(ifMatch, SelfReportETag(previous)); err != nil {
+		return previous, previous, err
+	}
+
+	updated := previous
+	if update.ProductsOfInterest != nil {
+		updated.ProductsOfInterest = *update.ProductsOfInterest
+	}
+	if update.JobRole != nil {
+		updated.JobRole = *update.JobRole
+	}
+	updated.UserIdentityID = userID
+	if previous.ID == 0 {
+		err = database.DB.Create(&updated).Error
+		return previous, updated, err
+	}
+
+	query := database.DB.Model(&updated)
+	if ifMatch != "" {
+		query = query.Where("version = ?", previous.Version)
+	}
+	result := query.Updates(map[string]interface{}{
+		"products_of_interest": updated.ProductsOfInterest,
+		"job_role":             updated.JobRole,
+		"version":              gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return previous, updated, result.Error
+	}
+	if ifMatch != "" && result.RowsAffected == 0 {
+		return previous, updated, fmt.Errorf("%w: the self report was modified, fetch it again and retry", util.ErrPreconditionFailed)
+	}
+	err = database.DB.First(&updated, updated.ID).Error
+	return previous, updated, err
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateSelfReportIfMatch(t *testing.T) {
+	user := setupTestUser(t)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("user_identity_id = ?", user.ID).Delete(&models.SelfReport{})
+	})
+	developer, manager := "developer", "manager"
+
+	_, created, err := UpdateSelfReport(user.ID, SelfReportUpdate{JobRole: &developer}, "")
+	require.NoError(t, err)
+	etag := SelfReportETag(created)
+
+	_, updated, err := UpdateSelfReport(user.ID, SelfReportUpdate{JobRole: &manager}, etag)
+	require.NoError(t, err)
+	assert.Equal(t, manager, updated.JobRole)
+	assert.Equal(t, created.Version+1, updated.Version)
+
+	_, _, err = UpdateSelfReport(user.ID, SelfReportUpdate{JobRole: &developer}, etag)
+	assert.True(t, errors.Is(err, util.ErrPreconditionFailed))
+
+	stored, err := GetSelfReport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, manager, stored.JobRole)
+	assert.Equal(t, SelfReportETag(updated), SelfReportETag(stored))
+}
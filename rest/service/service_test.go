@@ -65,7 +65,8 @@ func TestBatchLastVisited(t *testing.T) {
 
 func TestSmallBatchLastVisited(t *testing.T) {
 	const NewPages = 3
-	const PageCount = 3
+	// new pages are merged into the existing history, which stays capped at 10 pages
+	const PageCount = 10
 	newPages := []models.VisitedPage{}
 	for i := 0; i < NewPages; i++ {
 		newPage := models.VisitedPage{
@@ -82,4 +83,7 @@ func TestSmallBatchLastVisited(t *testing.T) {
 	if len(smallPages) != PageCount {
 		t.Errorf("Wanted %v pages, but found %v instead", PageCount, len(smallPages))
 	}
+	if smallPages[0].Pathname != "insights/ros-small-0" {
+		t.Errorf("Wanted the newest page first, but found %v instead", smallPages[0].Pathname)
+	}
 }
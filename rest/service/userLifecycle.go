@@ -0,0 +1,108 @@
+package service
+
+import (
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"gorm.io/datatypes"
+)
+
+const dayDuration = 24 * time.Hour
+
+// pruneLoginHistory drops the sessions older than the history retention.
+func pruneLoginHistory(history []time.Time, now time.Time, historyDays int) []time.Time {
+	cutoff := now.Add(-time.Duration(historyDays) * dayDuration)
+	pruned := []time.Time{}
+	for _, login := range history {
+		if login.After(cutoff) {
+			pruned = append(pruned, login)
+		}
+	}
+	return pruned
+}
+
+// TrackUserSession applies the lifecycle rules to the identity of an incoming request:
+//   - a new session is recorded when the last one started more than a session window ago,
+//     LastLogin is the start of the current session
+//   - FirstLogin is cleared when the second session starts
+//   - DayOne is cleared once the account is older than the day one duration
+//
+// The row is only written when something changed. A session started concurrently by another
+// replica is not recorded twice.
+func TrackUserSession(identity *models.UserIdentity, now time.Time) error {
+	lifecycleConfig := config.Get().UserLifecycleConfig
+	updates := map[string]interface{}{}
+
+	newSession := now.Sub(identity.LastLogin) >= lifecycleConfig.SessionWindow
+	if newSession {
+		previousLogin := identity.LastLogin
+		history := append(pruneLoginHistory(identity.LoginHistory.Data(), now, lifecycleConfig.HistoryDays), now)
+		updates["previous_login"] = &previousLogin
+		updates["last_login"] = now
+		updates["session_count"] = identity.SessionCount + 1
+		updates["login_history"] = datatypes.NewJSONType(history)
+		if identity.FirstLogin {
+			updates["first_login"] = false
+		}
+	}
+	if identity.DayOne && now.Sub(identity.CreatedAt) >= lifecycleConfig.DayOneDuration {
+		updates["day_one"] = false
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	query := database.DB.Model(&models.UserIdentity{}).Where("id = ?", identity.ID)
+	if newSession {
+		query = query.Where("session_count = ?", identity.SessionCount)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// another replica started the session, pick up its changes
+		if err := database.DB.First(identity, identity.ID).Error; err != nil {
+			return err
+		}
+		refreshCachedIdentity(identity)
+		return nil
+	}
+
+	if newSession {
+		previousLogin := identity.LastLogin
+		identity.PreviousLogin = &previousLogin
+		identity.LastLogin = now
+		identity.SessionCount++
+		identity.LoginHistory = updates["login_history"].(datatypes.JSONType[[]time.Time])
+		identity.FirstLogin = false
+	}
+	if _, ok := updates["day_one"]; ok {
+		identity.DayOne = false
+	}
+	refreshCachedIdentity(identity)
+	return nil
+}
+
+// recentSessionDays is the window of the recent sessions of the login summary.
+const recentSessionDays = 30
+
+// GetLoginSummary summarizes the login history of the user. The recent sessions can not span more days
+// than the kept login history, the window is shortened to it.
+func GetLoginSummary(identity models.UserIdentity, now time.Time) models.LoginSummary {
+	recentDays := min(recentSessionDays, config.Get().UserLifecycleConfig.HistoryDays)
+	summary := models.LoginSummary{
+		TotalSessions:          identity.SessionCount,
+		RecentDays:             recentDays,
+		CurrentSessionStart:    identity.LastLogin,
+		PreviousSessionStart:   identity.PreviousLogin,
+		DaysSinceAccountCreate: int(now.Sub(identity.CreatedAt) / dayDuration),
+	}
+	summary.RecentSessions = len(pruneLoginHistory(identity.LoginHistory.Data(), now, recentDays))
+	if identity.PreviousLogin != nil {
+		summary.ReturningAfterDays = int(identity.LastLogin.Sub(*identity.PreviousLogin) / dayDuration)
+	}
+	return summary
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
+	"github.com/RedHatInsights/chrome-service-backend/rest/database"
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func setupLifecycleUser(t *testing.T, createdAt time.Time) *models.UserIdentity {
+	t.Helper()
+	user := setupTestUser(t)
+	user.CreatedAt = createdAt
+	user.FirstLogin = true
+	user.DayOne = true
+	user.LastLogin = createdAt
+	user.SessionCount = 1
+	user.LoginHistory = datatypes.NewJSONType([]time.Time{createdAt})
+	require.NoError(t, database.DB.Save(user).Error)
+	return user
+}
+
+func TestTrackUserSession(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+	user := setupLifecycleUser(t, created)
+
+	t.Run("Should not record a session within the session window", func(t *testing.T) {
+		require.NoError(t, TrackUserSession(user, created.Add(2*time.Hour)))
+		assert.Equal(t, 1, user.SessionCount)
+		assert.True(t, user.FirstLogin)
+		assert.True(t, user.DayOne)
+	})
+
+	t.Run("Should start a second session and clear first login", func(t *testing.T) {
+		secondSession := created.Add(10 * time.Hour)
+		require.NoError(t, TrackUserSession(user, secondSession))
+		assert.Equal(t, 2, user.SessionCount)
+		assert.False(t, user.FirstLogin)
+		assert.True(t, user.DayOne)
+		assert.Equal(t, secondSession, user.LastLogin)
+
+		var stored models.UserIdentity
+		require.NoError(t, database.DB.First(&stored, user.ID).Error)
+		assert.Equal(t, 2, stored.SessionCount)
+		assert.False(t, stored.FirstLogin)
+		assert.Len(t, stored.LoginHistory.Data(), 2)
+	})
+
+	t.Run("Should clear day one after the first day", func(t *testing.T) {
+		require.NoError(t, TrackUserSession(user, created.Add(25*time.Hour)))
+		assert.False(t, user.DayOne)
+		assert.Equal(t, 3, user.SessionCount)
+	})
+
+	t.Run("Should not record a session started by another replica", func(t *testing.T) {
+		stale := *user
+		returning := created.Add(40 * 24 * time.Hour)
+		require.NoError(t, TrackUserSession(user, returning))
+		require.NoError(t, TrackUserSession(&stale, returning.Add(time.Minute)))
+		assert.Equal(t, 4, stale.SessionCount)
+
+		summary := GetLoginSummary(stale, returning.Add(time.Minute))
+		assert.Equal(t, 4, summary.TotalSessions)
+		assert.Equal(t, 1, summary.RecentSessions, "older sessions are outside of the recent window")
+		assert.Equal(t, 38, summary.ReturningAfterDays)
+		assert.Equal(t, 40, summary.DaysSinceAccountCreate)
+		assert.Equal(t, 30, summary.RecentDays)
+	})
+}
+
+func TestGetLoginSummaryWindow(t *testing.T) {
+	lifecycleConfig := &config.Get().UserLifecycleConfig
+	original := lifecycleConfig.HistoryDays
+	lifecycleConfig.HistoryDays = 7
+	t.Cleanup(func() {
+		lifecycleConfig.HistoryDays = original
+	})
+
+	now := time.Now()
+	identity := models.UserIdentity{
+		SessionCount: 3,
+		LastLogin:    now,
+		LoginHistory: datatypes.NewJSONType([]time.Time{now.Add(-20 * 24 * time.Hour), now.Add(-3 * 24 * time.Hour), now}),
+	}
+	summary := GetLoginSummary(identity, now)
+	assert.Equal(t, 7, summary.RecentDays, "the window does not span more days than the kept history")
+	assert.Equal(t, 2, summary.RecentSessions)
+}
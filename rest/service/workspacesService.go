@@ -1,20 +1,221 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/RedHatInsights/chrome-service-backend/config"
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/RedHatInsights/chrome-service-backend/rest/workspaces"
+	"github.com/sirupsen/logrus"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// defaultActiveWorkspace is the active workspace of users without recently used workspaces.
+const defaultActiveWorkspace = "default"
+
+var (
+	workspaceClient       workspaces.Client
+	workspaceClientLoaded bool
+	workspaceClientLock   sync.Mutex
 )
 
-// SaveRecentlyUsedWorkspaces saves a user's recently used workspaces in the database.
-func SaveRecentlyUsedWorkspaces(user *models.UserIdentity, recentlyUsedWorkspaces []models.Workspace) error {
-	return database.
-		DB.
-		Model(&user).
-		Updates(
-			models.UserIdentity{
-				ActiveWorkspace:        recentlyUsedWorkspaces[0].Id,
-				RecentlyUsedWorkspaces: datatypes.NewJSONType[[]models.Workspace](recentlyUsedWorkspaces),
-			},
-		).Error
+// SetWorkspaceClient replaces the workspace client built from the configuration. A nil client disables
+// the workspace validation.
+func SetWorkspaceClient(client workspaces.Client) {
+	workspaceClientLock.Lock()
+	defer workspaceClientLock.Unlock()
+	workspaceClient = client
+	workspaceClientLoaded = true
+}
+
+// getWorkspaceClient returns nil when no workspace service is configured.
+func getWorkspaceClient() workspaces.Client {
+	workspaceClientLock.Lock()
+	defer workspaceClientLock.Unlock()
+	if workspaceClientLoaded {
+		return workspaceClient
+	}
+
+	workspacesConfig := config.Get().WorkspacesConfig
+	if workspacesConfig.ServiceURL != "" {
+		workspaceClient = workspaces.NewHTTPClient(workspacesConfig.ServiceURL, &http.Client{Timeout: workspacesConfig.Timeout})
+	}
+	workspaceClientLoaded = true
+	return workspaceClient
+}
+
+type workspaceLookup struct {
+	workspace models.Workspace
+	err       error
+}
+
+// lookupWorkspaces fetches the workspaces concurrently, the results keep the order of the input.
+func lookupWorkspaces(ctx context.Context, client workspaces.Client, identityHeader string, requested []models.Workspace) []workspaceLookup {
+	lookups := make([]workspaceLookup, len(requested))
+	var wg sync.WaitGroup
+	for i, workspace := range requested {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			lookups[i].workspace, lookups[i].err = client.GetWorkspace(ctx, identityHeader, id)
+		}(i, workspace.Id)
+	}
+	wg.Wait()
+	return lookups
+}
+
+func isWorkspaceGone(err error) bool {
+	return errors.Is(err, workspaces.ErrNotFound) || errors.Is(err, workspaces.ErrForbidden)
+}
+
+// ValidateWorkspaces checks that the workspaces exist and the user can access them, and returns them with the
+// metadata of the workspace service. Workspaces are returned unchanged when no workspace service is configured.
+func ValidateWorkspaces(ctx context.Context, identityHeader string, requested []models.Workspace) ([]models.Workspace, error) {
+	client := getWorkspaceClient()
+	if client == nil {
+		return requested, nil
+	}
+
+	validated := make([]models.Workspace, 0, len(requested))
+	for i, lookup := range lookupWorkspaces(ctx, client, identityHeader, requested) {
+		if isWorkspaceGone(lookup.err) {
+			return nil, fmt.Errorf("%w: workspace %s does not exist or is not accessible", util.ErrBadRequest, requested[i].Id)
+		}
+		if lookup.err != nil {
+			return nil, fmt.Errorf("unable to validate workspace %s: %w", requested[i].Id, lookup.err)
+		}
+		validated = append(validated, lookup.workspace)
+	}
+	return validated, nil
+}
+
+// ValidateActiveWorkspace checks that the user can access the workspace. The default workspace is always accepted.
+func ValidateActiveWorkspace(ctx context.Context, identityHeader string, workspace string) error {
+	if workspace == defaultActiveWorkspace {
+		return nil
+	}
+	_, err := ValidateWorkspaces(ctx, identityHeader, []models.Workspace{{Id: workspace}})
+	return err
+}
+
+// storedRecentlyUsedWorkspaces reads the recently used workspaces of the user and their workspaces version.
+func storedRecentlyUsedWorkspaces(userID uint) ([]models.Workspace, int, error) {
+	var identity models.UserIdentity
+	err := database.DB.Select("id", "recently_used_workspaces", "workspaces_version").First(&identity, userID).Error
+	return identity.RecentlyUsedWorkspaces.Data(), identity.WorkspacesVersion, err
+}
+
+// RefreshRecentlyUsedWorkspaces updates the metadata of the user's recently used workspaces from a single listing
+// of the workspaces the user can access. Only workspaces missing from a successful listing were deleted or the
+// user lost access to, they are pruned. When the workspaces cannot be listed they are kept as stored. It returns
+// the workspaces and the workspaces version they belong to, the stored ones when the refreshed ones cannot be
+// saved or a concurrent write changed them.
+func RefreshRecentlyUsedWorkspaces(ctx context.Context, user *models.UserIdentity, identityHeader string) ([]models.Workspace, int, error) {
+	stored, version, err := storedRecentlyUsedWorkspaces(user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	client := getWorkspaceClient()
+	if client == nil || len(stored) == 0 {
+		return stored, version, nil
+	}
+
+	available, err := client.ListWorkspaces(ctx, identityHeader)
+	if err != nil {
+		logrus.Warnf("Unable to refresh the recently used workspaces of user %s: %v", user.AccountId, err)
+		return stored, version, nil
+	}
+	availableByID := make(map[string]models.Workspace, len(available))
+	for _, workspace := range available {
+		availableByID[workspace.Id] = workspace
+	}
+
+	refreshed := make([]models.Workspace, 0, len(stored))
+	for _, workspace := range stored {
+		current, ok := availableByID[workspace.Id]
+		if !ok {
+			logrus.Debugf("Pruning workspace %s from the recently used workspaces of user %s", workspace.Id, user.AccountId)
+			continue
+		}
+		refreshed = append(refreshed, current)
+	}
+
+	if reflect.DeepEqual(refreshed, stored) {
+		return stored, version, nil
+	}
+	refreshedVersion, err := writeRecentlyUsedWorkspaces(user, refreshed, version)
+	if err != nil {
+		if !errors.Is(err, util.ErrPreconditionFailed) {
+			logrus.Errorf("Unable to save the refreshed recently used workspaces of user %s: %v", user.AccountId, err)
+		}
+		return stored, version, nil
+	}
+	return refreshed, refreshedVersion, nil
+}
+
+// SaveRecentlyUsedWorkspaces saves a user's recently used workspaces in the database and returns their workspaces
+// version. The first workspace becomes the active one. With an If-Match header the workspaces are only saved while
+// the stored ones are the version the client read.
+func SaveRecentlyUsedWorkspaces(user *models.UserIdentity, recentlyUsedWorkspaces []models.Workspace, ifMatch string) (int, error) {
+	expectedVersion := 0
+	if ifMatch != "" {
+		_, version, err := storedRecentlyUsedWorkspaces(user.ID)
+		if err != nil {
+			return 0, err
+		}
+		if err := util.CheckIfMatch(ifMatch, RecentlyUsedWorkspacesETag(version)); err != nil {
+			return 0, err
+		}
+		expectedVersion = version
+	}
+	return writeRecentlyUsedWorkspaces(user, recentlyUsedWorkspaces, expectedVersion)
+}
+
+// writeRecentlyUsedWorkspaces saves the workspaces and returns the new workspaces version. A non zero
+// expectedVersion only saves them while the stored workspaces are that version.
+func writeRecentlyUsedWorkspaces(user *models.UserIdentity, recentlyUsedWorkspaces []models.Workspace, expectedVersion int) (int, error) {
+	activeWorkspace := defaultActiveWorkspace
+	if len(recentlyUsedWorkspaces) > 0 {
+		activeWorkspace = recentlyUsedWorkspaces[0].Id
+	}
+	workspaces := datatypes.NewJSONType[[]models.Workspace](recentlyUsedWorkspaces)
+
+	var version int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.UserIdentity{}).Where("id = ?", user.ID)
+		if expectedVersion != 0 {
+			query = query.Where("workspaces_version = ?", expectedVersion)
+		}
+		result := query.Updates(map[string]interface{}{
+			"active_workspace":         activeWorkspace,
+			"recently_used_workspaces": workspaces,
+			"workspaces_version":       gorm.Expr("workspaces_version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if expectedVersion != 0 {
+				return fmt.Errorf("%w: the recently used workspaces were modified, fetch them again and retry", util.ErrPreconditionFailed)
+			}
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.UserIdentity{}).Where("id = ?", user.ID).Select("workspaces_version").Scan(&version).Error
+	})
+	if err == nil {
+		user.ActiveWorkspace = activeWorkspace
+		user.RecentlyUsedWorkspaces = workspaces
+		user.WorkspacesVersion = version
+		refreshCachedIdentity(user)
+	}
+
+	return version, err
 }
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"slices"
 	"testing"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/database"
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/RedHatInsights/chrome-service-backend/rest/workspaces"
 )
 
 // TestSaveRecentlyUsedWorkspaces tests that both the "active workspace" and the workspaces list are saved in the
@@ -38,7 +42,7 @@ func TestSaveRecentlyUsedWorkspaces(t *testing.T) {
 		},
 	}
 
-	if err = SaveRecentlyUsedWorkspaces(user, recentlyUsedWorkspaces); err != nil {
+	if _, err = SaveRecentlyUsedWorkspaces(user, recentlyUsedWorkspaces, ""); err != nil {
 		t.Errorf("unable to save the recently used workspaces for the user: %s", err)
 	}
 
@@ -72,3 +76,81 @@ func TestSaveRecentlyUsedWorkspaces(t *testing.T) {
 		}
 	}
 }
+
+// TestRefreshRecentlyUsedWorkspaces tests that the workspaces' metadata is refreshed and that deleted and
+// inaccessible workspaces are pruned.
+func TestRefreshRecentlyUsedWorkspaces(t *testing.T) {
+	user := &models.UserIdentity{AccountId: "refresh-workspaces-user"}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("unable to save the mock user identity in the database: %s", err)
+	}
+	t.Cleanup(func() {
+		database.DB.Unscoped().Delete(user)
+	})
+
+	stored := []models.Workspace{
+		{Id: "0b5a5a9a-1f52-4d1c-8a2a-6f0d1b2f3a01", Name: "Old name"},
+		{Id: "0b5a5a9a-1f52-4d1c-8a2a-6f0d1b2f3a02", Name: "Deleted"},
+		{Id: "0b5a5a9a-1f52-4d1c-8a2a-6f0d1b2f3a03", Name: "Forbidden"},
+	}
+	if _, err := SaveRecentlyUsedWorkspaces(user, stored, ""); err != nil {
+		t.Fatalf("unable to save the recently used workspaces for the user: %s", err)
+	}
+
+	fake := workspaces.NewFakeClient(
+		models.Workspace{Id: stored[0].Id, Name: "New name"},
+		models.Workspace{Id: stored[2].Id, Name: "Forbidden"},
+	)
+	fake.Forbidden[stored[2].Id] = true
+	SetWorkspaceClient(fake)
+	t.Cleanup(func() {
+		SetWorkspaceClient(nil)
+	})
+
+	if _, err := ValidateWorkspaces(context.Background(), "", stored[1:2]); !errors.Is(err, util.ErrBadRequest) {
+		t.Errorf("deleted workspaces must be rejected with a bad request error, got %v", err)
+	}
+	if err := ValidateActiveWorkspace(context.Background(), "", defaultActiveWorkspace); err != nil {
+		t.Errorf("the default workspace must always be accepted, got %v", err)
+	}
+
+	calls := fake.Calls
+	refreshed, _, err := RefreshRecentlyUsedWorkspaces(context.Background(), user, "")
+	if err != nil {
+		t.Fatalf("unable to refresh the recently used workspaces: %s", err)
+	}
+	if fake.Calls-calls != 1 {
+		t.Errorf("the workspaces must be refreshed with a single request, got %d", fake.Calls-calls)
+	}
+	if len(refreshed) != 1 || refreshed[0].Name != "New name" {
+		t.Errorf(`unexpected refreshed workspaces. Want only "New name", got %v`, refreshed)
+	}
+
+	var retrievedUser models.UserIdentity
+	if err := database.DB.First(&retrievedUser, user.ID).Error; err != nil {
+		t.Fatalf("unable to fetch the stored user from the database: %s", err)
+	}
+	if len(retrievedUser.RecentlyUsedWorkspaces.Data()) != 1 || retrievedUser.ActiveWorkspace != stored[0].Id {
+		t.Errorf("the pruned workspaces were not saved: %v", retrievedUser.RecentlyUsedWorkspaces.Data())
+	}
+
+	// Workspaces that cannot be checked are kept, even when the workspace service answers with not found.
+	for _, lookupErr := range []error{errors.New("workspace service unavailable"), workspaces.ErrNotFound, workspaces.ErrForbidden} {
+		fake.Err = lookupErr
+		refreshed, _, err = RefreshRecentlyUsedWorkspaces(context.Background(), user, "")
+		if err != nil || len(refreshed) != 1 {
+			t.Errorf("workspaces must be kept when they cannot be listed (%v), got %v: %v", lookupErr, refreshed, err)
+		}
+	}
+
+	// Pruning every workspace resets the active workspace.
+	fake.Err = nil
+	fake.Remove(stored[0].Id)
+	refreshed, _, err = RefreshRecentlyUsedWorkspaces(context.Background(), user, "")
+	if err != nil || len(refreshed) != 0 {
+		t.Errorf("every workspace should have been pruned, got %v: %v", refreshed, err)
+	}
+	if user.ActiveWorkspace != defaultActiveWorkspace {
+		t.Errorf(`unexpected active workspace. Want "%s", got "%s"`, defaultActiveWorkspace, user.ActiveWorkspace)
+	}
+}
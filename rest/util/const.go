@@ -17,4 +17,9 @@ const (
 var (
 	ErrNotAuthorized = errors.New("not authorized")
 	ErrBadRequest    = errors.New("bad request")
+	ErrConflict      = errors.New("conflict")
+	ErrTooLarge      = errors.New("payload too large")
+
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrUnprocessable      = errors.New("unprocessable entity")
 )
@@ -24,6 +24,12 @@ const (
 	exportsPath           = "static/exports-generated.json"
 )
 
+// Generated configuration files read by the services after CreateChromeConfiguration runs.
+const (
+	BundlesGeneratedPath    = bundlesPath
+	FedModulesGeneratedPath = fedModulesPath
+)
+
 func getLegacyConfigFile(path string, env string) ([]byte, error) {
 	// read the file
 	file, err := os.ReadFile(fmt.Sprintf(path, env))
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// ETag returns a strong entity tag derived from the version parts of a resource, for example the ID and
+// version column of a row.
+func ETag(parts ...interface{}) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(hash, "%v|", part)
+	}
+	return fmt.Sprintf(`"%x"`, hash.Sum(nil)[:16])
+}
+
+// MatchesETag reports whether an If-None-Match header lists the entity tag. It uses the weak comparison
+// of RFC 9110, weak tags match their strong counterpart and "*" matches any tag.
+func MatchesETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesETagStrongly reports whether an If-Match header lists the entity tag. It uses the strong
+// comparison of RFC 9110, weak tags never match as they do not guarantee the representation is unchanged.
+func matchesETagStrongly(header string, etag string) bool {
+	if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckIfMatch returns ErrPreconditionFailed when the If-Match header is set and does not list the
+// current entity tag.
+func CheckIfMatch(ifMatch string, etag string) error {
+	if strings.TrimSpace(ifMatch) == "" || matchesETagStrongly(ifMatch, etag) {
+		return nil
+	}
+	return fmt.Errorf("%w: the resource was modified, fetch it again and retry", ErrPreconditionFailed)
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesETag(t *testing.T) {
+	etag := ETag("resource", 1, 2)
+	assert.Equal(t, etag, ETag("resource", 1, 2))
+	assert.NotEqual(t, etag, ETag("resource", 1, 3))
+
+	assert.True(t, MatchesETag(etag, etag))
+	assert.True(t, MatchesETag(`"other", `+etag, etag))
+	assert.True(t, MatchesETag("W/"+etag, etag))
+	assert.True(t, MatchesETag("*", etag))
+	assert.False(t, MatchesETag(`"other"`, etag))
+	assert.False(t, MatchesETag("", etag))
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	etag := ETag("resource", 1)
+	assert.NoError(t, CheckIfMatch("", etag))
+	assert.NoError(t, CheckIfMatch(etag, etag))
+	assert.NoError(t, CheckIfMatch("*", etag))
+	assert.ErrorIs(t, CheckIfMatch(ETag("resource", 2), etag), ErrPreconditionFailed)
+	// If-Match uses the strong comparison
+	assert.ErrorIs(t, CheckIfMatch("W/"+etag, etag), ErrPreconditionFailed)
+	assert.ErrorIs(t, CheckIfMatch(etag, "W/"+etag), ErrPreconditionFailed)
+}
@@ -0,0 +1,281 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	LIMIT_PARAM  = "limit"
+	OFFSET_PARAM = "offset"
+	SORT_PARAM   = "sort"
+)
+
+// ListFilter maps a filter query param to a column. Parse converts the raw value, and rejects invalid ones.
+type ListFilter struct {
+	Column string
+	Parse  func(value string) (interface{}, error)
+}
+
+func StringFilter(column string) ListFilter {
+	return ListFilter{Column: column, Parse: func(value string) (interface{}, error) {
+		return value, nil
+	}}
+}
+
+func BoolFilter(column string) ListFilter {
+	return ListFilter{Column: column, Parse: func(value string) (interface{}, error) {
+		return strconv.ParseBool(value)
+	}}
+}
+
+// ListQueryOptions describes the pagination, sorting and filtering a list endpoint supports.
+type ListQueryOptions struct {
+	// DefaultLimit applies when the limit param is missing, 0 returns every item
+	DefaultLimit int
+	MaxLimit     int
+	// SortFields maps the sort param fields to columns
+	SortFields map[string]string
+	// DefaultSort uses the sort param syntax, for example "-updated,name"
+	DefaultSort string
+	// TieBreaker is appended to the sort columns so pages are stable
+	TieBreaker string
+	Filters    map[string]ListFilter
+}
+
+type SortField struct {
+	Name   string
+	Column string
+	Desc   bool
+}
+
+type FilterValue struct {
+	Name   string
+	Column string
+	Value  interface{}
+}
+
+// ListQuery is the parsed list request.
+type ListQuery struct {
+	Limit      int
+	Offset     int
+	Sort       []SortField
+	Filters    []FilterValue
+	tieBreaker string
+}
+
+func parseSort(param string, options ListQueryOptions) ([]SortField, error) {
+	fields := []SortField{}
+	if strings.TrimSpace(param) == "" {
+		return fields, nil
+	}
+	for _, name := range strings.Split(param, ",") {
+		field := SortField{Name: strings.TrimSpace(name)}
+		if strings.HasPrefix(field.Name, "-") {
+			field.Name, field.Desc = field.Name[1:], true
+		}
+		column, ok := options.SortFields[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid sort field %q, expected one of %s", ErrBadRequest, field.Name, strings.Join(sortedKeys(options.SortFields), ", "))
+		}
+		if slices.ContainsFunc(fields, func(f SortField) bool { return f.Name == field.Name }) {
+			return nil, fmt.Errorf("%w: duplicate sort field %q", ErrBadRequest, field.Name)
+		}
+		field.Column = column
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ParseListQuery reads the limit, offset, sort and filter query params. Invalid values are reported
+// as ErrBadRequest.
+func ParseListQuery(query url.Values, options ListQueryOptions) (ListQuery, error) {
+	listQuery := ListQuery{Limit: options.DefaultLimit, tieBreaker: options.TieBreaker}
+
+	if param := query.Get(LIMIT_PARAM); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value < 1 || (options.MaxLimit > 0 && value > options.MaxLimit) {
+			if options.MaxLimit > 0 {
+				return listQuery, fmt.Errorf("%w: invalid limit %s, expected a number between 1 and %d", ErrBadRequest, param, options.MaxLimit)
+			}
+			return listQuery, fmt.Errorf("%w: invalid limit %s, expected a positive number", ErrBadRequest, param)
+		}
+		listQuery.Limit = value
+	}
+	if param := query.Get(OFFSET_PARAM); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value < 0 {
+			return listQuery, fmt.Errorf("%w: invalid offset %s, expected a positive number", ErrBadRequest, param)
+		}
+		listQuery.Offset = value
+	}
+
+	sortParam := options.DefaultSort
+	if query.Has(SORT_PARAM) {
+		sortParam = query.Get(SORT_PARAM)
+	}
+	sort, err := parseSort(sortParam, options)
+	if err != nil {
+		return listQuery, err
+	}
+	listQuery.Sort = sort
+
+	for _, name := range sortedKeys(options.Filters) {
+		if !query.Has(name) {
+			continue
+		}
+		filter := options.Filters[name]
+		value, err := filter.Parse(query.Get(name))
+		if err != nil {
+			return listQuery, fmt.Errorf("%w: invalid %s filter %q", ErrBadRequest, name, query.Get(name))
+		}
+		listQuery.Filters = append(listQuery.Filters, FilterValue{Name: name, Column: filter.Column, Value: value})
+	}
+
+	return listQuery, nil
+}
+
+// Filter returns the parsed value of a filter param.
+func (lq ListQuery) Filter(name string) (interface{}, bool) {
+	for _, filter := range lq.Filters {
+		if filter.Name == name {
+			return filter.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Apply adds the filters, order and page of the query to db.
+func (lq ListQuery) Apply(db *gorm.DB) *gorm.DB {
+	db = lq.ApplyFilters(db)
+	for _, field := range lq.Sort {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: field.Column}, Desc: field.Desc})
+	}
+	if lq.tieBreaker != "" {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: lq.tieBreaker}})
+	}
+	if lq.Limit > 0 {
+		db = db.Limit(lq.Limit)
+	}
+	if lq.Offset > 0 {
+		db = db.Offset(lq.Offset)
+	}
+	return db
+}
+
+// ApplyFilters adds only the filters of the query to db, for counting the matching rows.
+func (lq ListQuery) ApplyFilters(db *gorm.DB) *gorm.DB {
+	for _, filter := range lq.Filters {
+		db = db.Where(clause.Eq{Column: clause.Column{Name: filter.Column}, Value: filter.Value})
+	}
+	return db
+}
+
+// FindPage counts the rows matching db and the query filters, and loads the requested page of them.
+func FindPage[T any](db *gorm.DB, lq ListQuery) ([]T, int, error) {
+	db = db.Session(&gorm.Session{})
+	var total int64
+	if err := lq.ApplyFilters(db).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	items := []T{}
+	err := lq.Apply(db).Find(&items).Error
+	return items, int(total), err
+}
+
+// SortSlice orders in memory lists by the query sort fields. Fields without a compare function keep their order.
+func SortSlice[T any](items []T, lq ListQuery, compare map[string]func(a T, b T) int) {
+	if len(lq.Sort) == 0 {
+		return
+	}
+	slices.SortStableFunc(items, func(a T, b T) int {
+		for _, field := range lq.Sort {
+			cmp, ok := compare[field.Name]
+			if !ok {
+				continue
+			}
+			result := cmp(a, b)
+			if field.Desc {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+}
+
+// PageSlice returns the requested page of an in memory list.
+func PageSlice[T any](items []T, lq ListQuery) []T {
+	if lq.Offset >= len(items) {
+		return []T{}
+	}
+	end := len(items)
+	if lq.Limit > 0 && lq.Offset+lq.Limit < end {
+		end = lq.Offset + lq.Limit
+	}
+	return items[lq.Offset:end]
+}
+
+// Meta describes a page of count items out of total.
+func (lq ListQuery) Meta(count int, total int) ListMeta {
+	return ListMeta{Count: count, Total: total, Limit: lq.Limit, Offset: lq.Offset}
+}
+
+func pageLink(requestURL *url.URL, limit int, offset int) string {
+	link := *requestURL
+	query := link.Query()
+	query.Set(LIMIT_PARAM, strconv.Itoa(limit))
+	query.Set(OFFSET_PARAM, strconv.Itoa(offset))
+	link.RawQuery = query.Encode()
+	link.Scheme, link.Host = "", ""
+	return link.String()
+}
+
+// Links builds the first, previous, next and last page links of a paginated list. Lists without a
+// limit are a single page and have no links.
+func (lq ListQuery) Links(requestURL *url.URL, total int) *ListLinks {
+	if lq.Limit <= 0 {
+		return nil
+	}
+	lastOffset := 0
+	if total > 0 {
+		lastOffset = (total - 1) / lq.Limit * lq.Limit
+	}
+	links := &ListLinks{
+		First: pageLink(requestURL, lq.Limit, 0),
+		Last:  pageLink(requestURL, lq.Limit, lastOffset),
+	}
+	if lq.Offset > 0 {
+		links.Previous = pageLink(requestURL, lq.Limit, max(lq.Offset-lq.Limit, 0))
+	}
+	if lq.Offset+lq.Limit < total {
+		links.Next = pageLink(requestURL, lq.Limit, lq.Offset+lq.Limit)
+	}
+	return links
+}
+
+// NewListResponse wraps a page of items with its metadata and links.
+func NewListResponse[T any](items []T, total int, lq ListQuery, requestURL *url.URL) ListResponse[T] {
+	return ListResponse[T]{
+		Data:  items,
+		Meta:  lq.Meta(len(items), total),
+		Links: lq.Links(requestURL, total),
+	}
+}
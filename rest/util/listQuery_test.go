@@ -0,0 +1,118 @@
+package util_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var testListOptions = util.ListQueryOptions{
+	DefaultLimit: 2,
+	MaxLimit:     10,
+	SortFields:   map[string]string{"name": "name", "rank": "rank"},
+	DefaultSort:  "name",
+	TieBreaker:   "id",
+	Filters: map[string]util.ListFilter{
+		"name":   util.StringFilter("name"),
+		"active": util.BoolFilter("active"),
+	},
+}
+
+func parseTestQuery(t *testing.T, raw string) (util.ListQuery, error) {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	require.NoError(t, err)
+	return util.ParseListQuery(values, testListOptions)
+}
+
+func TestParseListQuery(t *testing.T) {
+	query, err := parseTestQuery(t, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, query.Limit)
+	assert.Equal(t, []util.SortField{{Name: "name", Column: "name"}}, query.Sort)
+
+	query, err = parseTestQuery(t, "limit=5&offset=3&sort=-rank,name&active=true")
+	require.NoError(t, err)
+	assert.Equal(t, 5, query.Limit)
+	assert.Equal(t, 3, query.Offset)
+	assert.Equal(t, []util.SortField{{Name: "rank", Column: "rank", Desc: true}, {Name: "name", Column: "name"}}, query.Sort)
+	active, ok := query.Filter("active")
+	assert.True(t, ok)
+	assert.Equal(t, true, active)
+
+	for _, raw := range []string{"limit=0", "limit=11", "limit=abc", "offset=-1", "sort=unknown", "sort=name,-name", "active=maybe"} {
+		_, err := parseTestQuery(t, raw)
+		assert.True(t, errors.Is(err, util.ErrBadRequest), "expected bad request for %s, got %v", raw, err)
+	}
+}
+
+func TestListLinks(t *testing.T) {
+	requestURL, err := url.Parse("https://console.redhat.com/api/items?sort=name&limit=2&offset=2")
+	require.NoError(t, err)
+	query, err := util.ParseListQuery(requestURL.Query(), testListOptions)
+	require.NoError(t, err)
+
+	links := query.Links(requestURL, 5)
+	require.NotNil(t, links)
+	assert.Equal(t, "/api/items?limit=2&offset=0&sort=name", links.First)
+	assert.Equal(t, "/api/items?limit=2&offset=0&sort=name", links.Previous)
+	assert.Equal(t, "/api/items?limit=2&offset=4&sort=name", links.Next)
+	assert.Equal(t, "/api/items?limit=2&offset=4&sort=name", links.Last)
+
+	query.Offset = 4
+	links = query.Links(requestURL, 5)
+	assert.Empty(t, links.Next)
+
+	assert.Nil(t, util.ListQuery{}.Links(requestURL, 5), "unpaginated lists have no links")
+}
+
+func TestSliceHelpers(t *testing.T) {
+	items := []string{"b", "c", "a", "d"}
+	query := util.ListQuery{Limit: 2, Offset: 1, Sort: []util.SortField{{Name: "value", Desc: true}}}
+	util.SortSlice(items, query, map[string]func(a string, b string) int{"value": strings.Compare})
+	assert.Equal(t, []string{"d", "c", "b", "a"}, items)
+	assert.Equal(t, []string{"c", "b"}, util.PageSlice(items, query))
+	assert.Empty(t, util.PageSlice(items, util.ListQuery{Offset: 10}))
+}
+
+type listQueryItem struct {
+	ID     uint
+	Name   string
+	Rank   int
+	Active bool
+}
+
+func TestFindPage(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&listQueryItem{}))
+	require.NoError(t, db.Create(&[]listQueryItem{
+		{Name: "c", Rank: 1, Active: true},
+		{Name: "a", Rank: 2, Active: true},
+		{Name: "b", Rank: 2, Active: false},
+		{Name: "d", Rank: 3, Active: true},
+	}).Error)
+
+	query, err := parseTestQuery(t, "active=true&sort=-rank")
+	require.NoError(t, err)
+	items, total, err := util.FindPage[listQueryItem](db.Model(&listQueryItem{}), query)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, items, 2)
+	assert.Equal(t, "d", items[0].Name)
+	assert.Equal(t, "a", items[1].Name)
+
+	query.Offset = 2
+	items, total, err = util.FindPage[listQueryItem](db.Model(&listQueryItem{}), query)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, items, 1)
+	assert.Equal(t, "c", items[0].Name)
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const PROBLEM_CONTENT_TYPE = "application/problem+json"
+
+// Problem is an RFC 7807 error response. Errors repeats the detail, or lists every validation error,
+// for clients of the previous {"errors": [...]} responses.
+type Problem struct {
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Status    int      `json:"status"`
+	Code      string   `json:"code"`
+	Detail    string   `json:"detail,omitempty"`
+	Instance  string   `json:"instance,omitempty"`
+	RequestID string   `json:"requestId,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+	cause     error
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+func (p *Problem) Unwrap() error {
+	return p.cause
+}
+
+// problemCode is the machine readable code of the status, for example "not_found".
+func problemCode(status int) string {
+	if status == http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// NewProblem creates a problem with the default code of the status.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   problemCode(status),
+		Detail: detail,
+	}
+}
+
+// WithCode replaces the default code of the status with a more specific one.
+func (p *Problem) WithCode(code string) *Problem {
+	p.Code = code
+	return p
+}
+
+// WithErrors lists every validation error of the request, the first one is used as the detail.
+func (p *Problem) WithErrors(errs []string) *Problem {
+	p.Errors = errs
+	if p.Detail == "" && len(errs) > 0 {
+		p.Detail = errs[0]
+	}
+	return p
+}
+
+// badRequestError marks an error as a client error while keeping its message.
+type badRequestError struct {
+	err error
+}
+
+func (e badRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e badRequestError) Unwrap() []error {
+	return []error{e.err, ErrBadRequest}
+}
+
+// BadRequest marks validation errors, for example the ones returned by the models, as client errors.
+// The message is kept so it can be shown to the client.
+func BadRequest(err error) error {
+	if err == nil || errors.Is(err, ErrBadRequest) {
+		return err
+	}
+	return badRequestError{err: err}
+}
+
+// ProblemFromError maps the service errors to problems. Unknown errors become internal errors and
+// their message is not exposed.
+func ProblemFromError(err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		problem = NewProblem(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotAuthorized):
+		problem = NewProblem(http.StatusForbidden, "not authorized").WithCode("not_authorized")
+	case errors.Is(err, ErrConflict):
+		problem = NewProblem(http.StatusConflict, err.Error())
+	case errors.Is(err, ErrTooLarge):
+		problem = NewProblem(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrPreconditionFailed):
+		problem = NewProblem(http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, ErrUnprocessable):
+		problem = NewProblem(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, ErrBadRequest):
+		problem = NewProblem(http.StatusBadRequest, err.Error())
+	default:
+		problem = NewProblem(http.StatusInternalServerError, "internal server error")
+	}
+	problem.cause = err
+	return problem
+}
+
+// WriteProblem renders the problem as application/problem+json, with the path and request id of r.
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	response := *problem
+	response.Instance = r.URL.Path
+	response.RequestID = middleware.GetReqID(r.Context())
+	if len(response.Errors) == 0 {
+		response.Errors = []string{response.Error()}
+	}
+
+	w.Header().Set("Content-Type", PROBLEM_CONTENT_TYPE)
+	w.WriteHeader(response.Status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// WriteError renders err as a problem. Internal errors are logged since their message is not exposed.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := ProblemFromError(err)
+	if problem.Status >= http.StatusInternalServerError {
+		logrus.WithField("request_id", middleware.GetReqID(r.Context())).Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+	WriteProblem(w, r, problem)
+}
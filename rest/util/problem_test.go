@@ -0,0 +1,60 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestProblemFromError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{gorm.ErrRecordNotFound, http.StatusNotFound, "not_found", "record not found"},
+		{fmt.Errorf("template 1: %w", ErrNotAuthorized), http.StatusForbidden, "not_authorized", "not authorized"},
+		{fmt.Errorf("%w: invalid limit", ErrBadRequest), http.StatusBadRequest, "bad_request", "bad request: invalid limit"},
+		{BadRequest(errors.New("invalid grid size")), http.StatusBadRequest, "bad_request", "invalid grid size"},
+		{fmt.Errorf("%w: stale", ErrPreconditionFailed), http.StatusPreconditionFailed, "precondition_failed", "precondition failed: stale"},
+		{fmt.Errorf("%w: key reused", ErrUnprocessable), http.StatusUnprocessableEntity, "unprocessable_entity", "unprocessable entity: key reused"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+	for _, test := range tests {
+		problem := ProblemFromError(test.err)
+		assert.Equal(t, test.status, problem.Status, test.err.Error())
+		assert.Equal(t, test.code, problem.Code, test.err.Error())
+		assert.Equal(t, test.detail, problem.Detail, test.err.Error())
+		assert.ErrorIs(t, problem, test.err)
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api/chrome-service/v1/favorite-pages?archived=false", nil)
+	request = request.WithContext(context.WithValue(request.Context(), middleware.RequestIDKey, "request-1"))
+	recorder := httptest.NewRecorder()
+
+	WriteError(recorder, request, fmt.Errorf("%w: invalid limit 0", ErrBadRequest))
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, PROBLEM_CONTENT_TYPE, recorder.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Bad Request", problem.Title)
+	assert.Equal(t, "bad request: invalid limit 0", problem.Detail)
+	assert.Equal(t, "/api/chrome-service/v1/favorite-pages", problem.Instance)
+	assert.Equal(t, "request-1", problem.RequestID)
+	assert.Equal(t, []string{"bad request: invalid limit 0"}, problem.Errors)
+}
@@ -0,0 +1,182 @@
+package util
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	RateLimitMemory = "memory"
+	RateLimitShared = "shared"
+)
+
+const DefaultRateLimitJanitorInterval = time.Minute
+
+// RateLimit is a token bucket holding up to Burst requests and refilled with PerMinute requests every
+// minute. A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (rl RateLimit) Enabled() bool {
+	return rl.PerMinute > 0
+}
+
+// Capacity is the number of requests a full bucket allows.
+func (rl RateLimit) Capacity() float64 {
+	if rl.Burst > 0 {
+		return float64(rl.Burst)
+	}
+	return float64(rl.PerMinute)
+}
+
+// RefillPerSecond is the number of tokens added to the bucket every second.
+func (rl RateLimit) RefillPerSecond() float64 {
+	return float64(rl.PerMinute) / 60
+}
+
+// RateLimitDecision is the outcome of taking a token from a bucket.
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token, only set when the request is denied
+	RetryAfter time.Duration
+}
+
+// TakeRateLimitToken refills a bucket holding tokens since updatedAt and takes a token from it. It
+// returns the tokens left in the bucket, backends store them with now as the update time.
+func TakeRateLimitToken(limit RateLimit, tokens float64, updatedAt time.Time, now time.Time) (float64, RateLimitDecision) {
+	capacity := limit.Capacity()
+	rate := limit.RefillPerSecond()
+	if elapsed := now.Sub(updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, NewRateLimitDecision(limit, tokens, allowed)
+}
+
+// NewRateLimitDecision describes a bucket left with tokens by a request that took a token or was denied.
+func NewRateLimitDecision(limit RateLimit, tokens float64, allowed bool) RateLimitDecision {
+	capacity := limit.Capacity()
+	rate := limit.RefillPerSecond()
+	decision := RateLimitDecision{Limit: int(capacity), Allowed: allowed}
+	if !allowed {
+		decision.RetryAfter = secondsDuration((1 - tokens) / rate)
+	}
+	decision.Remaining = int(math.Floor(tokens))
+	decision.Reset = secondsDuration((capacity - tokens) / rate)
+	return decision
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
+
+// RateLimiter is implemented by every rate limit backend.
+type RateLimiter interface {
+	// Take takes a token from the bucket of key, creating a full bucket when it does not exist yet.
+	Take(key string, limit RateLimit, now time.Time) (RateLimitDecision, error)
+	// Refund puts back a token taken by Take, for requests another bucket denied.
+	Refund(key string, limit RateLimit, now time.Time) error
+	// Close stops any background work owned by the limiter.
+	Close()
+}
+
+type rateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket is full again and can be forgotten
+	fullAt time.Time
+}
+
+// MemoryRateLimiter keeps the buckets of a single replica. A janitor goroutine forgets buckets that
+// refilled completely, they are equivalent to new buckets.
+type MemoryRateLimiter struct {
+	sync.Mutex
+	buckets map[string]*rateLimitBucket
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func NewMemoryRateLimiter(janitorInterval time.Duration) *MemoryRateLimiter {
+	if janitorInterval <= 0 {
+		janitorInterval = DefaultRateLimitJanitorInterval
+	}
+	limiter := &MemoryRateLimiter{
+		buckets: make(map[string]*rateLimitBucket),
+		stop:    make(chan struct{}),
+	}
+	go limiter.runJanitor(janitorInterval)
+	return limiter
+}
+
+func (ml *MemoryRateLimiter) Take(key string, limit RateLimit, now time.Time) (RateLimitDecision, error) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	bucket, ok := ml.buckets[key]
+	if !ok {
+		bucket = &rateLimitBucket{tokens: limit.Capacity(), updatedAt: now}
+		ml.buckets[key] = bucket
+	}
+	tokens, decision := TakeRateLimitToken(limit, bucket.tokens, bucket.updatedAt, now)
+	bucket.tokens = tokens
+	bucket.updatedAt = now
+	bucket.fullAt = now.Add(decision.Reset)
+	return decision, nil
+}
+
+func (ml *MemoryRateLimiter) Refund(key string, limit RateLimit, now time.Time) error {
+	ml.Lock()
+	defer ml.Unlock()
+
+	if bucket, ok := ml.buckets[key]; ok {
+		bucket.tokens = math.Min(limit.Capacity(), bucket.tokens+1)
+	}
+	return nil
+}
+
+// Len returns the number of buckets currently held by the limiter.
+func (ml *MemoryRateLimiter) Len() int {
+	ml.Lock()
+	defer ml.Unlock()
+	return len(ml.buckets)
+}
+
+func (ml *MemoryRateLimiter) removeFull(now time.Time) {
+	ml.Lock()
+	defer ml.Unlock()
+	for key, bucket := range ml.buckets {
+		if !bucket.fullAt.After(now) {
+			delete(ml.buckets, key)
+		}
+	}
+}
+
+func (ml *MemoryRateLimiter) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			ml.removeFull(now)
+		case <-ml.stop:
+			return
+		}
+	}
+}
+
+func (ml *MemoryRateLimiter) Close() {
+	ml.once.Do(func() {
+		close(ml.stop)
+	})
+}
@@ -0,0 +1,70 @@
+package util_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	limit := util.RateLimit{PerMinute: 60, Burst: 2}
+	now := time.Now()
+
+	t.Run("should take tokens of a full bucket", func(t *testing.T) {
+		tokens, decision := util.TakeRateLimitToken(limit, 2, now, now)
+		assert.Equal(t, 1.0, tokens)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 2, decision.Limit)
+		assert.Equal(t, 1, decision.Remaining)
+		assert.Equal(t, time.Second, decision.Reset)
+	})
+
+	t.Run("should deny requests of an empty bucket", func(t *testing.T) {
+		tokens, decision := util.TakeRateLimitToken(limit, 0.5, now, now)
+		assert.Equal(t, 0.5, tokens)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, 0, decision.Remaining)
+		assert.Equal(t, time.Second, decision.RetryAfter)
+		assert.Equal(t, 2*time.Second, decision.Reset)
+	})
+
+	t.Run("should refill up to the burst", func(t *testing.T) {
+		tokens, decision := util.TakeRateLimitToken(limit, 0, now.Add(-time.Hour), now)
+		assert.Equal(t, 1.0, tokens)
+		assert.True(t, decision.Allowed)
+	})
+
+	t.Run("should default the burst to the rate", func(t *testing.T) {
+		assert.Equal(t, 60.0, util.RateLimit{PerMinute: 60}.Capacity())
+		assert.False(t, util.RateLimit{}.Enabled())
+	})
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	limiter := util.NewMemoryRateLimiter(time.Hour)
+	defer limiter.Close()
+	limit := util.RateLimit{PerMinute: 60, Burst: 2}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		decision, err := limiter.Take("user:1:write", limit, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+	decision, err := limiter.Take("user:1:write", limit, now)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+
+	// buckets are independent
+	decision, err = limiter.Take("user:1:read", limit, now)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	decision, err = limiter.Take("user:1:write", limit, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 2, limiter.Len())
+}
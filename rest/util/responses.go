@@ -7,9 +7,18 @@ type ListMeta struct {
 	Offset int `json:"offset,omitempty"`
 }
 
+// ListLinks point to the other pages of a paginated list.
+type ListLinks struct {
+	First    string `json:"first,omitempty"`
+	Previous string `json:"previous,omitempty"`
+	Next     string `json:"next,omitempty"`
+	Last     string `json:"last,omitempty"`
+}
+
 type ListResponse[T interface{}] struct {
-	Data []T      `json:"data"`
-	Meta ListMeta `json:"meta"`
+	Data  []T        `json:"data"`
+	Meta  ListMeta   `json:"meta"`
+	Links *ListLinks `json:"links,omitempty"`
 }
 
 type EntityResponse[T interface{}] struct {
@@ -1,62 +1,304 @@
 package util
 
 import (
+	"container/list"
 	"sync"
 	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
+const (
+	IdentityCacheMemory = "memory"
+	IdentityCacheShared = "shared"
+)
+
+const (
+	/**
+	* Fairly short cache time is OK
+	* Mainly we are looking to speed up burst traffic for a single user
+	* Typically on session start where we get about 3-4 hits per user per session
+	 */
+	DefaultIdentityCacheTTL             = time.Second * 30
+	DefaultIdentityCacheMaxEntries      = 10000
+	DefaultIdentityCacheJanitorInterval = time.Minute
+)
+
+// IdentityCache is implemented by every user identity cache backend.
+type IdentityCache interface {
+	// Get returns the cached identity if it exists and has not expired.
+	Get(accountId string) (models.UserIdentity, bool)
+	// Set stores the identity in the local cache only. Use it to fill the cache after a read.
+	Set(accountId string, identity models.UserIdentity)
+	// Delete removes the identity, a shared cache also drops it on the other replicas.
+	Delete(accountId string)
+	// Refresh stores an identity that was just mutated and tells other replicas to drop their copy.
+	Refresh(accountId string, identity models.UserIdentity)
+	// Len returns the number of entries currently held by the cache.
+	Len() int
+	// Close stops any background work owned by the cache.
+	Close()
+}
+
+type IdentityCacheOptions struct {
+	MaxEntries      int
+	TTL             time.Duration
+	JanitorInterval time.Duration
+}
+
+// withDefaults fills unset or invalid options with the package defaults.
+func (o IdentityCacheOptions) withDefaults() IdentityCacheOptions {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = DefaultIdentityCacheMaxEntries
+	}
+	if o.TTL <= 0 {
+		o.TTL = DefaultIdentityCacheTTL
+	}
+	if o.JanitorInterval <= 0 {
+		o.JanitorInterval = DefaultIdentityCacheJanitorInterval
+	}
+	return o
+}
+
 type CacheEntry struct {
-	ExpireAt time.Time
-	Identity models.UserIdentity
+	AccountId string
+	ExpireAt  time.Time
+	Identity  models.UserIdentity
 }
 
-type UserIdentityCache struct {
-	Identities map[string]CacheEntry
+// LRUIdentityCache is a size bounded in-memory cache. The least recently used entry is evicted
+// once the cache is full and a janitor goroutine periodically removes expired entries.
+type LRUIdentityCache struct {
 	sync.Mutex
+	options IdentityCacheOptions
+	entries map[string]*list.Element
+	order   *list.List
+	stop    chan struct{}
+	once    sync.Once
 }
 
-func (c *UserIdentityCache) Get(accountId string) (models.UserIdentity, bool) {
+func NewLRUIdentityCache(options IdentityCacheOptions) *LRUIdentityCache {
+	options = options.withDefaults()
+	c := &LRUIdentityCache{
+		options: options,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		stop:    make(chan struct{}),
+	}
+	go c.runJanitor()
+	return c
+}
+
+func (c *LRUIdentityCache) Get(accountId string) (models.UserIdentity, bool) {
 	// Lock the cache to prevent out of sync operations
 	c.Lock()
 	defer c.Unlock()
 
-	entry, ok := c.Identities[accountId]
+	element, ok := c.entries[accountId]
 	if !ok {
+		identityCacheMisses.Inc()
 		return models.UserIdentity{}, false
 	}
+	entry := element.Value.(*CacheEntry)
 	if entry.ExpireAt.Before(time.Now()) {
-		delete(c.Identities, accountId)
+		c.removeElement(element)
+		identityCacheEvictions.WithLabelValues("expired").Inc()
+		identityCacheMisses.Inc()
 		return models.UserIdentity{}, false
 	}
+	c.order.MoveToFront(element)
+	identityCacheHits.Inc()
 	return entry.Identity, true
 }
 
-func (c *UserIdentityCache) Set(accountId string, identity models.UserIdentity) {
+func (c *LRUIdentityCache) Set(accountId string, identity models.UserIdentity) {
+	c.Lock()
+	defer c.Unlock()
+
+	expireAt := time.Now().Add(c.options.TTL)
+	if element, ok := c.entries[accountId]; ok {
+		entry := element.Value.(*CacheEntry)
+		entry.Identity = identity
+		entry.ExpireAt = expireAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[accountId] = c.order.PushFront(&CacheEntry{
+		AccountId: accountId,
+		ExpireAt:  expireAt,
+		Identity:  identity,
+	})
+
+	for c.order.Len() > c.options.MaxEntries {
+		c.removeElement(c.order.Back())
+		identityCacheEvictions.WithLabelValues("capacity").Inc()
+	}
+	identityCacheSize.Set(float64(c.order.Len()))
+}
+
+func (c *LRUIdentityCache) Delete(accountId string) {
 	c.Lock()
 	defer c.Unlock()
 
-	c.Identities[accountId] = CacheEntry{
-		/**
-		* Fairly short cache time is OK
-		* Mainly we are looking to speed up burst traffic for a single user
-		* Typically on session start where we get about 3-4 hits per user per session
-		 */
-		ExpireAt: time.Now().Add(time.Second * 30),
-		Identity: identity,
+	if element, ok := c.entries[accountId]; ok {
+		c.removeElement(element)
 	}
 }
 
-func (c *UserIdentityCache) Delete(accountId string) {
+// Refresh is equal to Set for a cache that is not shared between replicas.
+func (c *LRUIdentityCache) Refresh(accountId string, identity models.UserIdentity) {
+	c.Set(accountId, identity)
+}
+
+func (c *LRUIdentityCache) Len() int {
 	c.Lock()
 	defer c.Unlock()
 
-	delete(c.Identities, accountId)
+	return c.order.Len()
+}
+
+func (c *LRUIdentityCache) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+}
+
+// EvictExpired removes every expired entry and returns the number of removed entries.
+func (c *LRUIdentityCache) EvictExpired() int {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	evicted := 0
+	for element := c.order.Back(); element != nil; {
+		prev := element.Prev()
+		if element.Value.(*CacheEntry).ExpireAt.Before(now) {
+			c.removeElement(element)
+			evicted++
+		}
+		element = prev
+	}
+	identityCacheEvictions.WithLabelValues("expired").Add(float64(evicted))
+	return evicted
+}
+
+// removeElement expects the caller to hold the lock.
+func (c *LRUIdentityCache) removeElement(element *list.Element) {
+	entry := c.order.Remove(element).(*CacheEntry)
+	delete(c.entries, entry.AccountId)
+	identityCacheSize.Set(float64(c.order.Len()))
+}
+
+func (c *LRUIdentityCache) runJanitor() {
+	ticker := time.NewTicker(c.options.JanitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if evicted := c.EvictExpired(); evicted > 0 {
+				logrus.Debugf("identity cache janitor evicted %d entries", evicted)
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// CacheInvalidationBus distributes identity invalidations between service replicas.
+type CacheInvalidationBus interface {
+	Publish(message IdentityInvalidation) error
+	Subscribe(handler func(message IdentityInvalidation)) error
+	Close() error
+}
+
+type IdentityInvalidation struct {
+	Origin    string `json:"origin"`
+	AccountId string `json:"accountId"`
+}
+
+// SharedIdentityCache keeps identities in a local cache and uses an invalidation bus to make
+// sure that a mutation on one replica is not hidden by a stale entry on another one.
+type SharedIdentityCache struct {
+	local      IdentityCache
+	bus        CacheInvalidationBus
+	instanceId string
+}
+
+func NewSharedIdentityCache(local IdentityCache, bus CacheInvalidationBus) (*SharedIdentityCache, error) {
+	c := &SharedIdentityCache{
+		local:      local,
+		bus:        bus,
+		instanceId: uuid.NewString(),
+	}
+	err := bus.Subscribe(c.handleInvalidation)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *SharedIdentityCache) handleInvalidation(message IdentityInvalidation) {
+	// the replica that published the message already holds the up to date identity
+	if message.Origin == c.instanceId {
+		return
+	}
+	identityCacheInvalidations.WithLabelValues("received").Inc()
+	c.local.Delete(message.AccountId)
+}
+
+func (c *SharedIdentityCache) publish(accountId string) {
+	err := c.bus.Publish(IdentityInvalidation{Origin: c.instanceId, AccountId: accountId})
+	if err != nil {
+		logrus.Errorf("unable to publish identity cache invalidation for %s: %v", accountId, err)
+		return
+	}
+	identityCacheInvalidations.WithLabelValues("published").Inc()
+}
+
+func (c *SharedIdentityCache) Get(accountId string) (models.UserIdentity, bool) {
+	return c.local.Get(accountId)
+}
+
+func (c *SharedIdentityCache) Set(accountId string, identity models.UserIdentity) {
+	c.local.Set(accountId, identity)
+}
+
+// Delete drops the local entry and asks every other replica to do the same.
+func (c *SharedIdentityCache) Delete(accountId string) {
+	c.local.Delete(accountId)
+	c.publish(accountId)
+}
+
+func (c *SharedIdentityCache) Refresh(accountId string, identity models.UserIdentity) {
+	c.local.Set(accountId, identity)
+	c.publish(accountId)
+}
+
+func (c *SharedIdentityCache) Len() int {
+	return c.local.Len()
 }
 
-var UsersCache *UserIdentityCache
+func (c *SharedIdentityCache) Close() {
+	if err := c.bus.Close(); err != nil {
+		logrus.Errorf("unable to close identity cache invalidation bus: %v", err)
+	}
+	c.local.Close()
+}
 
-func InitUserIdentitiesCache() {
-	UsersCache = &UserIdentityCache{Identities: make(map[string]CacheEntry)}
+var UsersCache IdentityCache
+
+// InitUserIdentitiesCache sets up the in-memory identity cache. Use SetUserIdentitiesCache
+// to replace it with a shared backend once the invalidation bus is available.
+func InitUserIdentitiesCache(options IdentityCacheOptions) {
+	SetUserIdentitiesCache(NewLRUIdentityCache(options))
+}
+
+func SetUserIdentitiesCache(cache IdentityCache) {
+	if UsersCache != nil {
+		UsersCache.Close()
+	}
+	UsersCache = cache
 }
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	identityCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chrome_service_identity_cache_hits_total",
+		Help: "Number of user identity lookups served from the cache",
+	})
+	identityCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chrome_service_identity_cache_misses_total",
+		Help: "Number of user identity lookups that were not found in the cache",
+	})
+	identityCacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chrome_service_identity_cache_evictions_total",
+		Help: "Number of user identities evicted from the cache, by reason",
+	}, []string{"reason"})
+	identityCacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chrome_service_identity_cache_invalidations_total",
+		Help: "Number of cross replica identity invalidations, by direction",
+	}, []string{"direction"})
+	identityCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "chrome_service_identity_cache_entries",
+		Help: "Number of user identities currently held by the local cache",
+	})
+)
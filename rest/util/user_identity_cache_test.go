@@ -1,16 +1,19 @@
 package util_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/RedHatInsights/chrome-service-backend/rest/models"
 	"github.com/RedHatInsights/chrome-service-backend/rest/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCache(t *testing.T) {
-	util.InitUserIdentitiesCache()
+	util.InitUserIdentitiesCache(util.IdentityCacheOptions{})
 	t.Run("Test Cache Set", func(t *testing.T) {
 		testIdentity := models.UserIdentity{
 			AccountId: "test",
@@ -31,24 +34,140 @@ func TestCache(t *testing.T) {
 	})
 
 	t.Run("Test Cache Expire", func(t *testing.T) {
+		cache := util.NewLRUIdentityCache(util.IdentityCacheOptions{TTL: time.Millisecond})
+		defer cache.Close()
 		testIdentity := models.UserIdentity{
 			AccountId: "expired",
 		}
-		util.UsersCache.Set("expired", testIdentity)
+		cache.Set("expired", testIdentity)
 
-		identity, ok := util.UsersCache.Get("expired")
+		time.Sleep(time.Millisecond * 5)
+
+		identity, ok := cache.Get("expired")
+		assert.False(t, ok)
+		assert.Equal(t, models.UserIdentity{}, identity)
+		assert.Equal(t, 0, cache.Len())
+	})
+}
+
+func TestLRUIdentityCache(t *testing.T) {
+	t.Run("Should evict least recently used entry when full", func(t *testing.T) {
+		cache := util.NewLRUIdentityCache(util.IdentityCacheOptions{MaxEntries: 2})
+		defer cache.Close()
+
+		cache.Set("1", models.UserIdentity{AccountId: "1"})
+		cache.Set("2", models.UserIdentity{AccountId: "2"})
+		// mark "1" as recently used
+		_, ok := cache.Get("1")
+		require.True(t, ok)
+		cache.Set("3", models.UserIdentity{AccountId: "3"})
+
+		assert.Equal(t, 2, cache.Len())
+		_, ok = cache.Get("2")
+		assert.False(t, ok)
+		_, ok = cache.Get("1")
 		assert.True(t, ok)
-		assert.Equal(t, "expired", identity.AccountId)
-		assert.Equal(t, identity, testIdentity)
+		_, ok = cache.Get("3")
+		assert.True(t, ok)
+	})
+
+	t.Run("Should replace existing entry without growing", func(t *testing.T) {
+		cache := util.NewLRUIdentityCache(util.IdentityCacheOptions{MaxEntries: 2})
+		defer cache.Close()
+
+		cache.Set("1", models.UserIdentity{AccountId: "1", ActiveWorkspace: "a"})
+		cache.Refresh("1", models.UserIdentity{AccountId: "1", ActiveWorkspace: "b"})
+
+		identity, ok := cache.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, "b", identity.ActiveWorkspace)
+		assert.Equal(t, 1, cache.Len())
+	})
 
-		// Expire the cache by force
-		util.UsersCache.Identities["expired"] = util.CacheEntry{
-			ExpireAt: util.UsersCache.Identities["expired"].ExpireAt.Add(-time.Minute * 2),
-			Identity: testIdentity,
+	t.Run("Janitor should remove expired entries", func(t *testing.T) {
+		cache := util.NewLRUIdentityCache(util.IdentityCacheOptions{
+			TTL:             time.Millisecond,
+			JanitorInterval: time.Millisecond * 5,
+		})
+		defer cache.Close()
+
+		for i := 0; i < 10; i++ {
+			id := fmt.Sprint(i)
+			cache.Set(id, models.UserIdentity{AccountId: id})
 		}
 
-		identity, ok = util.UsersCache.Get("expired")
+		assert.Eventually(t, func() bool {
+			return cache.Len() == 0
+		}, time.Second, time.Millisecond*5)
+	})
+}
+
+// fakeInvalidationBus delivers published messages to every subscriber synchronously.
+type fakeInvalidationBus struct {
+	sync.Mutex
+	handlers  []func(message util.IdentityInvalidation)
+	published []util.IdentityInvalidation
+}
+
+func (b *fakeInvalidationBus) Publish(message util.IdentityInvalidation) error {
+	b.Lock()
+	b.published = append(b.published, message)
+	handlers := b.handlers
+	b.Unlock()
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (b *fakeInvalidationBus) Subscribe(handler func(message util.IdentityInvalidation)) error {
+	b.Lock()
+	defer b.Unlock()
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+func (b *fakeInvalidationBus) Close() error {
+	return nil
+}
+
+func TestSharedIdentityCache(t *testing.T) {
+	bus := &fakeInvalidationBus{}
+	replicaA, err := util.NewSharedIdentityCache(util.NewLRUIdentityCache(util.IdentityCacheOptions{}), bus)
+	require.NoError(t, err)
+	defer replicaA.Close()
+	replicaB, err := util.NewSharedIdentityCache(util.NewLRUIdentityCache(util.IdentityCacheOptions{}), bus)
+	require.NoError(t, err)
+	defer replicaB.Close()
+
+	t.Run("Set should not publish invalidation", func(t *testing.T) {
+		replicaA.Set("user", models.UserIdentity{AccountId: "user"})
+		replicaB.Set("user", models.UserIdentity{AccountId: "user"})
+
+		assert.Empty(t, bus.published)
+		_, ok := replicaB.Get("user")
+		assert.True(t, ok)
+	})
+
+	t.Run("Refresh should invalidate other replicas", func(t *testing.T) {
+		replicaA.Refresh("user", models.UserIdentity{AccountId: "user", ActiveWorkspace: "new"})
+
+		assert.Len(t, bus.published, 1)
+		identity, ok := replicaA.Get("user")
+		assert.True(t, ok)
+		assert.Equal(t, "new", identity.ActiveWorkspace)
+
+		_, ok = replicaB.Get("user")
+		assert.False(t, ok)
+	})
+
+	t.Run("Delete should invalidate other replicas", func(t *testing.T) {
+		replicaB.Set("user", models.UserIdentity{AccountId: "user"})
+		replicaA.Delete("user")
+
+		_, ok := replicaA.Get("user")
+		assert.False(t, ok)
+		_, ok = replicaB.Get("user")
 		assert.False(t, ok)
-		assert.Equal(t, models.UserIdentity{}, identity)
 	})
 }
@@ -79,3 +79,19 @@ func ParseJWTToken(tokenString string) (DecodedToken, error) {
 
 	return res, nil
 }
+
+// PrincipalID returns the ID of whoever made the request: the user ID of users, the client ID of
+// service accounts and the email of associates. It is empty when the identity has none of them.
+func PrincipalID(id *identity.XRHID) string {
+	switch {
+	case id == nil:
+		return ""
+	case id.Identity.User != nil:
+		return id.Identity.User.UserID
+	case id.Identity.ServiceAccount != nil:
+		return id.Identity.ServiceAccount.ClientId
+	case id.Identity.Associate != nil:
+		return id.Identity.Associate.Email
+	}
+	return ""
+}
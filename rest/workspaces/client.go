@@ -0,0 +1,119 @@
+// Package workspaces looks up workspaces in the workspace service on behalf of the requesting user.
+package workspaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+)
+
+var (
+	// ErrNotFound is returned for workspaces that do not exist, or were deleted.
+	ErrNotFound = errors.New("workspace not found")
+	// ErrForbidden is returned for workspaces the user has no access to.
+	ErrForbidden = errors.New("workspace access denied")
+)
+
+// listWorkspacesPageSize is the number of workspaces requested per page when listing workspaces.
+const listWorkspacesPageSize = 1000
+
+// Client looks up workspaces with the identity of the requesting user, so the result reflects the user's access.
+type Client interface {
+	GetWorkspace(ctx context.Context, identityHeader string, id string) (models.Workspace, error)
+	// ListWorkspaces returns every workspace the user can access.
+	ListWorkspaces(ctx context.Context, identityHeader string) ([]models.Workspace, error)
+}
+
+type workspacesPage struct {
+	Meta struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+	Data []models.Workspace `json:"data"`
+}
+
+// HTTPClient reads workspaces from the RBAC v2 workspaces API.
+type HTTPClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewHTTPClient(baseURL string, client *http.Client) *HTTPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPClient{BaseURL: baseURL, HTTPClient: client}
+}
+
+func (c *HTTPClient) GetWorkspace(ctx context.Context, identityHeader string, id string) (models.Workspace, error) {
+	var workspace models.Workspace
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/rbac/v2/workspaces/%s/", c.BaseURL, url.PathEscape(id)), nil)
+	if err != nil {
+		return workspace, err
+	}
+	request.Header.Set("x-rh-identity", identityHeader)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return workspace, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return workspace, ErrNotFound
+	case http.StatusForbidden:
+		return workspace, ErrForbidden
+	default:
+		return workspace, fmt.Errorf("unexpected workspace service response %d for workspace %s", response.StatusCode, id)
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&workspace); err != nil {
+		return workspace, fmt.Errorf("unable to decode workspace %s: %w", id, err)
+	}
+	return workspace, nil
+}
+
+func (c *HTTPClient) ListWorkspaces(ctx context.Context, identityHeader string) ([]models.Workspace, error) {
+	workspaces := []models.Workspace{}
+	for {
+		page, err := c.listWorkspacesPage(ctx, identityHeader, len(workspaces))
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, page.Data...)
+		if len(page.Data) == 0 || len(workspaces) >= page.Meta.Count {
+			return workspaces, nil
+		}
+	}
+}
+
+func (c *HTTPClient) listWorkspacesPage(ctx context.Context, identityHeader string, offset int) (workspacesPage, error) {
+	var page workspacesPage
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/rbac/v2/workspaces/?limit=%d&offset=%d", c.BaseURL, listWorkspacesPageSize, offset), nil)
+	if err != nil {
+		return page, err
+	}
+	request.Header.Set("x-rh-identity", identityHeader)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return page, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("unexpected workspace service response %d when listing workspaces", response.StatusCode)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("unable to decode workspaces: %w", err)
+	}
+	return page, nil
+}
@@ -0,0 +1,88 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientGetWorkspace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-rh-identity") != "identity" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/rbac/v2/workspaces/available/":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"available","parent_id":"root","type":"standard","name":"Available"}`))
+		case "/api/rbac/v2/workspaces/forbidden/":
+			w.WriteHeader(http.StatusForbidden)
+		case "/api/rbac/v2/workspaces/broken/":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, server.Client())
+
+	workspace, err := client.GetWorkspace(context.Background(), "identity", "available")
+	require.NoError(t, err)
+	assert.Equal(t, "Available", workspace.Name)
+	assert.Equal(t, "root", workspace.ParentId)
+
+	_, err = client.GetWorkspace(context.Background(), "identity", "forbidden")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.GetWorkspace(context.Background(), "identity", "deleted")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = client.GetWorkspace(context.Background(), "identity", "broken")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden), "unexpected responses are not treated as missing workspaces")
+}
+
+func TestHTTPClientListWorkspaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/rbac/v2/workspaces/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("x-rh-identity") != "identity" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			w.Write([]byte(`{"meta":{"count":3},"data":[{"id":"first"},{"id":"second"}]}`))
+		default:
+			w.Write([]byte(`{"meta":{"count":3},"data":[{"id":"third"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, server.Client())
+
+	workspaces, err := client.ListWorkspaces(context.Background(), "identity")
+	require.NoError(t, err)
+	ids := []string{}
+	for _, workspace := range workspaces {
+		ids = append(ids, workspace.Id)
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, ids)
+
+	_, err = client.ListWorkspaces(context.Background(), "other")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden), "failed listings are not treated as missing workspaces")
+
+	_, err = NewHTTPClient(server.URL+"/misconfigured", server.Client()).ListWorkspaces(context.Background(), "identity")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package workspaces
+
+import (
+	"context"
+	"sync"
+
+	"github.com/RedHatInsights/chrome-service-backend/rest/models"
+)
+
+// FakeClient is an in memory Client for tests. Workspaces missing from Workspaces are not found, and
+// workspaces listed in Forbidden are denied and not listed. Err, when set, is returned for every lookup.
+// Calls counts the requests made to the workspace service.
+type FakeClient struct {
+	mu         sync.Mutex
+	Workspaces map[string]models.Workspace
+	Forbidden  map[string]bool
+	Err        error
+	Calls      int
+}
+
+func NewFakeClient(workspaces ...models.Workspace) *FakeClient {
+	fake := &FakeClient{Workspaces: map[string]models.Workspace{}, Forbidden: map[string]bool{}}
+	for _, workspace := range workspaces {
+		fake.Workspaces[workspace.Id] = workspace
+	}
+	return fake
+}
+
+func (f *FakeClient) GetWorkspace(ctx context.Context, identityHeader string, id string) (models.Workspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls++
+	if f.Err != nil {
+		return models.Workspace{}, f.Err
+	}
+	if f.Forbidden[id] {
+		return models.Workspace{}, ErrForbidden
+	}
+	workspace, ok := f.Workspaces[id]
+	if !ok {
+		return models.Workspace{}, ErrNotFound
+	}
+	return workspace, nil
+}
+
+func (f *FakeClient) ListWorkspaces(ctx context.Context, identityHeader string) ([]models.Workspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls++
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	workspaces := []models.Workspace{}
+	for id, workspace := range f.Workspaces {
+		if !f.Forbidden[id] {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+	return workspaces, nil
+}
+
+// Set adds or replaces a workspace.
+func (f *FakeClient) Set(workspace models.Workspace) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Workspaces[workspace.Id] = workspace
+}
+
+// Remove deletes a workspace.
+func (f *FakeClient) Remove(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.Workspaces, id)
+}